@@ -4,19 +4,25 @@ import (
 	"context"
 	"github.com/marrgancovka/pvzService/internal/config"
 	"github.com/marrgancovka/pvzService/internal/pkg/db"
+	"github.com/marrgancovka/pvzService/internal/pkg/gateway"
 	"github.com/marrgancovka/pvzService/internal/pkg/grpcconn"
+	"github.com/marrgancovka/pvzService/internal/pkg/idempotency"
 	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
 	"github.com/marrgancovka/pvzService/internal/pkg/logger"
 	"github.com/marrgancovka/pvzService/internal/pkg/metrics"
 	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/pkg/notifier"
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
 	"github.com/marrgancovka/pvzService/internal/pkg/servers/mainServer"
 	"github.com/marrgancovka/pvzService/internal/pkg/servers/metricsServer"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
 	"github.com/marrgancovka/pvzService/internal/services/auth"
 	authHandler "github.com/marrgancovka/pvzService/internal/services/auth/delivery/http"
 	authRepository "github.com/marrgancovka/pvzService/internal/services/auth/repo"
 	authUsecase "github.com/marrgancovka/pvzService/internal/services/auth/usecase"
 	"github.com/marrgancovka/pvzService/internal/services/pvz"
 	pvzHandler "github.com/marrgancovka/pvzService/internal/services/pvz/delivery/http"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/events"
 	pvzRepository "github.com/marrgancovka/pvzService/internal/services/pvz/repo"
 	pvzUsecase "github.com/marrgancovka/pvzService/internal/services/pvz/usecase"
 	"github.com/marrgancovka/pvzService/migrations"
@@ -33,6 +39,7 @@ func main() {
 	app := fx.New(
 		fx.Provide(
 			logger.SetupLogger,
+			logger.NewLevelHandler,
 			builder.SetupBuilder,
 			mainServer.NewRouter,
 			func() config.ConfigPath {
@@ -42,21 +49,32 @@ func main() {
 			fx.Annotate(jwter.New, fx.As(new(auth.JWTer))),
 
 			grpcconn.Provide,
+			gateway.New,
 
 			fx.Annotate(metrics.New, fx.As(new(metrics.Metrics))),
+			tracing.New,
 			middleware.NewAuthMiddleware,
 			middleware.NewMetricsMiddleware,
+			middleware.NewRequestIDMiddleware,
+			middleware.NewTracingMiddleware,
+			middleware.NewIdempotencyMiddleware,
+			middleware.NewRBACMiddleware,
+			rbac.NewPolicy,
+			notifier.New,
+
+			fx.Annotate(idempotency.NewPostgresStore, fx.As(fx.Self()), fx.As(new(idempotency.Store))),
 
 			db.NewPostgresPool,
 			db.NewPostgresConnect,
 
 			authHandler.NewHandler,
-			fx.Annotate(authUsecase.NewUsecase, fx.As(new(auth.Usecase))),
+			fx.Annotate(authUsecase.NewUsecase, fx.As(new(auth.Usecase)), fx.As(new(auth.APIKeyAuthenticator)), fx.As(new(auth.SessionChecker))),
 			fx.Annotate(authRepository.NewRepository, fx.As(new(auth.Repository))),
 
 			pvzHandler.NewHandler,
 			fx.Annotate(pvzUsecase.NewUsecase, fx.As(new(pvz.Usecase))),
 			fx.Annotate(pvzRepository.NewRepository, fx.As(new(pvz.Repository))),
+			fx.Annotate(events.NewBus, fx.As(fx.Self()), fx.As(new(pvz.EventBus)), fx.As(new(pvz.EventPublisher))),
 		),
 		fx.WithLogger(func(logger *slog.Logger) fxevent.Logger {
 			return &fxevent.SlogLogger{Logger: logger}
@@ -66,6 +84,8 @@ func main() {
 			mainServer.RunServer,
 			migrations.RunMigrations,
 			metricsServer.RunServer,
+			idempotency.RunCleanup,
+			events.Run,
 		),
 	)
 
@@ -4,10 +4,21 @@ import (
 	"context"
 	"github.com/marrgancovka/pvzService/internal/config"
 	"github.com/marrgancovka/pvzService/internal/pkg/db"
+	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
 	"github.com/marrgancovka/pvzService/internal/pkg/logger"
+	"github.com/marrgancovka/pvzService/internal/pkg/metrics"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/pkg/notifier"
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
 	"github.com/marrgancovka/pvzService/internal/pkg/servers/grpcServer"
+	"github.com/marrgancovka/pvzService/internal/pkg/servers/metricsServer"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	authRepository "github.com/marrgancovka/pvzService/internal/services/auth/repo"
+	authUsecase "github.com/marrgancovka/pvzService/internal/services/auth/usecase"
 	"github.com/marrgancovka/pvzService/internal/services/pvz"
 	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/events"
 	pvzRepository "github.com/marrgancovka/pvzService/internal/services/pvz/repo"
 	pvzUsecase "github.com/marrgancovka/pvzService/internal/services/pvz/usecase"
 	"github.com/marrgancovka/pvzService/pkg/builder"
@@ -30,9 +41,19 @@ func main() {
 			config.MustLoad,
 
 			db.NewPostgresPool,
+			fx.Annotate(metrics.New, fx.As(new(metrics.Metrics))),
+			tracing.New,
+			fx.Annotate(jwter.New, fx.As(new(auth.JWTer))),
+			middleware.NewAuthMiddleware,
+			middleware.NewRBACMiddleware,
+			rbac.NewPolicy,
+			notifier.New,
+			fx.Annotate(authUsecase.NewUsecase, fx.As(new(auth.APIKeyAuthenticator)), fx.As(new(auth.SessionChecker))),
+			fx.Annotate(authRepository.NewRepository, fx.As(new(auth.Repository))),
 			grpc.NewHandler,
 			fx.Annotate(pvzUsecase.NewUsecase, fx.As(new(pvz.Usecase))),
 			fx.Annotate(pvzRepository.NewRepository, fx.As(new(pvz.Repository))),
+			fx.Annotate(events.NewBus, fx.As(fx.Self()), fx.As(new(pvz.EventBus)), fx.As(new(pvz.EventPublisher))),
 		),
 		fx.WithLogger(func(logger *slog.Logger) fxevent.Logger {
 			return &fxevent.SlogLogger{Logger: logger}
@@ -40,6 +61,8 @@ func main() {
 
 		fx.Invoke(
 			grpcServer.RunServer,
+			metricsServer.RunServer,
+			events.Run,
 		),
 	)
 
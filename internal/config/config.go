@@ -1,24 +1,43 @@
 package config
 
 import (
+	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
 	_ "github.com/joho/godotenv/autoload"
+	"github.com/marrgancovka/pvzService/internal/config/profile"
 	"github.com/marrgancovka/pvzService/internal/pkg/db"
 	"github.com/marrgancovka/pvzService/internal/pkg/grpcconn"
+	"github.com/marrgancovka/pvzService/internal/pkg/idempotency"
 	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
+	"github.com/marrgancovka/pvzService/internal/pkg/logger"
+	"github.com/marrgancovka/pvzService/internal/pkg/notifier"
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
 	"github.com/marrgancovka/pvzService/internal/pkg/servers/grpcServer"
 	"github.com/marrgancovka/pvzService/internal/pkg/servers/mainServer"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/events"
 	"go.uber.org/fx"
 	"log"
 	"os"
 )
 
 type Config struct {
-	HTTPServer    mainServer.Config `yaml:"httpServer"`
-	GRPCServer    grpcServer.Config `yaml:"grpcServer"`
-	PvzGRPCClient grpcconn.Config   `yaml:"pvzGRPCClient"`
-	DB            db.Config         `yaml:"db"`
-	Jwt           jwter.Config      `yaml:"jwt"`
+	// Environment is an alias of logger.environment, which selects the
+	// profile. Without either the service runs as prod.
+	Environment   profile.Profile    `yaml:"environment" env:"APP_ENV"`
+	HTTPServer    mainServer.Config  `yaml:"httpServer"`
+	GRPCServer    grpcServer.Config  `yaml:"grpcServer"`
+	PvzGRPCClient grpcconn.Config    `yaml:"pvzGRPCClient"`
+	DB            db.Config          `yaml:"db"`
+	Jwt           jwter.Config       `yaml:"jwt"`
+	Idempotency   idempotency.Config `yaml:"idempotency"`
+	Auth          auth.Config        `yaml:"auth"`
+	RBAC          rbac.Config        `yaml:"rbac"`
+	Notifier      notifier.Config    `yaml:"notifier"`
+	Events        events.Config      `yaml:"events"`
+	Tracing       tracing.Config     `yaml:"tracing"`
+	Logger        logger.Config      `yaml:"logger"`
 }
 
 type ConfigPath string
@@ -32,11 +51,19 @@ type In struct {
 type Out struct {
 	fx.Out
 
+	Environment   profile.Profile
 	HTTPServer    mainServer.Config
 	GRPCServer    grpcServer.Config
 	PvzGRPCClient grpcconn.Config
 	DB            db.Config
 	Jwt           jwter.Config
+	Idempotency   idempotency.Config
+	Auth          auth.Config
+	RBAC          rbac.Config
+	Notifier      notifier.Config
+	Events        events.Config
+	Tracing       tracing.Config
+	Logger        logger.Config
 }
 
 func MustLoad(in In) Out {
@@ -57,11 +84,52 @@ func MustLoad(in In) Out {
 		os.Exit(1)
 	}
 
+	env, err := resolveEnvironment(cfg)
+	if err != nil {
+		log.Print(err)
+		os.Exit(1)
+	}
+	cfg.Environment = env
+
+	if !cfg.Environment.IsValid() {
+		log.Printf("unknown environment: %s", cfg.Environment)
+		os.Exit(1)
+	}
+
+	if cfg.Environment.IsProduction() && cfg.Auth.DummyLogin {
+		log.Printf("dummy login must be disabled in %s environment", cfg.Environment)
+		os.Exit(1)
+	}
+
 	return Out{
+		Environment:   cfg.Environment,
 		HTTPServer:    cfg.HTTPServer,
 		GRPCServer:    cfg.GRPCServer,
 		PvzGRPCClient: cfg.PvzGRPCClient,
 		DB:            cfg.DB,
 		Jwt:           cfg.Jwt,
+		Idempotency:   cfg.Idempotency,
+		Auth:          cfg.Auth,
+		RBAC:          cfg.RBAC,
+		Notifier:      cfg.Notifier,
+		Events:        cfg.Events,
+		Tracing:       cfg.Tracing,
+		Logger:        cfg.Logger,
+	}
+}
+
+// resolveEnvironment picks the profile from logger.environment, falling back
+// to the top-level environment key.
+func resolveEnvironment(cfg Config) (profile.Profile, error) {
+	switch {
+	case cfg.Logger.Environment != "" && cfg.Environment != "" && cfg.Logger.Environment != cfg.Environment:
+		return "", fmt.Errorf("conflicting environments: logger.environment is %s, environment is %s",
+			cfg.Logger.Environment, cfg.Environment)
+	case cfg.Logger.Environment != "":
+		return cfg.Logger.Environment, nil
+	case cfg.Environment != "":
+		return cfg.Environment, nil
+	default:
+		return profile.Prod, nil
 	}
 }
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"github.com/marrgancovka/pvzService/internal/config/profile"
+	"github.com/marrgancovka/pvzService/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestResolveEnvironment(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      Config
+		expected profile.Profile
+		wantErr  bool
+	}{
+		{name: "default", expected: profile.Prod},
+		{name: "logger environment", cfg: Config{Logger: logger.Config{Environment: profile.Local}}, expected: profile.Local},
+		{name: "alias", cfg: Config{Environment: profile.Dev}, expected: profile.Dev},
+		{
+			name:     "both equal",
+			cfg:      Config{Environment: profile.Test, Logger: logger.Config{Environment: profile.Test}},
+			expected: profile.Test,
+		},
+		{
+			name:    "conflict",
+			cfg:     Config{Environment: profile.Local, Logger: logger.Config{Environment: profile.Prod}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, err := resolveEnvironment(tt.cfg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, env)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package profile
+
+// Profile is the environment the service runs in. Development conveniences
+// such as dummy login are only available outside production.
+type Profile string
+
+const (
+	Local Profile = "local"
+	Dev   Profile = "dev"
+	Test  Profile = "test"
+	Prod  Profile = "prod"
+)
+
+func (p Profile) IsValid() bool {
+	switch p {
+	case Local, Dev, Test, Prod:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p Profile) IsProduction() bool {
+	return p == Prod
+}
+
+// AllowsDummyLogin reports whether the profile may expose /dummyLogin.
+func (p Profile) AllowsDummyLogin() bool {
+	switch p {
+	case Local, Dev, Test:
+		return true
+	default:
+		return false
+	}
+}
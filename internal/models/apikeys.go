@@ -0,0 +1,74 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type APIKey struct {
+	ID         uuid.UUID   `json:"id"`
+	Name       string      `json:"name"`
+	Prefix     string      `json:"prefix"`
+	Hash       string      `json:"-"`
+	Role       Role        `json:"role"`
+	Scopes     []string    `json:"scopes"`
+	PvzIDs     []uuid.UUID `json:"pvzIds"`
+	CreatedBy  *uuid.UUID  `json:"createdBy,omitempty"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	ExpiresAt  *time.Time  `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time  `json:"revokedAt,omitempty"`
+	LastUsedAt *time.Time  `json:"lastUsedAt,omitempty"`
+}
+
+type APIKeyRequest struct {
+	Name      string      `json:"name"`
+	Role      Role        `json:"role"`
+	Scopes    []string    `json:"scopes"`
+	PvzIDs    []uuid.UUID `json:"pvzIds"`
+	ExpiresAt *time.Time  `json:"expiresAt"`
+}
+
+// IssuedAPIKey carries the plain key, which is shown only once on creation.
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// Principal is the authenticated caller, either a user with a JWT or
+// a machine client with an API key.
+type Principal struct {
+	ID        uuid.UUID
+	Role      Role
+	APIKeyID  uuid.UUID
+	Scopes    []string
+	PvzIDs    []uuid.UUID
+	ExpiresAt *time.Time
+}
+
+// HasScope reports whether the principal may use the scope. Principals
+// without explicit scopes are limited only by their role.
+func (p *Principal) HasScope(scope string) bool {
+	if len(p.Scopes) == 0 {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope || s == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessPvz reports whether the principal may act on the pvz. Principals
+// without PVZ restrictions may access any pvz.
+func (p *Principal) CanAccessPvz(pvzID uuid.UUID) bool {
+	if len(p.PvzIDs) == 0 {
+		return true
+	}
+	for _, id := range p.PvzIDs {
+		if id == pvzID {
+			return true
+		}
+	}
+	return false
+}
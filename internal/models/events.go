@@ -0,0 +1,60 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type PvzEventType string
+
+const (
+	EventReceptionOpened PvzEventType = "reception_opened"
+	EventReceptionClosed PvzEventType = "reception_closed"
+	EventProductAdded    PvzEventType = "product_added"
+	EventProductRemoved  PvzEventType = "product_removed"
+)
+
+// PvzEvent is a change of a reception or its products. IDs grow
+// monotonically and can be used to resume a feed.
+type PvzEvent struct {
+	ID          int64        `json:"id"`
+	Type        PvzEventType `json:"type"`
+	PvzID       uuid.UUID    `json:"pvzId"`
+	City        City         `json:"city"`
+	ReceptionID uuid.UUID    `json:"receptionId"`
+	ProductID   *uuid.UUID   `json:"productId,omitempty"`
+	ProductType ProductType  `json:"productType,omitempty"`
+	CreatedAt   time.Time    `json:"createdAt"`
+}
+
+// PvzEventFilter selects events by pvz and city, empty lists match
+// everything. AfterID resumes the feed after the last seen event.
+type PvzEventFilter struct {
+	PvzIDs  []uuid.UUID
+	Cities  []City
+	AfterID int64
+}
+
+func (f *PvzEventFilter) Match(event *PvzEvent) bool {
+	if len(f.PvzIDs) > 0 && !containsID(f.PvzIDs, event.PvzID) {
+		return false
+	}
+	if len(f.Cities) > 0 {
+		for _, city := range f.Cities {
+			if city == event.City {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type ExportFormat string
+
+const (
+	FormatCSV  ExportFormat = "csv"
+	FormatXLSX ExportFormat = "xlsx"
+)
+
+type ExportFilter struct {
+	StartDate   time.Time
+	EndDate     time.Time
+	City        City
+	PvzID       uuid.UUID
+	Status      ReceptionType
+	ProductType ProductType
+	// PvzIDs restricts the rows to these pvz, empty means all of them.
+	PvzIDs []uuid.UUID
+}
+
+type ExportRow struct {
+	PvzID               uuid.UUID
+	PvzCity             City
+	PvzRegistrationDate time.Time
+	ReceptionID         uuid.UUID
+	ReceptionDateTime   time.Time
+	ReceptionStatus     ReceptionType
+	ReceptionClosedAt   *time.Time
+	ProductID           *uuid.UUID
+	ProductDateTime     *time.Time
+	ProductType         *ProductType
+}
+
+func (format ExportFormat) IsValid() bool {
+	switch format {
+	case FormatCSV, FormatXLSX:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type LoginAttemptKind string
+
+const (
+	LoginAttemptEmail   LoginAttemptKind = "email"
+	LoginAttemptAccount LoginAttemptKind = "account"
+	LoginAttemptIP      LoginAttemptKind = "ip"
+)
+
+// LoginAttemptKey identifies a failed login counter: per account email and
+// client ip, per account email alone or per client ip alone. ClientIP is
+// only set for the email kind, so that failures from one client don't lock
+// the account for everybody else before the higher account-wide threshold
+// catches guesses spread over many clients.
+type LoginAttemptKey struct {
+	Kind     LoginAttemptKind
+	Subject  string
+	ClientIP string
+}
+
+type LoginAttempts struct {
+	Failures    int
+	LockedUntil *time.Time
+}
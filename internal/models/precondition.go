@@ -0,0 +1,15 @@
+package models
+
+import "slices"
+
+// IfMatch is the precondition of a conditional update. Any ("*") only
+// requires the resource to exist, otherwise its current version has to be
+// one of Versions.
+type IfMatch struct {
+	Any      bool
+	Versions []int64
+}
+
+func (m IfMatch) Matches(version int64) bool {
+	return m.Any || slices.Contains(m.Versions, version)
+}
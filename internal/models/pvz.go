@@ -17,15 +17,42 @@ type Pvz struct {
 	ID               uuid.UUID `json:"id"`
 	RegistrationDate time.Time `json:"registrationDate"`
 	City             City      `json:"city"`
+	Address          string    `json:"address,omitempty"`
+	Version          int64     `json:"-"`
 }
 
 type PvzWithReceptions struct {
 	ID               uuid.UUID                `json:"id"`
 	RegistrationDate time.Time                `json:"registrationDate"`
 	City             City                     `json:"city"`
+	Address          string                   `json:"address,omitempty"`
 	Receptions       []*ReceptionWithProducts `json:"receptions"`
 }
 
+// PvzImportColumns is the number of columns in an import file: id, city, registration date, address.
+const PvzImportColumns = 4
+
+type PvzImportRow struct {
+	Line             int
+	FieldCount       int
+	ID               string
+	City             string
+	RegistrationDate string
+	Address          string
+}
+
+type PvzImportLineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+type PvzImportReport struct {
+	DryRun   bool                  `json:"dryRun"`
+	Total    int                   `json:"total"`
+	Imported int                   `json:"imported"`
+	Errors   []*PvzImportLineError `json:"errors"`
+}
+
 func (city City) IsValid() bool {
 	switch city {
 	case CitySpb, CityMoscow, CityKazan:
@@ -17,6 +17,8 @@ type Reception struct {
 	DateTime time.Time     `json:"dateTime"`
 	PvzID    uuid.UUID     `json:"pvzId"`
 	Status   ReceptionType `json:"status"`
+	ClosedAt *time.Time    `json:"closedAt,omitempty"`
+	Version  int64         `json:"-"`
 }
 
 type ReceptionRequest struct {
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Me describes the caller of GET /me.
+type Me struct {
+	ID        uuid.UUID   `json:"id"`
+	Email     string      `json:"email,omitempty"`
+	Role      Role        `json:"role"`
+	PvzIDs    []uuid.UUID `json:"pvzIds"`
+	APIKeyID  *uuid.UUID  `json:"apiKeyId,omitempty"`
+	Scopes    []string    `json:"scopes,omitempty"`
+	ExpiresAt *time.Time  `json:"expiresAt,omitempty"`
+}
+
+// TokenIntrospection is the response of the token introspection endpoint
+// (RFC 7662). Only Active is set for tokens that are not accepted.
+type TokenIntrospection struct {
+	Active    bool        `json:"active"`
+	Subject   string      `json:"sub,omitempty"`
+	Username  string      `json:"username,omitempty"`
+	Role      Role        `json:"role,omitempty"`
+	PvzIDs    []uuid.UUID `json:"pvz_ids,omitempty"`
+	TokenType string      `json:"token_type,omitempty"`
+	Exp       int64       `json:"exp,omitempty"`
+	Iat       int64       `json:"iat,omitempty"`
+	Jti       string      `json:"jti,omitempty"`
+}
+
+type UserPvzRequest struct {
+	PvzIDs []uuid.UUID `json:"pvzIds"`
+}
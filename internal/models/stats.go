@@ -0,0 +1,62 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+type StatsPeriod string
+
+const (
+	PeriodDay   StatsPeriod = "day"
+	PeriodWeek  StatsPeriod = "week"
+	PeriodMonth StatsPeriod = "month"
+)
+
+type StatsGroup string
+
+const (
+	GroupByPvz         StatsGroup = "pvz"
+	GroupByCity        StatsGroup = "city"
+	GroupByProductType StatsGroup = "type"
+)
+
+type StatsFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+	Period    StatsPeriod
+	GroupBy   []StatsGroup
+	City      City
+	PvzID     uuid.UUID
+	// PvzIDs restricts the rows to these pvz, empty means all of them.
+	PvzIDs []uuid.UUID
+}
+
+type StatsRow struct {
+	Period                      time.Time   `json:"period"`
+	PvzID                       *uuid.UUID  `json:"pvzId,omitempty"`
+	City                        City        `json:"city,omitempty"`
+	ProductType                 ProductType `json:"productType,omitempty"`
+	ReceptionsCount             int64       `json:"receptionsCount"`
+	ProductsCount               int64       `json:"productsCount"`
+	AvgReceptionDurationSeconds *float64    `json:"avgReceptionDurationSeconds"`
+	ProductsPerReception        float64     `json:"productsPerReception"`
+}
+
+func (period StatsPeriod) IsValid() bool {
+	switch period {
+	case PeriodDay, PeriodWeek, PeriodMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+func (group StatsGroup) IsValid() bool {
+	switch group {
+	case GroupByPvz, GroupByCity, GroupByProductType:
+		return true
+	default:
+		return false
+	}
+}
@@ -10,7 +10,30 @@ type Token struct {
 }
 
 type TokenPayload struct {
-	ID   uuid.UUID
-	Role Role
-	Exp  time.Time
+	ID       uuid.UUID
+	Role     Role
+	Exp      time.Time
+	IssuedAt time.Time
+	TokenID  string
+	// Dummy marks tokens issued by /dummyLogin.
+	Dummy bool
+	// Version is the user's token version at the time of issue.
+	Version int
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
 }
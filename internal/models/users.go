@@ -1,28 +1,74 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"github.com/google/uuid"
+	"time"
+)
 
 type Role string
 
 const (
-	RoleEmployee  Role = "employee"
-	RoleModerator Role = "moderator"
+	RoleEmployee   Role = "employee"
+	RoleModerator  Role = "moderator"
+	RoleSupervisor Role = "supervisor"
+	RoleAuditor    Role = "auditor"
 )
 
 type Users struct {
-	ID       uuid.UUID `json:"id"`
-	Email    string    `json:"email"`
-	Password string    `json:"password"`
-	Role     Role      `json:"role"`
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Password  string    `json:"password"`
+	Role      Role      `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"createdAt"`
+	// TokenVersion is embedded into issued tokens, bumping it revokes them.
+	TokenVersion int `json:"-"`
+}
+
+// UserInfo is a user representation that is safe to return to clients.
+type UserInfo struct {
+	ID        uuid.UUID `json:"id"`
+	Email     string    `json:"email"`
+	Role      Role      `json:"role"`
+	Disabled  bool      `json:"disabled"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type RoleUpdate struct {
+	Role Role `json:"role"`
+}
+
+type ChangePasswordRequest struct {
+	OldPassword string `json:"oldPassword"`
+	NewPassword string `json:"newPassword"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetConfirm struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
 }
 
 type DummyLogin struct {
 	Role Role `json:"role"`
 }
 
+func (user *Users) Info() *UserInfo {
+	return &UserInfo{
+		ID:        user.ID,
+		Email:     user.Email,
+		Role:      user.Role,
+		Disabled:  user.Disabled,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
 func (role Role) IsValid() bool {
 	switch role {
-	case RoleModerator, RoleEmployee:
+	case RoleModerator, RoleEmployee, RoleSupervisor, RoleAuditor:
 		return true
 	default:
 		return false
@@ -7,6 +7,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jackc/pgx/v5/stdlib"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 	"log/slog"
 )
@@ -14,8 +15,9 @@ import (
 type PostgresParams struct {
 	fx.In
 
-	Cfg    Config
-	Logger *slog.Logger
+	Cfg            Config
+	Logger         *slog.Logger
+	TracerProvider trace.TracerProvider
 }
 
 func getConnStr(cfg *Config) string {
@@ -39,6 +41,7 @@ func NewPostgresPool(p PostgresParams) (*pgxpool.Pool, error) {
 	}
 
 	poolConfig.MaxConns = 10
+	poolConfig.ConnConfig.Tracer = newQueryTracer(p.TracerProvider)
 
 	ctx, cancel := context.WithTimeout(context.Background(), p.Cfg.ConnectTimeout)
 	defer cancel()
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func TestQueryOperation(t *testing.T) {
+	tests := []struct {
+		sql      string
+		expected string
+	}{
+		{sql: "SELECT id FROM pvz", expected: "SELECT"},
+		{sql: "\n  insert into pvz (id) values ($1)", expected: "INSERT"},
+		{sql: "", expected: "query"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, queryOperation(tt.sql))
+	}
+}
+
+func TestQueryTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := newQueryTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "SELECT 1", Args: []any{"secret"}})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{CommandTag: pgconn.NewCommandTag("SELECT 1")})
+
+	ctx = tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{SQL: "UPDATE pvz SET city = $1"})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{Err: errors.New("conflict")})
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "db SELECT", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	for _, attr := range spans[0].Attributes() {
+		assert.NotEqual(t, "secret", attr.Value.Emit())
+	}
+
+	assert.Equal(t, "db UPDATE", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
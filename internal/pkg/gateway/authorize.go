@@ -0,0 +1,139 @@
+package gateway
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	pvzgrpc "github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type pvzRequest interface {
+	GetPvzId() string
+}
+
+type pvzListRequest interface {
+	GetPvzIds() []string
+}
+
+// authorizedConn checks the caller authenticated by AuthMiddleware before
+// the gateway forwards a request to the gRPC service.
+type authorizedConn struct {
+	conn grpc.ClientConnInterface
+	rbac *middleware.RBACMiddleware
+}
+
+func (c *authorizedConn) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	if err := c.authorize(ctx, method); err != nil {
+		return err
+	}
+	if err := checkPvzAccess(ctx, args); err != nil {
+		return err
+	}
+	if err := c.conn.Invoke(ctx, method, args, reply, opts...); err != nil {
+		return err
+	}
+	filterPvzList(ctx, reply)
+	return nil
+}
+
+func (c *authorizedConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	if err := c.authorize(ctx, method); err != nil {
+		return nil, err
+	}
+	stream, err := c.conn.NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &authorizedStream{ClientStream: stream, ctx: ctx}, nil
+}
+
+func (c *authorizedConn) authorize(ctx context.Context, method string) error {
+	permission, ok := pvzgrpc.Permissions[method]
+	if !ok {
+		return status.Error(codes.PermissionDenied, ErrNoPermission.Error())
+	}
+	if !c.rbac.Allowed(ctx, permission) {
+		return status.Error(codes.PermissionDenied, auth.ErrNoAccess.Error())
+	}
+	return nil
+}
+
+// authorizedStream checks the request of a server-streaming call, which is
+// only known once the gateway sends it.
+type authorizedStream struct {
+	grpc.ClientStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) SendMsg(m any) error {
+	if err := checkPvzAccess(s.ctx, m); err != nil {
+		return err
+	}
+	return s.ClientStream.SendMsg(m)
+}
+
+// checkPvzAccess makes principals restricted to some pvz name them in the
+// request, otherwise they would read the data of every pvz.
+func checkPvzAccess(ctx context.Context, req any) error {
+	principal := middleware.PrincipalFromContext(ctx)
+	if principal == nil || len(principal.PvzIDs) == 0 {
+		return nil
+	}
+
+	var ids []string
+	switch r := req.(type) {
+	case *gen.GetPVZListRequest:
+		// names no pvz, the reply is filtered by filterPvzList instead
+		return nil
+	case pvzListRequest:
+		ids = r.GetPvzIds()
+	case pvzRequest:
+		if r.GetPvzId() != "" {
+			ids = []string{r.GetPvzId()}
+		}
+	default:
+		// requests that cannot be scoped to the principal's pvz are denied
+		return status.Error(codes.PermissionDenied, ErrPvzRestricted.Error())
+	}
+
+	if len(ids) == 0 {
+		return status.Error(codes.PermissionDenied, ErrPvzRestricted.Error())
+	}
+	for _, id := range ids {
+		pvzID, err := uuid.Parse(id)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if !principal.CanAccessPvz(pvzID) {
+			return status.Error(codes.PermissionDenied, auth.ErrNoAccess.Error())
+		}
+	}
+	return nil
+}
+
+// filterPvzList drops the pvz a restricted principal has no access to from a
+// list reply.
+func filterPvzList(ctx context.Context, reply any) {
+	principal := middleware.PrincipalFromContext(ctx)
+	if principal == nil || len(principal.PvzIDs) == 0 {
+		return
+	}
+	list, ok := reply.(*gen.GetPVZListResponse)
+	if !ok {
+		return
+	}
+
+	pvzs := list.Pvzs[:0]
+	for _, p := range list.GetPvzs() {
+		pvzID, err := uuid.Parse(p.GetId())
+		if err == nil && principal.CanAccessPvz(pvzID) {
+			pvzs = append(pvzs, p)
+		}
+	}
+	list.Pvzs = pvzs
+}
@@ -0,0 +1,8 @@
+package gateway
+
+import "errors"
+
+var (
+	ErrNoPermission  = errors.New("method is not exposed through the gateway")
+	ErrPvzRestricted = errors.New("request must name the accessible pvz")
+)
@@ -0,0 +1,80 @@
+package gateway
+
+import (
+	"context"
+	_ "embed"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"github.com/marrgancovka/pvzService/pkg/responser"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"log/slog"
+	"net/http"
+)
+
+//go:embed pvz.swagger.json
+var openAPISpec []byte
+
+type Params struct {
+	fx.In
+
+	GRPCClient     *grpc.ClientConn
+	RBACMiddleware *middleware.RBACMiddleware
+	Logger         *slog.Logger
+}
+
+// Gateway transcodes HTTP/JSON requests into calls of the pvz gRPC service.
+// Its routes come from the google.api.http annotations in proto/pvz.proto
+// and all live below /api/gateway.
+type Gateway struct {
+	mux *runtime.ServeMux
+	log *slog.Logger
+}
+
+func New(p Params) (*Gateway, error) {
+	gw := &Gateway{log: p.Logger}
+	gw.mux = runtime.NewServeMux(
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithErrorHandler(gw.handleError),
+	)
+
+	conn := &authorizedConn{conn: p.GRPCClient, rbac: p.RBACMiddleware}
+	if err := gen.RegisterPVZServiceHandlerClient(context.Background(), gw.mux, gen.NewPVZServiceClient(conn)); err != nil {
+		p.Logger.Error("register gateway handlers: " + err.Error())
+		return nil, err
+	}
+	return gw, nil
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// OpenAPI serves the OpenAPI document generated from the proto.
+func (g *Gateway) OpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(openAPISpec)
+}
+
+// handleError writes gRPC errors in the {"msg": ...} shape of the rest of the API.
+func (g *Gateway) handleError(ctx context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	const op = "gateway.Gateway.handleError"
+	logger := logctx.From(r.Context(), g.log).With("op", op)
+
+	st := status.Convert(err)
+	code := runtime.HTTPStatusFromCode(st.Code())
+	if code >= http.StatusInternalServerError {
+		logger.Error("gateway call failed: "+err.Error(), "path", r.URL.Path)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	responser.SendErr(w, code, st.Message())
+}
@@ -0,0 +1,215 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var listedPvz = uuid.New()
+
+type fakeServer struct {
+	gen.UnimplementedPVZServiceServer
+	stats *gen.GetStatsRequest
+}
+
+func (f *fakeServer) GetPVZList(context.Context, *gen.GetPVZListRequest) (*gen.GetPVZListResponse, error) {
+	return &gen.GetPVZListResponse{Pvzs: []*gen.PVZ{
+		{Id: "1", City: "Москва"},
+		{Id: listedPvz.String(), City: "Казань"},
+	}}, nil
+}
+
+func (f *fakeServer) GetStats(_ context.Context, req *gen.GetStatsRequest) (*gen.GetStatsResponse, error) {
+	f.stats = req
+	if req.GetPeriod() == "year" {
+		return nil, status.Error(codes.InvalidArgument, "invalid period")
+	}
+	return &gen.GetStatsResponse{}, nil
+}
+
+func newTestGateway(t *testing.T, server *fakeServer) *Gateway {
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	gen.RegisterPVZServiceServer(srv, server)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	policy, err := rbac.New(rbac.DefaultRoles)
+	require.NoError(t, err)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	gw, err := New(Params{
+		GRPCClient:     conn,
+		RBACMiddleware: middleware.NewRBACMiddleware(middleware.RBACMiddlewareParams{Policy: policy, Logger: logger}),
+		Logger:         logger,
+	})
+	require.NoError(t, err)
+	return gw
+}
+
+func TestGateway(t *testing.T) {
+	restrictedPvz := uuid.New()
+
+	tests := []struct {
+		name           string
+		path           string
+		role           models.Role
+		principal      *models.Principal
+		expectedCode   int
+		expectedBody   string
+		unexpectedBody string
+	}{
+		{
+			name:         "list",
+			path:         "/api/gateway/v1/pvz",
+			role:         models.RoleModerator,
+			expectedCode: http.StatusOK,
+			expectedBody: `"city":"Москва"`,
+		},
+		{
+			name:           "restricted principal lists its pvz",
+			path:           "/api/gateway/v1/pvz",
+			role:           models.RoleModerator,
+			principal:      &models.Principal{Role: models.RoleModerator, PvzIDs: []uuid.UUID{listedPvz}},
+			expectedCode:   http.StatusOK,
+			expectedBody:   listedPvz.String(),
+			unexpectedBody: `"city":"Москва"`,
+		},
+		{
+			name:         "stats",
+			path:         "/api/gateway/v1/stats?period=day&groupBy=city",
+			role:         models.RoleModerator,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "grpc error",
+			path:         "/api/gateway/v1/stats?period=year",
+			role:         models.RoleModerator,
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"msg":"invalid period"}`,
+		},
+		{
+			name:         "no role",
+			path:         "/api/gateway/v1/pvz",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "restricted principal without pvz",
+			path:         "/api/gateway/v1/stats?period=day",
+			role:         models.RoleModerator,
+			principal:    &models.Principal{Role: models.RoleModerator, PvzIDs: []uuid.UUID{restrictedPvz}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:         "restricted principal with its pvz",
+			path:         "/api/gateway/v1/stats?period=day&pvzId=" + restrictedPvz.String(),
+			role:         models.RoleModerator,
+			principal:    &models.Principal{Role: models.RoleModerator, PvzIDs: []uuid.UUID{restrictedPvz}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "unknown route",
+			path:         "/api/gateway/v1/unknown",
+			role:         models.RoleModerator,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &fakeServer{}
+			gw := newTestGateway(t, server)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			ctx := req.Context()
+			if tt.role != "" {
+				ctx = context.WithValue(ctx, middleware.RoleInContext, tt.role)
+			}
+			if tt.principal != nil {
+				ctx = context.WithValue(ctx, middleware.PrincipalInContext, tt.principal)
+			}
+			rec := httptest.NewRecorder()
+
+			gw.ServeHTTP(rec, req.WithContext(ctx))
+
+			assert.Equal(t, tt.expectedCode, rec.Code, rec.Body.String())
+			if tt.expectedBody != "" {
+				assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			}
+			if tt.unexpectedBody != "" {
+				assert.NotContains(t, rec.Body.String(), tt.unexpectedBody)
+			}
+		})
+	}
+}
+
+func TestGateway_OpenAPI(t *testing.T) {
+	gw := &Gateway{}
+	rec := httptest.NewRecorder()
+
+	gw.OpenAPI(rec, httptest.NewRequest(http.MethodGet, "/api/gateway/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var spec struct {
+		Paths map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &spec))
+	assert.Contains(t, spec.Paths, "/api/gateway/v1/pvz")
+	assert.Contains(t, spec.Paths, "/api/gateway/v1/events")
+}
+
+func TestCheckPvzAccess(t *testing.T) {
+	pvzID := uuid.New()
+	restricted := &models.Principal{Role: models.RoleModerator, PvzIDs: []uuid.UUID{pvzID}}
+
+	tests := []struct {
+		name         string
+		principal    *models.Principal
+		req          any
+		expectedCode codes.Code
+	}{
+		{name: "unrestricted", principal: &models.Principal{Role: models.RoleModerator}, req: &gen.PVZ{}, expectedCode: codes.OK},
+		{name: "list is filtered", principal: restricted, req: &gen.GetPVZListRequest{}, expectedCode: codes.OK},
+		{name: "own pvz", principal: restricted, req: &gen.GetStatsRequest{PvzId: pvzID.String()}, expectedCode: codes.OK},
+		{name: "other pvz", principal: restricted, req: &gen.WatchPVZEventsRequest{PvzIds: []string{uuid.NewString()}}, expectedCode: codes.PermissionDenied},
+		{name: "unscoped request", principal: restricted, req: &gen.PVZ{}, expectedCode: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), middleware.PrincipalInContext, tt.principal)
+
+			err := checkPvzAccess(ctx, tt.req)
+
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package grpcconn
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// breaker stops calling a service that keeps failing. After OpenTimeout a
+// single trial call decides whether it closes again.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	timeout   time.Duration
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	trial     bool
+	now       func() time.Time
+	onChange  func(open bool)
+}
+
+func newBreaker(cfg BreakerConfig, onChange func(open bool)) *breaker {
+	return &breaker{
+		threshold: cfg.FailureThreshold,
+		timeout:   cfg.OpenTimeout,
+		now:       time.Now,
+		onChange:  onChange,
+	}
+}
+
+// allow returns ErrCircuitOpen as an UNAVAILABLE status while calls have to fail fast.
+func (b *breaker) allow() error {
+	if b.threshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if b.now().Sub(b.openedAt) < b.timeout {
+			return status.Error(codes.Unavailable, ErrCircuitOpen.Error())
+		}
+		b.state = stateHalfOpen
+		b.trial = true
+		return nil
+	case stateHalfOpen:
+		if b.trial {
+			return status.Error(codes.Unavailable, ErrCircuitOpen.Error())
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+// record counts the result of a call let through by allow.
+func (b *breaker) record(err error) {
+	if b.threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if status.Code(err) == codes.Canceled {
+		// the caller gave up, this says nothing about the service
+		b.trial = false
+		return
+	}
+	if !isFailure(err) {
+		if b.state != stateClosed {
+			b.setState(stateClosed)
+		}
+		b.failures = 0
+		b.trial = false
+		return
+	}
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		b.trial = false
+		if b.state != stateOpen {
+			b.setState(stateOpen)
+		}
+	}
+}
+
+func (b *breaker) setState(state breakerState) {
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(state == stateOpen)
+	}
+}
+
+// isFailure reports whether the error means the service is unreachable or
+// overloaded. Errors caused by the request don't count, and neither does
+// Internal: a single failing request must not block every other caller.
+func isFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
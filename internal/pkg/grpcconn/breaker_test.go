@@ -0,0 +1,130 @@
+package grpcconn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newTestBreaker(now *time.Time, changes *[]bool) *breaker {
+	b := newBreaker(BreakerConfig{FailureThreshold: 2, OpenTimeout: 10 * time.Second}, func(open bool) {
+		*changes = append(*changes, open)
+	})
+	b.now = func() time.Time { return *now }
+	return b
+}
+
+func TestBreaker(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	t.Run("opens after threshold and fails fast", func(t *testing.T) {
+		now := time.Now()
+		var changes []bool
+		b := newTestBreaker(&now, &changes)
+
+		for range 2 {
+			assert.NoError(t, b.allow())
+			b.record(unavailable)
+		}
+
+		err := b.allow()
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Contains(t, err.Error(), ErrCircuitOpen.Error())
+		assert.Equal(t, []bool{true}, changes)
+	})
+
+	t.Run("request errors don't count", func(t *testing.T) {
+		now := time.Now()
+		var changes []bool
+		b := newTestBreaker(&now, &changes)
+
+		for range 5 {
+			assert.NoError(t, b.allow())
+			b.record(status.Error(codes.InvalidArgument, "bad"))
+		}
+		assert.NoError(t, b.allow())
+		assert.Empty(t, changes)
+	})
+
+	t.Run("internal errors don't count", func(t *testing.T) {
+		now := time.Now()
+		var changes []bool
+		b := newTestBreaker(&now, &changes)
+
+		for range 5 {
+			assert.NoError(t, b.allow())
+			b.record(status.Error(codes.Internal, "bug"))
+		}
+		assert.NoError(t, b.allow())
+		assert.Empty(t, changes)
+	})
+
+	t.Run("success resets failures", func(t *testing.T) {
+		now := time.Now()
+		var changes []bool
+		b := newTestBreaker(&now, &changes)
+
+		b.record(unavailable)
+		b.record(nil)
+		b.record(unavailable)
+		assert.NoError(t, b.allow())
+	})
+
+	t.Run("half open lets one trial through and closes on success", func(t *testing.T) {
+		now := time.Now()
+		var changes []bool
+		b := newTestBreaker(&now, &changes)
+		b.record(unavailable)
+		b.record(unavailable)
+
+		now = now.Add(11 * time.Second)
+		assert.NoError(t, b.allow())
+		assert.Error(t, b.allow())
+
+		b.record(nil)
+		assert.NoError(t, b.allow())
+		assert.Equal(t, []bool{true, false}, changes)
+	})
+
+	t.Run("half open reopens on failure", func(t *testing.T) {
+		now := time.Now()
+		var changes []bool
+		b := newTestBreaker(&now, &changes)
+		b.record(unavailable)
+		b.record(unavailable)
+
+		now = now.Add(11 * time.Second)
+		assert.NoError(t, b.allow())
+		b.record(unavailable)
+
+		assert.Error(t, b.allow())
+		now = now.Add(11 * time.Second)
+		assert.NoError(t, b.allow())
+	})
+
+	t.Run("canceled trial releases half open", func(t *testing.T) {
+		now := time.Now()
+		var changes []bool
+		b := newTestBreaker(&now, &changes)
+		b.record(unavailable)
+		b.record(unavailable)
+
+		now = now.Add(11 * time.Second)
+		assert.NoError(t, b.allow())
+		b.record(status.Error(codes.Canceled, "canceled"))
+
+		assert.NoError(t, b.allow())
+		assert.Equal(t, []bool{true}, changes)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		b := newBreaker(BreakerConfig{}, nil)
+		for range 10 {
+			b.record(unavailable)
+		}
+		assert.NoError(t, b.allow())
+	})
+}
@@ -1,22 +1,234 @@
 package grpcconn
 
 import (
-	"errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/metrics"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/pkg/tlsconfig"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strings"
+	"time"
 )
 
+// retryableMethods are safe to repeat, WatchPVZEvents is resumed by the
+// caller with its last event id instead.
+var retryableMethods = []string{
+	gen.PVZService_GetPVZList_FullMethodName,
+	gen.PVZService_GetStats_FullMethodName,
+}
+
 type In struct {
 	fx.In
 
-	Config Config
+	Lifecycle      fx.Lifecycle
+	Config         Config
+	Metrics        metrics.Metrics
+	TracerProvider trace.TracerProvider
+	Logger         *slog.Logger
 }
 
 func Provide(in In) (*grpc.ClientConn, error) {
-	conn, err := grpc.NewClient(in.Config.Address, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+	creds := insecure.NewCredentials()
+	if in.Config.TLS.Enabled {
+		reloader, err := tlsconfig.NewClient(in.Config.TLS, in.Logger)
+		if err != nil {
+			in.Logger.Error("tls config: " + err.Error())
+			return nil, err
+		}
+		reloader.Start(in.Lifecycle)
+		creds = credentials.NewTLS(reloader.ClientConfig())
+	}
+
+	opts, err := dialOptions(in.Config, creds, in.Metrics, in.TracerProvider, in.Logger)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(in.Config.Address, opts...)
+	if err != nil {
+		in.Logger.Error("grpc new client: " + err.Error())
 		return nil, err
 	}
-	return conn, err
+
+	in.Lifecycle.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return conn.Close()
+		},
+	})
+	return conn, nil
+}
+
+func dialOptions(cfg Config, creds credentials.TransportCredentials, m metrics.Metrics, tp trace.TracerProvider, logger *slog.Logger) ([]grpc.DialOption, error) {
+	serviceConfig, err := buildServiceConfig(cfg.Retry)
+	if err != nil {
+		return nil, err
+	}
+
+	cb := newBreaker(cfg.Breaker, func(open bool) {
+		m.GRPCClientCircuitOpen(cfg.Address, open)
+		if open {
+			logger.Warn("grpc circuit breaker opened", "address", cfg.Address)
+		} else {
+			logger.Info("grpc circuit breaker closed", "address", cfg.Address)
+		}
+	})
+	interceptors := &interceptors{timeout: cfg.Timeout, breaker: cb, metrics: m}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(serviceConfig),
+		// injects the trace context into the outgoing metadata
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler(
+			otelgrpc.WithTracerProvider(tp),
+			otelgrpc.WithPropagators(otel.GetTextMapPropagator()),
+		)),
+		// the breaker sees the outcome after all retries
+		grpc.WithChainUnaryInterceptor(interceptors.unary),
+		grpc.WithChainStreamInterceptor(interceptors.stream),
+	}
+	if cfg.Keepalive.Time > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                cfg.Keepalive.Time,
+			Timeout:             cfg.Keepalive.Timeout,
+			PermitWithoutStream: cfg.Keepalive.PermitWithoutStream,
+		}))
+	}
+	return opts, nil
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type serviceConfig struct {
+	MethodConfig []methodConfig `json:"methodConfig,omitempty"`
+}
+
+func buildServiceConfig(cfg RetryConfig) (string, error) {
+	sc := serviceConfig{}
+	if cfg.MaxAttempts > 1 {
+		mc := methodConfig{
+			RetryPolicy: &retryPolicy{
+				MaxAttempts:          cfg.MaxAttempts,
+				InitialBackoff:       formatSeconds(cfg.InitialBackoff),
+				MaxBackoff:           formatSeconds(cfg.MaxBackoff),
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"UNAVAILABLE"},
+			},
+		}
+		for _, fullMethod := range retryableMethods {
+			service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+			mc.Name = append(mc.Name, methodName{Service: service, Method: method})
+		}
+		sc.MethodConfig = append(sc.MethodConfig, mc)
+	}
+
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return "", fmt.Errorf("marshal grpc service config: %w", err)
+	}
+	return string(data), nil
+}
+
+// formatSeconds writes a duration the way the service config expects it.
+func formatSeconds(d time.Duration) string {
+	return fmt.Sprintf("%gs", d.Seconds())
+}
+
+type interceptors struct {
+	timeout time.Duration
+	breaker *breaker
+	metrics metrics.Metrics
+}
+
+func (i *interceptors) unary(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	ctx = withCredentials(withRequestID(ctx))
+	if _, ok := ctx.Deadline(); !ok && i.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := i.breaker.allow()
+	if err == nil {
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		i.breaker.record(err)
+	}
+	i.metrics.GRPCClientHandled(method, status.Code(err).String(), time.Since(start))
+	return err
+}
+
+// stream guards only establishing the stream, streams are long-lived and
+// end with an error on every server shutdown.
+func (i *interceptors) stream(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	ctx = withCredentials(withRequestID(ctx))
+	start := time.Now()
+	err := i.breaker.allow()
+	var stream grpc.ClientStream
+	if err == nil {
+		stream, err = streamer(ctx, desc, cc, method, opts...)
+		i.breaker.record(err)
+	}
+	i.metrics.GRPCClientHandled(method, status.Code(err).String(), time.Since(start))
+	return stream, err
+}
+
+// withRequestID passes the id of the request being served on to the
+// server, so that the log lines of both services can be matched.
+func withRequestID(ctx context.Context) context.Context {
+	requestID := logctx.RequestID(ctx)
+	if requestID == "" {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(logctx.RequestIDMetadataKey)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, logctx.RequestIDMetadataKey, requestID)
+}
+
+// withCredentials forwards the credentials of the caller, the pvz service
+// authenticates and authorizes every call itself.
+func withCredentials(ctx context.Context) context.Context {
+	creds, ok := middleware.CredentialsFromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	key, value := middleware.AuthorizationMetadataKey, creds.Authorization
+	if creds.APIKey != "" {
+		key, value = middleware.APIKeyMetadataKey, creds.APIKey
+	}
+	// the gateway already forwards the Authorization header
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(key)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, key, value)
 }
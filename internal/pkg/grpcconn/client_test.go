@@ -0,0 +1,162 @@
+package grpcconn
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/metrics/mocks"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type flakyServer struct {
+	gen.UnimplementedPVZServiceServer
+	calls    atomic.Int32
+	failures int32
+	code     codes.Code
+	delay    time.Duration
+
+	requestID     atomic.Value
+	authorization atomic.Value
+}
+
+func (f *flakyServer) GetPVZList(ctx context.Context, _ *gen.GetPVZListRequest) (*gen.GetPVZListResponse, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		f.requestID.Store(md.Get(logctx.RequestIDMetadataKey))
+		f.authorization.Store(md.Get(middleware.AuthorizationMetadataKey))
+	}
+	if f.calls.Add(1) <= f.failures {
+		return nil, status.Error(f.code, "failed")
+	}
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &gen.GetPVZListResponse{}, nil
+}
+
+func newTestClient(t *testing.T, server *flakyServer, cfg Config) gen.PVZServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	gen.RegisterPVZServiceServer(srv, server)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	ctrl := gomock.NewController(t)
+	metrics := mocks.NewMockMetrics(ctrl)
+	metrics.EXPECT().GRPCClientHandled(gen.PVZService_GetPVZList_FullMethodName, gomock.Any(), gomock.Any()).AnyTimes()
+	metrics.EXPECT().GRPCClientCircuitOpen(gomock.Any(), gomock.Any()).AnyTimes()
+
+	opts, err := dialOptions(cfg, insecure.NewCredentials(), metrics, noop.NewTracerProvider(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	opts = append(opts, grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	}))
+
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return gen.NewPVZServiceClient(conn)
+}
+
+func TestClient(t *testing.T) {
+	retry := RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+	t.Run("retries unavailable", func(t *testing.T) {
+		server := &flakyServer{failures: 2, code: codes.Unavailable}
+		client := newTestClient(t, server, Config{Timeout: time.Second, Retry: retry})
+
+		_, err := client.GetPVZList(context.Background(), &gen.GetPVZListRequest{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int32(3), server.calls.Load())
+	})
+
+	t.Run("doesn't retry other codes", func(t *testing.T) {
+		server := &flakyServer{failures: 1, code: codes.InvalidArgument}
+		client := newTestClient(t, server, Config{Timeout: time.Second, Retry: retry})
+
+		_, err := client.GetPVZList(context.Background(), &gen.GetPVZListRequest{})
+
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.Equal(t, int32(1), server.calls.Load())
+	})
+
+	t.Run("applies the default deadline", func(t *testing.T) {
+		server := &flakyServer{delay: time.Second}
+		client := newTestClient(t, server, Config{Timeout: 20 * time.Millisecond})
+
+		_, err := client.GetPVZList(context.Background(), &gen.GetPVZListRequest{})
+
+		assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	})
+
+	t.Run("fails fast once the breaker is open", func(t *testing.T) {
+		server := &flakyServer{failures: 100, code: codes.Unavailable}
+		client := newTestClient(t, server, Config{
+			Timeout: time.Second,
+			Breaker: BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute},
+		})
+
+		for range 2 {
+			_, err := client.GetPVZList(context.Background(), &gen.GetPVZListRequest{})
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+		}
+		_, err := client.GetPVZList(context.Background(), &gen.GetPVZListRequest{})
+
+		assert.Equal(t, codes.Unavailable, status.Code(err))
+		assert.Contains(t, err.Error(), ErrCircuitOpen.Error())
+		assert.Equal(t, int32(2), server.calls.Load())
+	})
+
+	t.Run("propagates the request id", func(t *testing.T) {
+		server := &flakyServer{}
+		client := newTestClient(t, server, Config{Timeout: time.Second})
+
+		_, err := client.GetPVZList(logctx.WithRequestID(context.Background(), "req-1"), &gen.GetPVZListRequest{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"req-1"}, server.requestID.Load())
+	})
+
+	t.Run("forwards the caller credentials", func(t *testing.T) {
+		server := &flakyServer{}
+		client := newTestClient(t, server, Config{Timeout: time.Second})
+
+		ctx := context.WithValue(context.Background(), middleware.CredentialsInContext, middleware.Credentials{Authorization: "Bearer token"})
+		_, err := client.GetPVZList(ctx, &gen.GetPVZListRequest{})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Bearer token"}, server.authorization.Load())
+	})
+}
+
+func TestBuildServiceConfig(t *testing.T) {
+	sc, err := buildServiceConfig(RetryConfig{MaxAttempts: 3, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second})
+	require.NoError(t, err)
+	assert.Contains(t, sc, `"service":"pvz.v1.PVZService","method":"GetPVZList"`)
+	assert.Contains(t, sc, `"initialBackoff":"0.1s"`)
+	assert.NotContains(t, sc, "WatchPVZEvents")
+
+	sc, err = buildServiceConfig(RetryConfig{MaxAttempts: 1})
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, sc)
+}
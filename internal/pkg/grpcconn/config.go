@@ -1,5 +1,36 @@
 package grpcconn
 
+import (
+	"github.com/marrgancovka/pvzService/internal/pkg/tlsconfig"
+	"time"
+)
+
 type Config struct {
 	Address string `yaml:"address" env-default:"localhost:3000"`
+	// Timeout is the deadline of unary calls made without one.
+	Timeout   time.Duration    `yaml:"timeout" env-default:"3s"`
+	Retry     RetryConfig      `yaml:"retry"`
+	Keepalive KeepaliveConfig  `yaml:"keepalive"`
+	Breaker   BreakerConfig    `yaml:"breaker"`
+	TLS       tlsconfig.Config `yaml:"tls"`
+}
+
+// RetryConfig applies to the idempotent RPCs only, see retryableMethods.
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"maxAttempts" env-default:"3"`
+	InitialBackoff time.Duration `yaml:"initialBackoff" env-default:"100ms"`
+	MaxBackoff     time.Duration `yaml:"maxBackoff" env-default:"1s"`
+}
+
+type KeepaliveConfig struct {
+	Time                time.Duration `yaml:"time" env-default:"30s"`
+	Timeout             time.Duration `yaml:"timeout" env-default:"10s"`
+	PermitWithoutStream bool          `yaml:"permitWithoutStream" env-default:"true"`
+}
+
+type BreakerConfig struct {
+	// FailureThreshold consecutive failures open the breaker, zero disables it.
+	FailureThreshold int `yaml:"failureThreshold" env-default:"5"`
+	// OpenTimeout is how long calls fail fast before a trial call is let through.
+	OpenTimeout time.Duration `yaml:"openTimeout" env-default:"10s"`
 }
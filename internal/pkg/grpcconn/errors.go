@@ -0,0 +1,5 @@
+package grpcconn
+
+import "errors"
+
+var ErrCircuitOpen = errors.New("pvz service is unavailable, circuit breaker is open")
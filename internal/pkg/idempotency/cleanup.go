@@ -0,0 +1,57 @@
+package idempotency
+
+import (
+	"context"
+	"go.uber.org/fx"
+	"log/slog"
+	"time"
+)
+
+type CleanupParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Store     *PostgresStore
+	Config    Config
+	Logger    *slog.Logger
+}
+
+// RunCleanup periodically removes expired keys so that the table does not grow without bound.
+func RunCleanup(p CleanupParams) {
+	if p.Config.CleanupInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+
+				ticker := time.NewTicker(p.Config.CleanupInterval)
+				defer ticker.Stop()
+
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						deleted, err := p.Store.DeleteExpired(ctx)
+						if err != nil {
+							continue
+						}
+						p.Logger.Debug("deleted expired idempotency keys", "count", deleted)
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			<-done
+			return nil
+		},
+	})
+}
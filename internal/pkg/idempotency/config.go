@@ -0,0 +1,11 @@
+package idempotency
+
+import "time"
+
+type Config struct {
+	TTL             time.Duration `yaml:"ttl" env-default:"24h"`
+	CleanupInterval time.Duration `yaml:"cleanupInterval" env-default:"1h"`
+	// MaxBodySize limits the request body read for the request hash, it has
+	// to fit the largest body a route accepts (the 10MB pvz import)
+	MaxBodySize int64 `yaml:"maxBodySize" env-default:"10485760"`
+}
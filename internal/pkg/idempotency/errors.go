@@ -0,0 +1,11 @@
+package idempotency
+
+import "errors"
+
+var (
+	ErrKeyReused    = errors.New("idempotency key is already used with a different request")
+	ErrInProgress   = errors.New("request with this idempotency key is still in progress")
+	ErrKeyTooLong   = errors.New("idempotency key is too long")
+	ErrNotReserved  = errors.New("idempotency key is not reserved")
+	ErrBodyTooLarge = errors.New("request body is too large")
+)
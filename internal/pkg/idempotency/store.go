@@ -0,0 +1,203 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"go.uber.org/fx"
+	"log/slog"
+	"time"
+)
+
+// Record is a stored request. StatusCode is zero while the original request is still being processed.
+type Record struct {
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+type Store interface {
+	// Reserve saves the key for a new request. If the key is already known, the stored record is returned instead.
+	Reserve(ctx context.Context, scope, key, requestHash string) (*Record, error)
+	Complete(ctx context.Context, scope, key string, record *Record) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+type Params struct {
+	fx.In
+
+	Pool    *pgxpool.Pool
+	Logger  *slog.Logger
+	Builder squirrel.StatementBuilderType
+	Config  Config
+}
+
+type PostgresStore struct {
+	pool    *pgxpool.Pool
+	log     *slog.Logger
+	builder squirrel.StatementBuilderType
+	cfg     Config
+}
+
+func NewPostgresStore(p Params) *PostgresStore {
+	return &PostgresStore{
+		pool:    p.Pool,
+		log:     p.Logger,
+		builder: p.Builder,
+		cfg:     p.Config,
+	}
+}
+
+func (store *PostgresStore) Reserve(ctx context.Context, scope, key, requestHash string) (*Record, error) {
+	const op = "idempotency.PostgresStore.Reserve"
+	logger := logctx.From(ctx, store.log).With("op", op)
+
+	tx, err := store.pool.Begin(ctx)
+	if err != nil {
+		logger.Error("failed to begin transaction: " + err.Error())
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := store.builder.
+		Delete("idempotency_keys").
+		Where(squirrel.And{
+			squirrel.Eq{"scope": scope, "key": key},
+			squirrel.Lt{"created_at": time.Now().Add(-store.cfg.TTL)},
+		}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		logger.Error("failed to delete expired key: " + err.Error())
+		return nil, err
+	}
+
+	query, args, err = store.builder.
+		Insert("idempotency_keys").
+		Columns("scope", "key", "request_hash").
+		Values(scope, key, requestHash).
+		Suffix("ON CONFLICT (scope, key) DO NOTHING").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		logger.Error("failed to reserve key: " + err.Error())
+		return nil, err
+	}
+
+	var existing *Record
+	if tag.RowsAffected() == 0 {
+		query, args, err = store.builder.
+			Select("request_hash", "status_code", "content_type", "response_body", "created_at").
+			From("idempotency_keys").
+			Where(squirrel.Eq{"scope": scope, "key": key}).
+			ToSql()
+		if err != nil {
+			logger.Error("build query error: " + err.Error())
+			return nil, err
+		}
+
+		existing = &Record{}
+		if err = tx.QueryRow(ctx, query, args...).Scan(
+			&existing.RequestHash,
+			&existing.StatusCode,
+			&existing.ContentType,
+			&existing.Body,
+			&existing.CreatedAt,
+		); err != nil {
+			logger.Error("failed to get stored key: " + err.Error())
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("failed to commit transaction: " + err.Error())
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+func (store *PostgresStore) Complete(ctx context.Context, scope, key string, record *Record) error {
+	const op = "idempotency.PostgresStore.Complete"
+	logger := logctx.From(ctx, store.log).With("op", op)
+
+	query, args, err := store.builder.
+		Update("idempotency_keys").
+		Set("status_code", record.StatusCode).
+		Set("content_type", record.ContentType).
+		Set("response_body", record.Body).
+		Where(squirrel.Eq{"scope": scope, "key": key}).
+		Suffix("RETURNING key").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	var stored string
+	if err = store.pool.QueryRow(ctx, query, args...).Scan(&stored); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("key is not reserved")
+			return ErrNotReserved
+		}
+		logger.Error("failed to save response: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (store *PostgresStore) Release(ctx context.Context, scope, key string) error {
+	const op = "idempotency.PostgresStore.Release"
+	logger := logctx.From(ctx, store.log).With("op", op)
+
+	query, args, err := store.builder.
+		Delete("idempotency_keys").
+		Where(squirrel.Eq{"scope": scope, "key": key}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	if _, err = store.pool.Exec(ctx, query, args...); err != nil {
+		logger.Error("failed to release key: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (store *PostgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	const op = "idempotency.PostgresStore.DeleteExpired"
+	logger := logctx.From(ctx, store.log).With("op", op)
+
+	query, args, err := store.builder.
+		Delete("idempotency_keys").
+		Where(squirrel.Lt{"created_at": time.Now().Add(-store.cfg.TTL)}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return 0, err
+	}
+
+	tag, err := store.pool.Exec(ctx, query, args...)
+	if err != nil {
+		logger.Error("failed to delete expired keys: " + err.Error())
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,72 @@
+package jwter
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"time"
+)
+
+type Claims struct {
+	Role    models.Role `json:"role"`
+	Dummy   bool        `json:"dummy,omitempty"`
+	Version int         `json:"ver,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// verifyClaims checks the registered claims. exp, iat and jti are required,
+// nbf is optional, iss and aud are checked when configured.
+func (jwter *JWTer) verifyClaims(claims *Claims, now time.Time) error {
+	leeway := jwter.cfg.Leeway
+
+	if claims.ExpiresAt == nil || claims.IssuedAt == nil || claims.ID == "" || claims.Subject == "" {
+		return ErrInvalidTokenClaims
+	}
+	if !claims.VerifyExpiresAt(now.Add(-leeway), true) {
+		return ErrTokenExpired
+	}
+	if !claims.VerifyNotBefore(now.Add(leeway), false) || !claims.VerifyIssuedAt(now.Add(leeway), true) {
+		return ErrTokenNotValidYet
+	}
+	if jwter.cfg.Issuer != "" && !claims.VerifyIssuer(jwter.cfg.Issuer, true) {
+		return ErrInvalidTokenClaims
+	}
+	if len(jwter.cfg.Audience) > 0 && !jwter.verifyAudience(claims) {
+		return ErrInvalidTokenClaims
+	}
+
+	return nil
+}
+
+// isLegacy reports whether claims were issued in the format that predates
+// mandatory iat and jti. Such tokens were only ever signed with KeyJWT.
+func (jwter *JWTer) isLegacy(claims *Claims, kid string) bool {
+	return jwter.cfg.AcceptLegacyClaims && kid == "" && claims.IssuedAt == nil && claims.ID == ""
+}
+
+// verifyLegacyClaims checks the claims of a legacy token: only sub and exp
+// were ever set, so iss and aud are not checked. Legacy dummy tokens carry
+// the nil UUID and no dummy claim, they are rejected so that they don't
+// bypass the dummy login restriction.
+func (jwter *JWTer) verifyLegacyClaims(claims *Claims, now time.Time) error {
+	if claims.ExpiresAt == nil {
+		return ErrInvalidTokenClaims
+	}
+	if id, err := uuid.Parse(claims.Subject); err != nil || id == uuid.Nil {
+		return ErrInvalidTokenClaims
+	}
+	if !claims.VerifyExpiresAt(now.Add(-jwter.cfg.Leeway), true) {
+		return ErrTokenExpired
+	}
+
+	return nil
+}
+
+func (jwter *JWTer) verifyAudience(claims *Claims) bool {
+	for _, audience := range jwter.cfg.Audience {
+		if claims.VerifyAudience(audience, true) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package jwter
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("secret")
+
+func signTestClaims(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS512, claims).SignedString(testSecret)
+	require.NoError(t, err)
+	return token
+}
+
+func validClaims(now time.Time) *Claims {
+	return &Claims{
+		Role: models.RoleEmployee,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "pvz-service",
+			Subject:   uuid.NewString(),
+			Audience:  jwt.ClaimStrings{"pvz-service"},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		},
+	}
+}
+
+func TestJWTer_ValidateClaims(t *testing.T) {
+	jwter := newTestJWTer(t, Config{
+		KeyJWT:   testSecret,
+		Issuer:   "pvz-service",
+		Audience: []string{"pvz-service", "warehouse"},
+		Leeway:   time.Minute,
+	})
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		modify  func(c *Claims)
+		wantErr error
+	}{
+		{name: "valid", modify: func(c *Claims) {}},
+		{
+			name:   "second audience",
+			modify: func(c *Claims) { c.Audience = jwt.ClaimStrings{"warehouse"} },
+		},
+		{
+			name:   "expired within leeway",
+			modify: func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-30 * time.Second)) },
+		},
+		{
+			name:    "expired",
+			modify:  func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-2 * time.Minute)) },
+			wantErr: ErrTokenExpired,
+		},
+		{
+			name:   "issued in future within leeway",
+			modify: func(c *Claims) { c.IssuedAt = jwt.NewNumericDate(now.Add(30 * time.Second)) },
+		},
+		{
+			name:    "not valid yet",
+			modify:  func(c *Claims) { c.NotBefore = jwt.NewNumericDate(now.Add(2 * time.Minute)) },
+			wantErr: ErrTokenNotValidYet,
+		},
+		{
+			name:    "wrong issuer",
+			modify:  func(c *Claims) { c.Issuer = "evil" },
+			wantErr: ErrInvalidTokenClaims,
+		},
+		{
+			name:    "wrong audience",
+			modify:  func(c *Claims) { c.Audience = jwt.ClaimStrings{"billing"} },
+			wantErr: ErrInvalidTokenClaims,
+		},
+		{
+			name:    "no jti",
+			modify:  func(c *Claims) { c.ID = "" },
+			wantErr: ErrInvalidTokenClaims,
+		},
+		{
+			name:    "no exp",
+			modify:  func(c *Claims) { c.ExpiresAt = nil },
+			wantErr: ErrInvalidTokenClaims,
+		},
+		{
+			name:    "invalid sub",
+			modify:  func(c *Claims) { c.Subject = "admin" },
+			wantErr: ErrInvalidTokenClaims,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims(now)
+			tt.modify(claims)
+
+			payload, err := jwter.ValidateJWT(signTestClaims(t, claims))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, claims.ID, payload.TokenID)
+		})
+	}
+}
+
+func TestJWTer_ValidateMalformedClaims(t *testing.T) {
+	jwter := newTestJWTer(t, Config{KeyJWT: testSecret})
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+	}{
+		{name: "numeric sub", claims: jwt.MapClaims{"sub": 42, "role": "employee", "exp": time.Now().Add(time.Hour).Unix()}},
+		{name: "numeric role", claims: jwt.MapClaims{"sub": uuid.NewString(), "role": 1, "exp": time.Now().Add(time.Hour).Unix()}},
+		{name: "string exp", claims: jwt.MapClaims{"sub": uuid.NewString(), "role": "employee", "exp": "tomorrow"}},
+		{name: "empty", claims: jwt.MapClaims{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NotPanics(t, func() {
+				_, err := jwter.ValidateJWT(signTestClaims(t, tt.claims))
+				assert.Error(t, err)
+			})
+		})
+	}
+}
+
+func FuzzValidateJWT(f *testing.F) {
+	jwter := newTestJWTer(f, Config{KeyJWT: testSecret, Issuer: "pvz-service", Audience: []string{"pvz-service"}})
+
+	valid, err := jwter.GenerateJWT(&models.TokenPayload{ID: uuid.New(), Role: models.RoleEmployee})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(valid.Token)
+	f.Add("")
+	f.Add("a.b.c")
+	f.Add("eyJhbGciOiJub25lIn0.eyJzdWIiOjF9.")
+	f.Add("eyJhbGciOiJIUzUxMiIsImtpZCI6MX0.e30.sig")
+
+	f.Fuzz(func(t *testing.T, token string) {
+		_, _ = jwter.ValidateJWT(token)
+	})
+}
+
+func TestJWTer_ValidateLegacyClaims(t *testing.T) {
+	now := time.Now()
+	legacy := func(exp time.Time) jwt.MapClaims {
+		return jwt.MapClaims{"sub": uuid.NewString(), "role": "employee", "exp": exp.Unix()}
+	}
+
+	tests := []struct {
+		name    string
+		accept  bool
+		claims  jwt.MapClaims
+		wantErr error
+	}{
+		{name: "accepted", accept: true, claims: legacy(now.Add(time.Hour))},
+		{name: "expired", accept: true, claims: legacy(now.Add(-time.Hour)), wantErr: ErrTokenExpired},
+		{name: "window closed", accept: false, claims: legacy(now.Add(time.Hour)), wantErr: ErrInvalidTokenClaims},
+		{
+			name:    "dummy",
+			accept:  true,
+			claims:  jwt.MapClaims{"sub": uuid.Nil.String(), "role": "moderator", "exp": now.Add(time.Hour).Unix()},
+			wantErr: ErrInvalidTokenClaims,
+		},
+		{
+			name:    "invalid sub",
+			accept:  true,
+			claims:  jwt.MapClaims{"sub": "admin", "role": "employee", "exp": now.Add(time.Hour).Unix()},
+			wantErr: ErrInvalidTokenClaims,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwter := newTestJWTer(t, Config{
+				KeyJWT:             testSecret,
+				Issuer:             "pvz-service",
+				Audience:           []string{"pvz-service"},
+				AcceptLegacyClaims: tt.accept,
+			})
+
+			payload, err := jwter.ValidateJWT(signTestClaims(t, tt.claims))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.claims["sub"], payload.ID.String())
+			assert.Equal(t, models.RoleEmployee, payload.Role)
+			assert.True(t, payload.IssuedAt.IsZero())
+		})
+	}
+}
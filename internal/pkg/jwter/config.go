@@ -4,5 +4,29 @@ import "time"
 
 type Config struct {
 	ExpirationTime time.Duration `yaml:"accessExpirationTime" env-default:"24h"`
-	KeyJWT         []byte        `env:"JWT_SECRET" env-required:"true"`
+	Issuer         string        `yaml:"issuer" env-default:"pvz-service"`
+	Audience       []string      `yaml:"audience" env-default:"pvz-service"`
+	// Leeway is the allowed clock skew between us and the token issuer
+	// when checking exp, nbf and iat.
+	Leeway time.Duration `yaml:"leeway" env-default:"30s"`
+	// KeyJWT is the legacy HS512 secret. Tokens signed with it carry no kid,
+	// so it keeps verifying them after the active key has been rotated.
+	KeyJWT []byte `env:"JWT_SECRET"`
+	// AcceptLegacyClaims keeps accepting tokens issued before iat, jti, iss
+	// and aud became mandatory ({sub, role, exp} signed with KeyJWT). Turn it
+	// off once accessExpirationTime has passed since the deploy.
+	AcceptLegacyClaims bool        `yaml:"acceptLegacyClaims" env:"JWT_ACCEPT_LEGACY_CLAIMS"`
+	ActiveKeyID        string      `yaml:"activeKeyId" env:"JWT_ACTIVE_KEY_ID"`
+	Keys               []KeyConfig `yaml:"keys"`
+}
+
+// KeyConfig describes one key of the key ring. Keys other than the active one
+// are retired: they only verify tokens that were issued before the rotation,
+// so a public key is enough for them.
+type KeyConfig struct {
+	ID             string `yaml:"kid"`
+	Algorithm      string `yaml:"alg"`
+	PrivateKeyPath string `yaml:"privateKeyPath"`
+	PublicKeyPath  string `yaml:"publicKeyPath"`
+	SecretEnv      string `yaml:"secretEnv"`
 }
@@ -7,5 +7,11 @@ var (
 	ErrUnexpectedSigningMethod = errors.New("unexpected signing method")
 	ErrInvalidToken            = errors.New("invalid token")
 	ErrTokenExpired            = errors.New("token expired")
+	ErrTokenNotValidYet        = errors.New("token is not valid yet")
 	ErrInvalidTokenClaims      = errors.New("invalid token claims")
+	ErrUnknownKeyID            = errors.New("unknown key id")
+	ErrNoSigningKey            = errors.New("no signing key configured")
+	ErrUnsupportedAlgorithm    = errors.New("unsupported signing algorithm")
+	ErrInvalidKey              = errors.New("invalid key")
+	ErrDuplicateKeyID          = errors.New("duplicate key id")
 )
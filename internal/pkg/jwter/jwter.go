@@ -1,11 +1,13 @@
 package jwter
 
 import (
+	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 	"github.com/marrgancovka/pvzService/internal/models"
 	"go.uber.org/fx"
 	"log/slog"
+	"sort"
 	"time"
 )
 
@@ -17,30 +19,75 @@ type Params struct {
 }
 
 type JWTer struct {
-	cfg Config
-	log *slog.Logger
+	cfg    Config
+	log    *slog.Logger
+	active *key
+	keys   map[string]*key
 }
 
-func New(p Params) *JWTer {
-	return &JWTer{
-		cfg: p.Config,
-		log: p.Logger,
+func New(p Params) (*JWTer, error) {
+	const op = "jwter.New"
+	logger := p.Logger.With("op", op)
+
+	jwter := &JWTer{
+		cfg:  p.Config,
+		log:  p.Logger,
+		keys: make(map[string]*key, len(p.Config.Keys)+1),
+	}
+
+	if len(p.Config.KeyJWT) > 0 {
+		jwter.keys[""] = legacyKey(p.Config.KeyJWT)
+	}
+	for _, keyCfg := range p.Config.Keys {
+		if _, ok := jwter.keys[keyCfg.ID]; ok || keyCfg.ID == "" {
+			logger.Error("duplicate or empty kid: " + keyCfg.ID)
+			return nil, fmt.Errorf("%w: %q", ErrDuplicateKeyID, keyCfg.ID)
+		}
+		k, err := loadKey(keyCfg)
+		if err != nil {
+			logger.Error("load key: " + err.Error())
+			return nil, err
+		}
+		jwter.keys[k.id] = k
+	}
+
+	active, ok := jwter.keys[p.Config.ActiveKeyID]
+	if !ok || !active.canSign() {
+		logger.Error("active key is not configured: " + p.Config.ActiveKeyID)
+		return nil, ErrNoSigningKey
 	}
+	jwter.active = active
+
+	logger.Info("jwt keys loaded", "active_kid", active.id, "alg", active.method.Alg(), "keys", len(jwter.keys))
+	return jwter, nil
 }
 
 func (jwter *JWTer) GenerateJWT(payload *models.TokenPayload) (*models.Token, error) {
 	const op = "jwter.GenerateJWT"
 	logger := jwter.log.With("op", op)
 
-	expTime := time.Now().Add(jwter.cfg.ExpirationTime)
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{
-		"sub":  payload.ID,
-		"role": payload.Role,
-		"exp":  expTime.Unix(),
+	now := time.Now()
+	expTime := now.Add(jwter.cfg.ExpirationTime)
+
+	token := jwt.NewWithClaims(jwter.active.method, &Claims{
+		Role:    payload.Role,
+		Dummy:   payload.Dummy,
+		Version: payload.Version,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    jwter.cfg.Issuer,
+			Subject:   payload.ID.String(),
+			Audience:  jwter.cfg.Audience,
+			ExpiresAt: jwt.NewNumericDate(expTime),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		},
 	})
+	if jwter.active.id != "" {
+		token.Header["kid"] = jwter.active.id
+	}
 
-	tokenStr, err := token.SignedString(jwter.cfg.KeyJWT)
+	tokenStr, err := token.SignedString(jwter.active.signKey)
 	if err != nil {
 		logger.Error("JWT Error: " + err.Error())
 		return nil, err
@@ -62,53 +109,57 @@ func (jwter *JWTer) ValidateJWT(tokenString string) (*models.TokenPayload, error
 	const op = "jwter.ValidateJWT"
 	logger := jwter.log.With("op", op)
 
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	// Registered claims are verified below with the configured leeway,
+	// jwt/v4 validation has no notion of clock skew.
+	parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+
+	var kid string
+	claims := &Claims{}
+	_, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ = token.Header["kid"].(string)
+		k, ok := jwter.keys[kid]
+		if !ok {
+			logger.Error("validate jwt: unknown kid: " + kid)
+			return nil, ErrUnknownKeyID
+		}
+		if token.Method.Alg() != k.method.Alg() {
 			logger.Error("validate jwt: Unexpected signing method")
 			return nil, ErrUnexpectedSigningMethod
 		}
 
-		return jwter.cfg.KeyJWT, nil
+		return k.verifyKey, nil
 	})
 	if err != nil {
 		logger.Error("parsing token: " + err.Error())
 		return nil, ErrInvalidToken
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		logger.Error("jwt validate: claims error")
-		return nil, ErrInvalidToken
+	verify := jwter.verifyClaims
+	if jwter.isLegacy(claims, kid) {
+		logger.Warn("validate jwt: accepting legacy token", "sub", claims.Subject)
+		verify = jwter.verifyLegacyClaims
+	}
+	if err = verify(claims, time.Now()); err != nil {
+		logger.Error("validate jwt: " + err.Error())
+		return nil, err
 	}
 
-	id, err := uuid.Parse(claims["sub"].(string))
+	id, err := uuid.Parse(claims.Subject)
 	if err != nil {
 		logger.Error("jwt validate: invalid id")
 		return nil, ErrInvalidTokenClaims
 	}
 
-	role, ok := claims["role"].(string)
-	if !ok {
-		logger.Error("jwt validate: invalid role: " + claims["role"].(string))
-		return nil, ErrInvalidTokenClaims
-	}
-
-	exp, ok := claims["exp"].(float64)
-	if !ok {
-		logger.Error("jwt validate: invalid exp")
-		return nil, ErrInvalidTokenClaims
-	}
-	expTime := time.Unix(int64(exp), 0)
-
-	if expTime.Before(time.Now()) {
-		logger.Error("validate jwt: token expired")
-		return nil, ErrTokenExpired
-	}
-
 	payload := &models.TokenPayload{
-		ID:   id,
-		Role: models.Role(role),
-		Exp:  expTime,
+		ID:      id,
+		Role:    claims.Role,
+		Exp:     claims.ExpiresAt.Time,
+		TokenID: claims.ID,
+		Dummy:   claims.Dummy,
+		Version: claims.Version,
+	}
+	if claims.IssuedAt != nil {
+		payload.IssuedAt = claims.IssuedAt.Time
 	}
 
 	if id == uuid.Nil {
@@ -118,3 +169,18 @@ func (jwter *JWTer) ValidateJWT(tokenString string) (*models.TokenPayload, error
 
 	return payload, err
 }
+
+// JWKS returns the public keys that verify issued tokens, including retired
+// ones, so other services can validate tokens without the signing secret.
+func (jwter *JWTer) JWKS() *models.JWKSet {
+	set := &models.JWKSet{Keys: []models.JWK{}}
+	for _, k := range jwter.keys {
+		if jwk, ok := k.jwk(); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+	return set
+}
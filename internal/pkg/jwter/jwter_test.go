@@ -0,0 +1,193 @@
+package jwter
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePrivateKey(t *testing.T, private interface{}) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func writePublicKey(t *testing.T, public interface{}) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "key.pub")
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+	return path
+}
+
+func newTestJWTer(tb testing.TB, cfg Config) *JWTer {
+	tb.Helper()
+
+	cfg.ExpirationTime = time.Hour
+	jwter, err := New(Params{
+		Config: cfg,
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(tb, err)
+	return jwter
+}
+
+func TestJWTer_Algorithms(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	t.Setenv("TEST_JWT_SECRET", "secret")
+
+	tests := []struct {
+		name    string
+		key     KeyConfig
+		wantKty string
+	}{
+		{name: "HS512", key: KeyConfig{ID: "hs", Algorithm: "HS512", SecretEnv: "TEST_JWT_SECRET"}},
+		{name: "RS256", key: KeyConfig{ID: "rs", Algorithm: "RS256", PrivateKeyPath: writePrivateKey(t, rsaKey)}, wantKty: "RSA"},
+		{name: "ES256", key: KeyConfig{ID: "es", Algorithm: "ES256", PrivateKeyPath: writePrivateKey(t, ecKey)}, wantKty: "EC"},
+		{name: "EdDSA", key: KeyConfig{ID: "ed", Algorithm: "EdDSA", PrivateKeyPath: writePrivateKey(t, edKey)}, wantKty: "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwter := newTestJWTer(t, Config{ActiveKeyID: tt.key.ID, Keys: []KeyConfig{tt.key}})
+			id := uuid.New()
+
+			token, err := jwter.GenerateJWT(&models.TokenPayload{ID: id, Role: models.RoleEmployee})
+			require.NoError(t, err)
+
+			parsed, _, err := new(jwt.Parser).ParseUnverified(token.Token, jwt.MapClaims{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.key.ID, parsed.Header["kid"])
+			assert.Equal(t, tt.name, parsed.Header["alg"])
+
+			payload, err := jwter.ValidateJWT(token.Token)
+			require.NoError(t, err)
+			assert.Equal(t, id, payload.ID)
+			assert.Equal(t, models.RoleEmployee, payload.Role)
+
+			jwks := jwter.JWKS()
+			if tt.wantKty == "" {
+				assert.Empty(t, jwks.Keys)
+				return
+			}
+			require.Len(t, jwks.Keys, 1)
+			assert.Equal(t, tt.wantKty, jwks.Keys[0].Kty)
+			assert.Equal(t, tt.key.ID, jwks.Keys[0].Kid)
+		})
+	}
+}
+
+func TestJWTer_Rotation(t *testing.T) {
+	oldKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	legacy := newTestJWTer(t, Config{KeyJWT: []byte("legacy")})
+	legacyToken, err := legacy.GenerateJWT(&models.TokenPayload{ID: uuid.New(), Role: models.RoleModerator})
+	require.NoError(t, err)
+
+	before := newTestJWTer(t, Config{
+		KeyJWT:      []byte("legacy"),
+		ActiveKeyID: "old",
+		Keys:        []KeyConfig{{ID: "old", Algorithm: "ES256", PrivateKeyPath: writePrivateKey(t, oldKey)}},
+	})
+	oldToken, err := before.GenerateJWT(&models.TokenPayload{ID: uuid.New(), Role: models.RoleEmployee})
+	require.NoError(t, err)
+
+	after := newTestJWTer(t, Config{
+		KeyJWT:      []byte("legacy"),
+		ActiveKeyID: "new",
+		Keys: []KeyConfig{
+			{ID: "old", Algorithm: "ES256", PublicKeyPath: writePublicKey(t, &oldKey.PublicKey)},
+			{ID: "new", Algorithm: "EdDSA", PrivateKeyPath: writePrivateKey(t, newKey)},
+		},
+	})
+
+	_, err = after.ValidateJWT(legacyToken.Token)
+	assert.NoError(t, err)
+	_, err = after.ValidateJWT(oldToken.Token)
+	assert.NoError(t, err)
+	assert.Len(t, after.JWKS().Keys, 2)
+
+	withoutOld := newTestJWTer(t, Config{
+		ActiveKeyID: "new",
+		Keys:        []KeyConfig{{ID: "new", Algorithm: "EdDSA", PrivateKeyPath: writePrivateKey(t, newKey)}},
+	})
+	_, err = withoutOld.ValidateJWT(oldToken.Token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, err = withoutOld.ValidateJWT(legacyToken.Token)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr error
+	}{
+		{name: "no keys", cfg: Config{}, wantErr: ErrNoSigningKey},
+		{name: "unknown active key", cfg: Config{KeyJWT: []byte("secret"), ActiveKeyID: "missing"}, wantErr: ErrNoSigningKey},
+		{
+			name: "retired key as active",
+			cfg: Config{ActiveKeyID: "es", Keys: []KeyConfig{
+				{ID: "es", Algorithm: "ES256", PublicKeyPath: writePublicKey(t, &ecKey.PublicKey)},
+			}},
+			wantErr: ErrNoSigningKey,
+		},
+		{
+			name:    "unsupported algorithm",
+			cfg:     Config{ActiveKeyID: "ps", Keys: []KeyConfig{{ID: "ps", Algorithm: "PS512"}}},
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name: "duplicate kid",
+			cfg: Config{ActiveKeyID: "es", Keys: []KeyConfig{
+				{ID: "es", Algorithm: "ES256", PublicKeyPath: writePublicKey(t, &ecKey.PublicKey)},
+				{ID: "es", Algorithm: "ES256", PublicKeyPath: writePublicKey(t, &ecKey.PublicKey)},
+			}},
+			wantErr: ErrDuplicateKeyID,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(Params{
+				Config: tt.cfg,
+				Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+			})
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
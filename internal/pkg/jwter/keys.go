@@ -0,0 +1,173 @@
+package jwter
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"math/big"
+	"os"
+)
+
+var signingMethods = map[string]jwt.SigningMethod{
+	jwt.SigningMethodHS512.Alg(): jwt.SigningMethodHS512,
+	jwt.SigningMethodRS256.Alg(): jwt.SigningMethodRS256,
+	jwt.SigningMethodES256.Alg(): jwt.SigningMethodES256,
+	jwt.SigningMethodEdDSA.Alg(): jwt.SigningMethodEdDSA,
+}
+
+type key struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+func (k *key) canSign() bool {
+	return k.signKey != nil
+}
+
+func legacyKey(secret []byte) *key {
+	return &key{
+		method:    jwt.SigningMethodHS512,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+func loadKey(cfg KeyConfig) (*key, error) {
+	method, ok := signingMethods[cfg.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q for key %q", ErrUnsupportedAlgorithm, cfg.Algorithm, cfg.ID)
+	}
+
+	k := &key{
+		id:     cfg.ID,
+		method: method,
+	}
+
+	if method == jwt.SigningMethodHS512 {
+		secret := os.Getenv(cfg.SecretEnv)
+		if cfg.SecretEnv == "" || secret == "" {
+			return nil, fmt.Errorf("%w: empty secret for key %q", ErrInvalidKey, cfg.ID)
+		}
+		k.signKey = []byte(secret)
+		k.verifyKey = []byte(secret)
+		return k, nil
+	}
+
+	var err error
+	switch {
+	case cfg.PrivateKeyPath != "":
+		k.signKey, k.verifyKey, err = readPrivateKey(method, cfg.PrivateKeyPath)
+	case cfg.PublicKeyPath != "":
+		k.verifyKey, err = readPublicKey(method, cfg.PublicKeyPath)
+	default:
+		err = ErrInvalidKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load key %q: %w", cfg.ID, err)
+	}
+
+	return k, nil
+}
+
+func readPrivateKey(method jwt.SigningMethod, path string) (interface{}, interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch method {
+	case jwt.SigningMethodRS256:
+		private, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return private, &private.PublicKey, nil
+	case jwt.SigningMethodES256:
+		private, err := jwt.ParseECPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if private.Curve != elliptic.P256() {
+			return nil, nil, ErrInvalidKey
+		}
+		return private, &private.PublicKey, nil
+	case jwt.SigningMethodEdDSA:
+		private, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		edPrivate, ok := private.(ed25519.PrivateKey)
+		if !ok {
+			return nil, nil, ErrInvalidKey
+		}
+		return edPrivate, edPrivate.Public(), nil
+	default:
+		return nil, nil, ErrUnsupportedAlgorithm
+	}
+}
+
+func readPublicKey(method jwt.SigningMethod, path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch method {
+	case jwt.SigningMethodRS256:
+		return jwt.ParseRSAPublicKeyFromPEM(data)
+	case jwt.SigningMethodES256:
+		public, err := jwt.ParseECPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		if public.Curve != elliptic.P256() {
+			return nil, ErrInvalidKey
+		}
+		return public, nil
+	case jwt.SigningMethodEdDSA:
+		return jwt.ParseEdPublicKeyFromPEM(data)
+	default:
+		return nil, ErrUnsupportedAlgorithm
+	}
+}
+
+// jwk returns the public part of the key, symmetric keys are never published.
+func (k *key) jwk() (models.JWK, bool) {
+	jwk := models.JWK{
+		Kid: k.id,
+		Use: "sig",
+		Alg: k.method.Alg(),
+	}
+
+	switch public := k.verifyKey.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encodeBase64(public.N.Bytes())
+		jwk.E = encodeBase64(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (public.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = public.Curve.Params().Name
+		jwk.X = encodeBase64(public.X.FillBytes(make([]byte, size)))
+		jwk.Y = encodeBase64(public.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encodeBase64(public)
+	default:
+		return models.JWK{}, false
+	}
+
+	return jwk, true
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
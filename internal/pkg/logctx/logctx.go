@@ -0,0 +1,70 @@
+package logctx
+
+import (
+	"context"
+	"log/slog"
+)
+
+const (
+	// RequestIDHeader carries the request id in HTTP requests and responses.
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDMetadataKey carries the request id in gRPC metadata.
+	RequestIDMetadataKey = "x-request-id"
+
+	maxRequestIDLength = 128
+)
+
+type loggerKey struct{}
+
+type requestIDKey struct{}
+
+// With stores a request scoped logger, e.g. one that already has
+// the request id attached.
+func With(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// From returns the logger stored by With or fallback if
+// there is none.
+func From(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// WithAttrs adds attributes to the logger stored by With, e.g. the user
+// once the request is authenticated. Without a stored logger ctx is
+// returned unchanged.
+func WithAttrs(ctx context.Context, args ...any) context.Context {
+	logger, ok := ctx.Value(loggerKey{}).(*slog.Logger)
+	if !ok {
+		return ctx
+	}
+	return With(ctx, logger.With(args...))
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the id of the request being served or "".
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// ValidRequestID reports whether an id received from a client may be
+// reused. Long ids and control characters are rejected so that a caller
+// cannot forge log lines.
+func ValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < 0x21 || requestID[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
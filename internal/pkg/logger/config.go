@@ -0,0 +1,34 @@
+package logger
+
+import (
+	"github.com/marrgancovka/pvzService/internal/config/profile"
+	"time"
+)
+
+type Config struct {
+	// Environment is the profile of the app, see config.Config. It also
+	// selects the defaults for Level and Format.
+	Environment profile.Profile `yaml:"environment" env:"LOG_ENV"`
+	// Level is debug, info, warn or error. Defaults to info in prod and
+	// debug elsewhere, it can be changed at runtime via the admin endpoint.
+	Level string `yaml:"level" env:"LOG_LEVEL"`
+	// Format is text or json. Defaults to text in local and json elsewhere.
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+	// Outputs lists where records are written: stdout, stderr and file.
+	Outputs []string   `yaml:"outputs" env:"LOG_OUTPUTS" env-default:"stdout"`
+	File    FileConfig `yaml:"file"`
+	// RedactKeys are attribute keys masked in addition to the built-in
+	// ones, such as password and token.
+	RedactKeys []string `yaml:"redactKeys"`
+}
+
+// FileConfig is used by the file output. The file is rotated once it
+// reaches MaxSizeMB or is older than RotateEvery, rotated files are kept
+// for MaxAge and at most MaxBackups of them.
+type FileConfig struct {
+	Path        string        `yaml:"path" env:"LOG_FILE" env-default:"pvzService.log"`
+	MaxSizeMB   int64         `yaml:"maxSizeMB" env-default:"100"`
+	RotateEvery time.Duration `yaml:"rotateEvery" env-default:"24h"`
+	MaxAge      time.Duration `yaml:"maxAge" env-default:"168h"`
+	MaxBackups  int           `yaml:"maxBackups" env-default:"7"`
+}
@@ -0,0 +1,9 @@
+package logger
+
+import "errors"
+
+var (
+	ErrUnknownFormat = errors.New("unknown log format")
+	ErrUnknownOutput = errors.New("unknown log output")
+	ErrInvalidLevel  = errors.New("invalid log level")
+)
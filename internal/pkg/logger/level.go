@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/pkg/reader"
+	"github.com/marrgancovka/pvzService/pkg/responser"
+	"go.uber.org/fx"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type LevelRequest struct {
+	Level string `json:"level"`
+}
+
+type LevelHandlerParams struct {
+	fx.In
+
+	Level  *slog.LevelVar
+	Logger *slog.Logger
+}
+
+// LevelHandler reads and changes the level of the running service, e.g. to
+// get debug logs of a misbehaving instance without a restart. The change is
+// not persisted, a restart returns to the configured level.
+type LevelHandler struct {
+	level *slog.LevelVar
+	log   *slog.Logger
+}
+
+func NewLevelHandler(p LevelHandlerParams) *LevelHandler {
+	return &LevelHandler{
+		level: p.Level,
+		log:   p.Logger,
+	}
+}
+
+func (h *LevelHandler) GetLevel(w http.ResponseWriter, r *http.Request) {
+	responser.SendOk(w, http.StatusOK, LevelRequest{Level: levelName(h.level.Level())})
+}
+
+func (h *LevelHandler) SetLevel(w http.ResponseWriter, r *http.Request) {
+	const op = "logger.LevelHandler.SetLevel"
+	logger := logctx.From(r.Context(), h.log).With("op", op)
+
+	var req LevelRequest
+	if err := reader.ReadRequestData(r, &req); err != nil {
+		logger.Error("error read request data: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(req.Level)); err != nil {
+		responser.SendErr(w, http.StatusBadRequest, ErrInvalidLevel.Error())
+		return
+	}
+
+	previous := h.level.Level()
+	h.level.Set(level)
+	// logged at warn to be visible whatever the new level is
+	logger.Warn("log level changed", "from", levelName(previous), "to", levelName(level))
+	responser.SendOk(w, http.StatusOK, LevelRequest{Level: levelName(level)})
+}
+
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
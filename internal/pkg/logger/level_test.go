@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLevelHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		expectedCode  int
+		expectedLevel slog.Level
+	}{
+		{name: "sets level", body: `{"level":"debug"}`, expectedCode: http.StatusOK, expectedLevel: slog.LevelDebug},
+		{name: "case insensitive", body: `{"level":"ERROR"}`, expectedCode: http.StatusOK, expectedLevel: slog.LevelError},
+		{name: "unknown level", body: `{"level":"loud"}`, expectedCode: http.StatusBadRequest, expectedLevel: slog.LevelInfo},
+		{name: "invalid body", body: `{`, expectedCode: http.StatusBadRequest, expectedLevel: slog.LevelInfo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level := new(slog.LevelVar)
+			h := NewLevelHandler(LevelHandlerParams{Level: level, Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+
+			rec := httptest.NewRecorder()
+			h.SetLevel(rec, httptest.NewRequest(http.MethodPut, "/api/v1/admin/log-level", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedLevel, level.Level())
+
+			rec = httptest.NewRecorder()
+			h.GetLevel(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/log-level", nil))
+			assert.JSONEq(t, `{"level":"`+strings.ToLower(tt.expectedLevel.String())+`"}`, rec.Body.String())
+		})
+	}
+}
@@ -1,24 +1,126 @@
 package logger
 
 import (
+	"context"
+	"fmt"
+	"github.com/marrgancovka/pvzService/internal/config/profile"
+	"go.uber.org/fx"
 	"io"
 	"log/slog"
 	"os"
+	"strings"
 )
 
-const logFile = "pvzService.log"
+type Params struct {
+	fx.In
 
-func SetupLogger() (*slog.Logger, error) {
-	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	Lifecycle   fx.Lifecycle
+	Config      Config
+	Environment profile.Profile
+}
+
+type Out struct {
+	fx.Out
+
+	Logger *slog.Logger
+	Level  *slog.LevelVar
+}
+
+func SetupLogger(p Params) (Out, error) {
+	log, level, closer, err := New(p.Config, p.Environment)
 	if err != nil {
-		return nil, err
+		return Out{}, err
 	}
 
-	multiWriter := io.MultiWriter(os.Stdout, file)
+	if closer != nil {
+		// appended first, so the file is closed after every other hook logged
+		p.Lifecycle.Append(fx.Hook{
+			OnStop: func(context.Context) error {
+				return closer.Close()
+			},
+		})
+	}
+	return Out{Logger: log, Level: level}, nil
+}
 
-	log := slog.New(slog.NewJSONHandler(multiWriter, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	}))
+// New builds the logger described by cfg. The returned LevelVar changes the
+// level of the logger at runtime, the closer is nil without a file output.
+func New(cfg Config, env profile.Profile) (*slog.Logger, *slog.LevelVar, io.Closer, error) {
+	if cfg.Environment != "" {
+		env = cfg.Environment
+	}
+
+	level := new(slog.LevelVar)
+	if err := setLevel(level, cfg.Level, env); err != nil {
+		return nil, nil, nil, err
+	}
 
-	return log, nil
+	var writers []io.Writer
+	var closer io.Closer
+	for _, output := range cfg.Outputs {
+		switch strings.TrimSpace(output) {
+		case "stdout":
+			writers = append(writers, os.Stdout)
+		case "stderr":
+			writers = append(writers, os.Stderr)
+		case "file":
+			file, err := newRotatingFile(cfg.File)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			writers = append(writers, file)
+			closer = file
+		default:
+			return nil, nil, nil, fmt.Errorf("%w: %q", ErrUnknownOutput, output)
+		}
+	}
+
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: newRedactor(cfg.RedactKeys).replaceAttr,
+	}
+
+	var handler slog.Handler
+	out := io.MultiWriter(writers...)
+	switch format(cfg.Format, env) {
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	case "text":
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		if closer != nil {
+			_ = closer.Close()
+		}
+		return nil, nil, nil, fmt.Errorf("%w: %q", ErrUnknownFormat, cfg.Format)
+	}
+
+	return slog.New(handler), level, closer, nil
+}
+
+func setLevel(level *slog.LevelVar, value string, env profile.Profile) error {
+	if value == "" {
+		if env.IsProduction() {
+			level.Set(slog.LevelInfo)
+		} else {
+			level.Set(slog.LevelDebug)
+		}
+		return nil
+	}
+
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(value)); err != nil {
+		return fmt.Errorf("%w: %q", ErrInvalidLevel, value)
+	}
+	level.Set(parsed)
+	return nil
+}
+
+func format(value string, env profile.Profile) string {
+	if value != "" {
+		return value
+	}
+	if env == profile.Local {
+		return "text"
+	}
+	return "json"
 }
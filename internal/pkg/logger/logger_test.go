@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"bytes"
+	"github.com/marrgancovka/pvzService/internal/config/profile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            Config
+		env            profile.Profile
+		expectedLevel  slog.Level
+		expectedFormat string
+		expectedErr    error
+	}{
+		{name: "local defaults", env: profile.Local, expectedLevel: slog.LevelDebug, expectedFormat: "text"},
+		{name: "prod defaults", env: profile.Prod, expectedLevel: slog.LevelInfo, expectedFormat: "json"},
+		{name: "logger environment wins", cfg: Config{Environment: profile.Prod}, env: profile.Local, expectedLevel: slog.LevelInfo, expectedFormat: "json"},
+		{name: "explicit values", cfg: Config{Level: "warn", Format: "text"}, env: profile.Prod, expectedLevel: slog.LevelWarn, expectedFormat: "text"},
+		{name: "invalid level", cfg: Config{Level: "loud"}, expectedErr: ErrInvalidLevel},
+		{name: "unknown format", cfg: Config{Format: "xml"}, expectedErr: ErrUnknownFormat},
+		{name: "unknown output", cfg: Config{Outputs: []string{"syslog"}}, expectedErr: ErrUnknownOutput},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.Outputs = append(tt.cfg.Outputs, "stdout")
+			log, level, closer, err := New(tt.cfg, tt.env)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Nil(t, closer)
+			assert.Equal(t, tt.expectedLevel, level.Level())
+			switch tt.expectedFormat {
+			case "json":
+				assert.IsType(t, &slog.JSONHandler{}, log.Handler())
+			case "text":
+				assert.IsType(t, &slog.TextHandler{}, log.Handler())
+			}
+		})
+	}
+}
+
+func TestNew_FileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "service.log")
+	log, level, closer, err := New(Config{
+		Format:  "json",
+		Outputs: []string{"file"},
+		File:    FileConfig{Path: path, MaxSizeMB: 1},
+	}, profile.Prod)
+	require.NoError(t, err)
+
+	log.Debug("hidden")
+	level.Set(slog.LevelDebug)
+	log.Debug("visible", "password", "qwerty")
+	require.NoError(t, closer.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hidden")
+	assert.Contains(t, string(data), `"msg":"visible","password":"[REDACTED]"`)
+}
+
+func TestRedactor(t *testing.T) {
+	const jwt = "eyJhbGciOiJIUzUxMiJ9.eyJzdWIiOjF9.c2lnbmF0dXJl"
+	const apiKey = "pvz_0a1b2c3d_00112233445566778899aabbccddeeff"
+
+	var out bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{
+		ReplaceAttr: newRedactor([]string{"card_number"}).replaceAttr,
+	}))
+
+	log.With("Authorization", "Bearer "+jwt).Info("success login user: "+jwt,
+		"token", "secret-token",
+		"New-Password", "qwerty",
+		"card_number", "4242",
+		"header", "X-API-Key: "+apiKey,
+		"user_id", "42",
+	)
+
+	assert.NotContains(t, out.String(), jwt)
+	assert.NotContains(t, out.String(), apiKey)
+	assert.NotContains(t, out.String(), "secret-token")
+	assert.NotContains(t, out.String(), "qwerty")
+	assert.NotContains(t, out.String(), "4242")
+	assert.Contains(t, out.String(), `"msg":"success login user: [REDACTED]"`)
+	assert.Contains(t, out.String(), `"header":"X-API-Key: [REDACTED]"`)
+	assert.Contains(t, out.String(), `"user_id":"42"`)
+}
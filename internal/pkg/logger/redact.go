@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+var defaultRedactKeys = []string{
+	"password", "old_password", "new_password",
+	"token", "access_token", "refresh_token", "reset_token",
+	"api_key", "secret", "authorization", "cookie",
+}
+
+// secretPatterns catch secrets that ended up inside a message or a value
+// of an innocent key: JWTs and the API keys issued by the auth service.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	regexp.MustCompile(`pvz_[0-9a-f]{8}_[0-9a-f]+`),
+}
+
+type redactor struct {
+	keys map[string]struct{}
+}
+
+func newRedactor(extraKeys []string) *redactor {
+	r := &redactor{keys: make(map[string]struct{})}
+	for _, key := range append(defaultRedactKeys, extraKeys...) {
+		r.keys[normalizeKey(key)] = struct{}{}
+	}
+	return r
+}
+
+// replaceAttr is used as slog.HandlerOptions.ReplaceAttr, so it sees the
+// message and the attributes added with Logger.With as well.
+func (r *redactor) replaceAttr(_ []string, a slog.Attr) slog.Attr {
+	if _, ok := r.keys[normalizeKey(a.Key)]; ok {
+		return slog.String(a.Key, redacted)
+	}
+	if a.Value.Kind() == slog.KindString {
+		if value, ok := scrub(a.Value.String()); ok {
+			return slog.String(a.Key, value)
+		}
+	}
+	return a
+}
+
+func scrub(value string) (string, bool) {
+	changed := false
+	for _, pattern := range secretPatterns {
+		if pattern.MatchString(value) {
+			value = pattern.ReplaceAllString(value, redacted)
+			changed = true
+		}
+	}
+	return value, changed
+}
+
+func normalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "-", "_")
+}
@@ -0,0 +1,153 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotatingFile is an append-only log file that is renamed to
+// <name>-<time><ext> once it grows past maxSize or gets older than
+// rotateEvery. Old backups are removed after every rotation.
+type rotatingFile struct {
+	cfg     FileConfig
+	maxSize int64
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	now      func() time.Time
+}
+
+func newRotatingFile(cfg FileConfig) (*rotatingFile, error) {
+	f := &rotatingFile{
+		cfg:     cfg,
+		maxSize: cfg.MaxSizeMB * 1024 * 1024,
+		now:     time.Now,
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *rotatingFile) shouldRotate(next int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.maxSize > 0 && f.size+next > f.maxSize {
+		return true
+	}
+	return f.cfg.RotateEvery > 0 && f.now().Sub(f.openedAt) >= f.cfg.RotateEvery
+}
+
+func (f *rotatingFile) open() error {
+	if dir := filepath.Dir(f.cfg.Path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create log dir: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(f.cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	// the age of a file kept from a previous run counts from its last write
+	f.openedAt = f.now()
+	if info.Size() > 0 {
+		f.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	// when the rename fails the current file is reopened, so that records
+	// are still written while the rotation is retried on the next write
+	renameErr := os.Rename(f.cfg.Path, f.backupName(f.now()))
+	if err := f.open(); err != nil {
+		return err
+	}
+	if renameErr == nil {
+		f.removeOldBackups()
+	}
+	return nil
+}
+
+func (f *rotatingFile) backupName(t time.Time) string {
+	ext := filepath.Ext(f.cfg.Path)
+	return strings.TrimSuffix(f.cfg.Path, ext) + "-" + t.Format(backupTimeFormat) + ext
+}
+
+// removeOldBackups is best effort, a failure must not stop the logging.
+func (f *rotatingFile) removeOldBackups() {
+	ext := filepath.Ext(f.cfg.Path)
+	pattern := strings.TrimSuffix(f.cfg.Path, ext) + "-*" + ext
+	backups, err := filepath.Glob(pattern)
+	if err != nil {
+		return
+	}
+	// the time format sorts lexically, newest first
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+
+	for i, name := range backups {
+		expired := false
+		if f.cfg.MaxAge > 0 {
+			if info, err := os.Stat(name); err == nil && f.now().Sub(info.ModTime()) > f.cfg.MaxAge {
+				expired = true
+			}
+		}
+		if expired || (f.cfg.MaxBackups > 0 && i >= f.cfg.MaxBackups) {
+			_ = os.Remove(name)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func backups(t *testing.T, path string) []string {
+	names, err := filepath.Glob(strings.TrimSuffix(path, ".log") + "-*.log")
+	require.NoError(t, err)
+	return names
+}
+
+func TestRotatingFile(t *testing.T) {
+	t.Run("rotates by size", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "service.log")
+		f, err := newRotatingFile(FileConfig{Path: path, MaxBackups: 10})
+		require.NoError(t, err)
+		defer f.Close()
+		f.maxSize = 10
+
+		now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+		f.now = func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		}
+
+		for range 3 {
+			_, err = f.Write([]byte("0123456789"))
+			require.NoError(t, err)
+		}
+
+		assert.Len(t, backups(t, path), 2)
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "0123456789", string(data))
+	})
+
+	t.Run("rotates by time", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "service.log")
+		now := time.Now()
+		f, err := newRotatingFile(FileConfig{Path: path, RotateEvery: time.Hour})
+		require.NoError(t, err)
+		defer f.Close()
+		f.now = func() time.Time { return now }
+
+		_, err = f.Write([]byte("first\n"))
+		require.NoError(t, err)
+		now = now.Add(30 * time.Minute)
+		_, err = f.Write([]byte("second\n"))
+		require.NoError(t, err)
+		assert.Empty(t, backups(t, path))
+
+		now = now.Add(time.Hour)
+		_, err = f.Write([]byte("third\n"))
+		require.NoError(t, err)
+		assert.Len(t, backups(t, path), 1)
+	})
+
+	t.Run("keeps max backups", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "service.log")
+		f, err := newRotatingFile(FileConfig{Path: path, MaxBackups: 2})
+		require.NoError(t, err)
+		defer f.Close()
+		f.maxSize = 1
+
+		now := time.Now()
+		f.now = func() time.Time {
+			now = now.Add(time.Second)
+			return now
+		}
+		for range 5 {
+			_, err = f.Write([]byte("x"))
+			require.NoError(t, err)
+		}
+
+		assert.Len(t, backups(t, path), 2)
+	})
+
+	t.Run("removes expired backups", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "service.log")
+		expired := strings.TrimSuffix(path, ".log") + "-2020-01-01T00-00-00.000.log"
+		require.NoError(t, os.WriteFile(expired, []byte("old"), 0644))
+		old := time.Now().Add(-30 * 24 * time.Hour)
+		require.NoError(t, os.Chtimes(expired, old, old))
+
+		f, err := newRotatingFile(FileConfig{Path: path, MaxAge: 24 * time.Hour})
+		require.NoError(t, err)
+		defer f.Close()
+		f.maxSize = 1
+
+		for range 2 {
+			_, err = f.Write([]byte("x"))
+			require.NoError(t, err)
+		}
+
+		names := backups(t, path)
+		assert.Len(t, names, 1)
+		assert.NotContains(t, names, expired)
+	})
+
+	t.Run("write after close", func(t *testing.T) {
+		f, err := newRotatingFile(FileConfig{Path: filepath.Join(t.TempDir(), "service.log")})
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		_, err = f.Write([]byte("x"))
+		assert.ErrorIs(t, err, os.ErrClosed)
+	})
+}
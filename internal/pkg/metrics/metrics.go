@@ -12,6 +12,11 @@ type Metrics interface {
 	CreatedPvzTotal(string)
 	CreatedReceptionsTotal(string)
 	AddedProductTotal(string)
+	FailedLoginsTotal(string)
+	LoginLockoutsTotal(string)
+	GRPCClientHandled(string, string, time.Duration)
+	GRPCClientCircuitOpen(string, bool)
+	GRPCServerHandled(string, string, time.Duration)
 }
 
 type Metric struct {
@@ -20,6 +25,13 @@ type Metric struct {
 	createdPvzTotal       *prometheus.CounterVec
 	createdReceptionTotal *prometheus.CounterVec
 	addedProductTotal     *prometheus.CounterVec
+	failedLoginsTotal     *prometheus.CounterVec
+	loginLockoutsTotal    *prometheus.CounterVec
+	grpcClientHandled     *prometheus.CounterVec
+	grpcClientDuration    *prometheus.HistogramVec
+	grpcClientCircuitOpen *prometheus.GaugeVec
+	grpcServerHandled     *prometheus.CounterVec
+	grpcServerDuration    *prometheus.HistogramVec
 }
 
 func New() *Metric {
@@ -53,12 +65,61 @@ func New() *Metric {
 	}, []string{"type"})
 	prometheus.MustRegister(addedProductTotal)
 
+	failedLoginsTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_failed_logins_total",
+		Help: "Total number of failed login attempts",
+	}, []string{"reason"})
+	prometheus.MustRegister(failedLoginsTotal)
+
+	loginLockoutsTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_login_lockouts_total",
+		Help: "Total number of login lockouts",
+	}, []string{"kind"})
+	prometheus.MustRegister(loginLockoutsTotal)
+
+	grpcClientHandled := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_handled_total",
+		Help: "Total number of gRPC client calls by method and status code",
+	}, []string{"method", "code"})
+	prometheus.MustRegister(grpcClientHandled)
+
+	grpcClientDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "grpc_client_handling_seconds",
+		Help: "Duration of gRPC client calls including retries",
+	}, []string{"method"})
+	prometheus.MustRegister(grpcClientDuration)
+
+	grpcClientCircuitOpen := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_client_circuit_open",
+		Help: "Whether the circuit breaker of the gRPC client is open",
+	}, []string{"target"})
+	prometheus.MustRegister(grpcClientCircuitOpen)
+
+	grpcServerHandled := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "Total number of gRPC calls handled by method and status code",
+	}, []string{"method", "code"})
+	prometheus.MustRegister(grpcServerHandled)
+
+	grpcServerDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "grpc_server_handling_seconds",
+		Help: "Duration of gRPC calls handled by method and status code",
+	}, []string{"method", "code"})
+	prometheus.MustRegister(grpcServerDuration)
+
 	return &Metric{
 		requestsTotal:         requestsTotal,
 		responseTime:          responseTime,
 		createdPvzTotal:       createdPvzTotal,
 		createdReceptionTotal: createdReceptionTotal,
 		addedProductTotal:     addedProductTotal,
+		failedLoginsTotal:     failedLoginsTotal,
+		loginLockoutsTotal:    loginLockoutsTotal,
+		grpcClientHandled:     grpcClientHandled,
+		grpcClientDuration:    grpcClientDuration,
+		grpcClientCircuitOpen: grpcClientCircuitOpen,
+		grpcServerHandled:     grpcServerHandled,
+		grpcServerDuration:    grpcServerDuration,
 	}
 }
 
@@ -81,3 +142,29 @@ func (m *Metric) CreatedReceptionsTotal(pvzId string) {
 func (m *Metric) AddedProductTotal(productType string) {
 	m.addedProductTotal.WithLabelValues(productType).Inc()
 }
+
+func (m *Metric) FailedLoginsTotal(reason string) {
+	m.failedLoginsTotal.WithLabelValues(reason).Inc()
+}
+
+func (m *Metric) LoginLockoutsTotal(kind string) {
+	m.loginLockoutsTotal.WithLabelValues(kind).Inc()
+}
+
+func (m *Metric) GRPCClientHandled(method, code string, duration time.Duration) {
+	m.grpcClientHandled.WithLabelValues(method, code).Inc()
+	m.grpcClientDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+func (m *Metric) GRPCClientCircuitOpen(target string, open bool) {
+	value := 0.0
+	if open {
+		value = 1
+	}
+	m.grpcClientCircuitOpen.WithLabelValues(target).Set(value)
+}
+
+func (m *Metric) GRPCServerHandled(method, code string, duration time.Duration) {
+	m.grpcServerHandled.WithLabelValues(method, code).Inc()
+	m.grpcServerDuration.WithLabelValues(method, code).Observe(duration.Seconds())
+}
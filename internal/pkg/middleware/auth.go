@@ -3,7 +3,11 @@ package middleware
 import (
 	"context"
 	"errors"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/config/profile"
+	"github.com/marrgancovka/pvzService/internal/models"
 	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
 	"github.com/marrgancovka/pvzService/internal/services/auth"
 	"github.com/marrgancovka/pvzService/pkg/responser"
 	"go.uber.org/fx"
@@ -11,52 +15,188 @@ import (
 	"strings"
 )
 
-const RoleInContext string = "RoleInContext"
+const (
+	RoleInContext        string = "RoleInContext"
+	UserIDInContext      string = "UserIDInContext"
+	PrincipalInContext   string = "PrincipalInContext"
+	TokenInContext       string = "TokenInContext"
+	CredentialsInContext string = "CredentialsInContext"
+)
+
+const (
+	APIKeyHeader = "X-API-Key"
+	// the gRPC metadata keys carrying the same credentials
+	APIKeyMetadataKey        = "x-api-key"
+	AuthorizationMetadataKey = "authorization"
+)
+
+// Credentials are the raw API key and Authorization value of a request.
+type Credentials struct {
+	APIKey        string
+	Authorization string
+}
 
 type AuthMiddlewareParams struct {
 	fx.In
 
-	JWTer auth.JWTer
+	JWTer       auth.JWTer
+	APIKeys     auth.APIKeyAuthenticator
+	Sessions    auth.SessionChecker
+	AuthConfig  auth.Config
+	Environment profile.Profile
 }
 
 type AuthMiddleware struct {
-	jwt auth.JWTer
+	jwt        auth.JWTer
+	apiKeys    auth.APIKeyAuthenticator
+	sessions   auth.SessionChecker
+	allowDummy bool
 }
 
 func NewAuthMiddleware(p AuthMiddlewareParams) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwt: p.JWTer,
+		jwt:        p.JWTer,
+		apiKeys:    p.APIKeys,
+		sessions:   p.Sessions,
+		allowDummy: p.Environment.AllowsDummyLogin() && p.AuthConfig.DummyLogin,
 	}
 }
 
 func (authMD *AuthMiddleware) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			responser.SendErr(w, http.StatusForbidden, "not authorized")
+		ctx, err := authMD.Authenticate(r.Context(), Credentials{
+			APIKey:        r.Header.Get(APIKeyHeader),
+			Authorization: r.Header.Get("Authorization"),
+		})
+		if err != nil {
+			if IsAuthError(err) {
+				responser.SendErr(w, http.StatusForbidden, err.Error())
+				return
+			}
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
 			return
 		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			responser.SendErr(w, http.StatusForbidden, "Authorization header format must be Bearer {token}")
-			return
+// Authenticate checks the API key or, without one, the bearer token and
+// returns ctx carrying the caller. It is shared by the HTTP middleware and
+// the gRPC server, errors matched by IsAuthError reject the caller, others
+// are failures of the service.
+func (authMD *AuthMiddleware) Authenticate(ctx context.Context, creds Credentials) (context.Context, error) {
+	var principal *models.Principal
+	var tokenPayload *models.TokenPayload
+
+	if creds.APIKey != "" {
+		var err error
+		principal, err = authMD.apiKeys.AuthenticateAPIKey(ctx, creds.APIKey)
+		if err != nil {
+			return ctx, err
+		}
+	} else {
+		if creds.Authorization == "" {
+			return ctx, ErrNotAuthorized
 		}
 
-		token := parts[1]
+		parts := strings.Split(creds.Authorization, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			return ctx, ErrAuthHeaderFormat
+		}
 
-		claims, err := authMD.jwt.ValidateJWT(token)
+		claims, err := authMD.jwt.ValidateJWT(parts[1])
 		if err != nil && !errors.Is(err, jwter.ErrNoID) {
-			responser.SendErr(w, http.StatusForbidden, "Invalid token")
-			return
+			return ctx, ErrInvalidToken
 		}
 
-		if !claims.Role.IsValid() {
-			responser.SendErr(w, http.StatusForbidden, "Invalid role")
-			return
+		if claims.Dummy && !authMD.allowDummy {
+			return ctx, ErrDummyToken
 		}
 
-		ctx := context.WithValue(r.Context(), RoleInContext, claims.Role)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+		pvzIDs, err := authMD.sessions.CheckSession(ctx, claims)
+		if err != nil {
+			return ctx, err
+		}
+
+		principal = &models.Principal{
+			ID:     claims.ID,
+			Role:   claims.Role,
+			PvzIDs: pvzIDs,
+		}
+		tokenPayload = claims
+	}
+
+	if !principal.Role.IsValid() {
+		return ctx, ErrInvalidRole
+	}
+
+	ctx = context.WithValue(ctx, PrincipalInContext, principal)
+	ctx = context.WithValue(ctx, RoleInContext, principal.Role)
+	ctx = context.WithValue(ctx, CredentialsInContext, creds)
+	ctx = logctx.WithAttrs(ctx, "role", principal.Role)
+	if principal.ID != uuid.Nil {
+		ctx = context.WithValue(ctx, UserIDInContext, principal.ID)
+		ctx = logctx.WithAttrs(ctx, "user_id", principal.ID)
+	}
+	if principal.APIKeyID != uuid.Nil {
+		ctx = logctx.WithAttrs(ctx, "api_key_id", principal.APIKeyID)
+	}
+	if tokenPayload != nil {
+		ctx = context.WithValue(ctx, TokenInContext, tokenPayload)
+	}
+	return ctx, nil
+}
+
+// IsAuthError reports whether Authenticate rejected the credentials rather
+// than failed.
+func IsAuthError(err error) bool {
+	return errors.Is(err, ErrNotAuthorized) ||
+		errors.Is(err, ErrAuthHeaderFormat) ||
+		errors.Is(err, ErrInvalidToken) ||
+		errors.Is(err, ErrDummyToken) ||
+		errors.Is(err, ErrInvalidRole) ||
+		errors.Is(err, auth.ErrInvalidAPIKey) ||
+		errors.Is(err, auth.ErrTokenRevoked)
+}
+
+// CredentialsFromContext returns the credentials the caller was
+// authenticated with, the gRPC client forwards them to the pvz service.
+func CredentialsFromContext(ctx context.Context) (Credentials, bool) {
+	creds, ok := ctx.Value(CredentialsInContext).(Credentials)
+	return creds, ok
+}
+
+// PrincipalFromContext returns the caller authenticated by AuthMiddleware
+// or nil for unauthenticated requests.
+func PrincipalFromContext(ctx context.Context) *models.Principal {
+	principal, _ := ctx.Value(PrincipalInContext).(*models.Principal)
+	return principal
+}
+
+// TokenFromContext returns the validated JWT of the request or nil if the
+// caller was authenticated otherwise.
+func TokenFromContext(ctx context.Context) *models.TokenPayload {
+	payload, _ := ctx.Value(TokenInContext).(*models.TokenPayload)
+	return payload
+}
+
+// CanAccessPvz reports whether the caller may act on the pvz. Requests
+// without a principal are not restricted here; access to them is decided
+// by the route permissions.
+func CanAccessPvz(ctx context.Context, pvzID uuid.UUID) bool {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil {
+		return true
+	}
+	return principal.CanAccessPvz(pvzID)
+}
+
+// AccessiblePvzIDs returns the pvz the caller is restricted to, nil when it
+// may access all of them. Collection routes filter their results by it.
+func AccessiblePvzIDs(ctx context.Context) []uuid.UUID {
+	principal := PrincipalFromContext(ctx)
+	if principal == nil {
+		return nil
+	}
+	return principal.PvzIDs
 }
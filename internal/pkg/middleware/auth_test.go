@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/config/profile"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type rejectingAPIKeys struct{}
+
+func (rejectingAPIKeys) AuthenticateAPIKey(context.Context, string) (*models.Principal, error) {
+	return nil, auth.ErrInvalidAPIKey
+}
+
+type staticSessions struct {
+	pvzIDs []uuid.UUID
+	err    error
+}
+
+func (s staticSessions) CheckSession(context.Context, *models.TokenPayload) ([]uuid.UUID, error) {
+	return s.pvzIDs, s.err
+}
+
+func FuzzAuthMiddleware(f *testing.F) {
+	jwt, err := jwter.New(jwter.Params{
+		Config: jwter.Config{
+			ExpirationTime: time.Hour,
+			KeyJWT:         []byte("secret"),
+			Issuer:         "pvz-service",
+			Audience:       []string{"pvz-service"},
+		},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	valid, err := jwt.GenerateJWT(&models.TokenPayload{ID: uuid.New(), Role: models.RoleEmployee})
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add("Bearer " + valid.Token)
+	f.Add("Bearer ")
+	f.Add("Bearer a.b.c")
+	f.Add("Basic dXNlcjpwYXNz")
+	f.Add("Bearer eyJhbGciOiJIUzUxMiJ9.eyJzdWIiOjEsInJvbGUiOjJ9.c2ln")
+
+	m := NewAuthMiddleware(AuthMiddlewareParams{JWTer: jwt, APIKeys: rejectingAPIKeys{}, Sessions: staticSessions{}})
+	handler := m.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	f.Fuzz(func(t *testing.T, header string) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/pvz", nil)
+		req.Header.Set("Authorization", header)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK && rec.Code != http.StatusForbidden {
+			t.Fatalf("unexpected status %d", rec.Code)
+		}
+	})
+}
+
+func TestAuthMiddleware_DummyToken(t *testing.T) {
+	jwt, err := jwter.New(jwter.Params{
+		Config: jwter.Config{
+			ExpirationTime: time.Hour,
+			KeyJWT:         []byte("secret"),
+		},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.GenerateJWT(&models.TokenPayload{Role: models.RoleModerator, Dummy: true})
+	if err != nil && !errors.Is(err, jwter.ErrNoID) {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		environment  profile.Profile
+		dummyLogin   bool
+		expectedCode int
+	}{
+		{name: "local with dummy login", environment: profile.Local, dummyLogin: true, expectedCode: http.StatusOK},
+		{name: "local without dummy login", environment: profile.Local, dummyLogin: false, expectedCode: http.StatusForbidden},
+		{name: "production", environment: profile.Prod, dummyLogin: true, expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewAuthMiddleware(AuthMiddlewareParams{
+				JWTer:       jwt,
+				APIKeys:     rejectingAPIKeys{},
+				Sessions:    staticSessions{},
+				AuthConfig:  auth.Config{DummyLogin: tt.dummyLogin},
+				Environment: tt.environment,
+			})
+			handler := m.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/pvz", nil)
+			req.Header.Set("Authorization", "Bearer "+token.Token)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestAuthMiddleware_Session(t *testing.T) {
+	jwt, err := jwter.New(jwter.Params{
+		Config: jwter.Config{
+			ExpirationTime: time.Hour,
+			KeyJWT:         []byte("secret"),
+		},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.GenerateJWT(&models.TokenPayload{ID: uuid.New(), Role: models.RoleEmployee})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		sessionErr   error
+		expectedCode int
+	}{
+		{name: "active", sessionErr: nil, expectedCode: http.StatusOK},
+		{name: "revoked", sessionErr: auth.ErrTokenRevoked, expectedCode: http.StatusForbidden},
+		{name: "lookup failed", sessionErr: errors.New("db error"), expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewAuthMiddleware(AuthMiddlewareParams{
+				JWTer:    jwt,
+				APIKeys:  rejectingAPIKeys{},
+				Sessions: staticSessions{err: tt.sessionErr},
+			})
+			handler := m.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/pvz", nil)
+			req.Header.Set("Authorization", "Bearer "+token.Token)
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestAuthMiddleware_SessionPvz(t *testing.T) {
+	jwt, err := jwter.New(jwter.Params{
+		Config: jwter.Config{
+			ExpirationTime: time.Hour,
+			KeyJWT:         []byte("secret"),
+		},
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := jwt.GenerateJWT(&models.TokenPayload{ID: uuid.New(), Role: models.RoleEmployee})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assigned, other := uuid.New(), uuid.New()
+	m := NewAuthMiddleware(AuthMiddlewareParams{
+		JWTer:    jwt,
+		APIKeys:  rejectingAPIKeys{},
+		Sessions: staticSessions{pvzIDs: []uuid.UUID{assigned}},
+	})
+	handler := m.AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, []uuid.UUID{assigned}, AccessiblePvzIDs(r.Context()))
+		assert.True(t, CanAccessPvz(r.Context(), assigned))
+		assert.False(t, CanAccessPvz(r.Context(), other))
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/pvz", nil)
+	req.Header.Set("Authorization", "Bearer "+token.Token)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,11 @@
+package middleware
+
+import "errors"
+
+var (
+	ErrNotAuthorized    = errors.New("not authorized")
+	ErrAuthHeaderFormat = errors.New("authorization header format must be Bearer {token}")
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrDummyToken       = errors.New("dummy tokens are not accepted")
+	ErrInvalidRole      = errors.New("invalid role")
+)
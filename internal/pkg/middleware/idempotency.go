@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/pkg/idempotency"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/pkg/responser"
+	"go.uber.org/fx"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotencyReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+type IdempotencyMiddlewareParams struct {
+	fx.In
+
+	Store  idempotency.Store
+	Config idempotency.Config
+	Logger *slog.Logger
+}
+
+type IdempotencyMiddleware struct {
+	store       idempotency.Store
+	maxBodySize int64
+	log         *slog.Logger
+}
+
+func NewIdempotencyMiddleware(p IdempotencyMiddlewareParams) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{
+		store:       p.Store,
+		maxBodySize: p.Config.MaxBodySize,
+		log:         p.Logger,
+	}
+}
+
+// IdempotencyMiddleware replays the stored response for a repeated mutating request with the same Idempotency-Key.
+// On authenticated routes it must be registered after AuthMiddleware, because keys are scoped by the
+// authenticated user or, for dummy tokens, by the token.
+func (m *IdempotencyMiddleware) IdempotencyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" || !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		const op = "middleware.IdempotencyMiddleware"
+		logger := logctx.From(r.Context(), m.log).With("op", op)
+
+		if len(key) > maxIdempotencyKeyLength {
+			responser.SendErr(w, http.StatusBadRequest, idempotency.ErrKeyTooLong.Error())
+			return
+		}
+
+		// the body is read before the handler applies its own limit
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, m.maxBodySize))
+		if err != nil {
+			maxBytesErr := &http.MaxBytesError{}
+			if errors.As(err, &maxBytesErr) {
+				responser.SendErr(w, http.StatusRequestEntityTooLarge, idempotency.ErrBodyTooLarge.Error())
+				return
+			}
+			logger.Error("error read request body: " + err.Error())
+			responser.SendErr(w, http.StatusBadRequest, "bad request")
+			return
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := idempotencyScope(r)
+		requestHash := hashRequest(r, body)
+
+		stored, err := m.store.Reserve(r.Context(), scope, key, requestHash)
+		if err != nil {
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+			return
+		}
+
+		if stored != nil {
+			switch {
+			case stored.RequestHash != requestHash:
+				logger.Warn("idempotency key reused with a different request")
+				responser.SendErr(w, http.StatusUnprocessableEntity, idempotency.ErrKeyReused.Error())
+			case stored.StatusCode == 0:
+				responser.SendErr(w, http.StatusConflict, idempotency.ErrInProgress.Error())
+			default:
+				logger.Info("replay stored response", "status", stored.StatusCode)
+				if stored.ContentType != "" {
+					w.Header().Set("Content-Type", stored.ContentType)
+				}
+				w.Header().Set(IdempotencyReplayedHeader, "true")
+				w.WriteHeader(stored.StatusCode)
+				_, _ = w.Write(stored.Body)
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			// the key is released if the handler panicked, failed on the server side or rejected the caller,
+			// so the client can retry
+			if completed {
+				return
+			}
+			if err := m.store.Release(context.WithoutCancel(r.Context()), scope, key); err != nil {
+				logger.Error("failed to release idempotency key: " + err.Error())
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+
+		if !storable(recorder.status) {
+			return
+		}
+
+		record := &idempotency.Record{
+			RequestHash: requestHash,
+			StatusCode:  recorder.status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err = m.store.Complete(context.WithoutCancel(r.Context()), scope, key, record); err != nil {
+			logger.Error("failed to store response: " + err.Error())
+			return
+		}
+		completed = true
+	})
+}
+
+// storable reports whether the response is replayed for the key. Server errors are retried, and so are
+// rejected callers: RBAC runs after this middleware, a replayed 403 would outlive a granted permission.
+func storable(status int) bool {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return false
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return false
+	default:
+		return true
+	}
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func idempotencyScope(r *http.Request) string {
+	ctx := r.Context()
+	principal := "anonymous:" + remoteHost(r)
+	if role := ctx.Value(RoleInContext); role != nil {
+		principal = fmt.Sprint(role)
+		if userID := ctx.Value(UserIDInContext); userID != nil {
+			principal += ":" + fmt.Sprint(userID)
+		} else if token := TokenFromContext(ctx); token != nil && token.TokenID != "" {
+			// dummy tokens carry no user, every one of them is its own session
+			principal += ":session:" + token.TokenID
+		}
+		if p := PrincipalFromContext(ctx); p != nil && p.APIKeyID != uuid.Nil {
+			principal += ":key:" + p.APIKeyID.String()
+		}
+	}
+	return r.Method + " " + r.URL.Path + " " + principal
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func hashRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.status = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.wroteHeader = true
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/idempotency"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]*idempotency.Record
+}
+
+func (s *memoryStore) Reserve(_ context.Context, scope, key, requestHash string) (*idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[scope+key]; ok {
+		return record, nil
+	}
+	s.records[scope+key] = &idempotency.Record{RequestHash: requestHash}
+	return nil, nil
+}
+
+func (s *memoryStore) Complete(_ context.Context, scope, key string, record *idempotency.Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records[scope+key] = record
+	return nil
+}
+
+func (s *memoryStore) Release(_ context.Context, scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, scope+key)
+	return nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	store := &memoryStore{records: make(map[string]*idempotency.Record)}
+	md := &IdempotencyMiddleware{
+		store:       store,
+		maxBodySize: 1 << 10,
+		log:         slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	calls := 0
+	status := http.StatusCreated
+	handler := md.IdempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	}))
+
+	send := func(key, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/products", bytes.NewBufferString(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("key-1", `{"type":"обувь"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, 1, calls)
+
+	replayed := send("key-1", `{"type":"обувь"}`)
+	assert.Equal(t, http.StatusCreated, replayed.Code)
+	assert.Equal(t, first.Body.String(), replayed.Body.String())
+	assert.Equal(t, "true", replayed.Header().Get(IdempotencyReplayedHeader))
+	assert.Equal(t, "application/json", replayed.Header().Get("Content-Type"))
+	assert.Equal(t, 1, calls)
+
+	reused := send("key-1", `{"type":"одежда"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.Equal(t, 1, calls)
+
+	send("", `{"type":"обувь"}`)
+	send("", `{"type":"обувь"}`)
+	assert.Equal(t, 3, calls)
+
+	status = http.StatusInternalServerError
+	assert.Equal(t, http.StatusInternalServerError, send("key-2", `{}`).Code)
+	status = http.StatusCreated
+	assert.Equal(t, http.StatusCreated, send("key-2", `{}`).Code)
+	assert.Equal(t, 5, calls)
+
+	// a denied request is executed again once the caller got the permission
+	status = http.StatusForbidden
+	assert.Equal(t, http.StatusForbidden, send("key-3", `{}`).Code)
+	status = http.StatusCreated
+	assert.Equal(t, http.StatusCreated, send("key-3", `{}`).Code)
+	assert.Equal(t, 7, calls)
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	store := &memoryStore{records: make(map[string]*idempotency.Record)}
+	md := &IdempotencyMiddleware{
+		store:       store,
+		maxBodySize: 1 << 10,
+		log:         slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/receptions", bytes.NewBufferString(`{}`))
+	req.Header.Set(IdempotencyKeyHeader, "key")
+
+	_, err := store.Reserve(context.Background(), idempotencyScope(req), "key", hashRequest(req, []byte(`{}`)))
+	assert.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	md.IdempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not be called while the original request is in progress")
+	})).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestIdempotencyMiddleware_BodyTooLarge(t *testing.T) {
+	store := &memoryStore{records: make(map[string]*idempotency.Record)}
+	md := &IdempotencyMiddleware{
+		store:       store,
+		maxBodySize: 16,
+		log:         slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	handler := md.IdempotencyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	send := func(key, body string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/pvz/import", bytes.NewBufferString(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	large := strings.Repeat("a", 17)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send("key", large))
+	assert.Empty(t, store.records)
+	assert.Equal(t, http.StatusCreated, send("key", large[:16]))
+	// without a key the body is left to the handler
+	assert.Equal(t, http.StatusCreated, send("", large))
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	request := func(remoteAddr string, values map[string]any) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/receptions", nil)
+		req.RemoteAddr = remoteAddr
+		ctx := req.Context()
+		for key, value := range values {
+			ctx = context.WithValue(ctx, key, value)
+		}
+		return req.WithContext(ctx)
+	}
+	dummy := func(tokenID string) map[string]any {
+		return map[string]any{
+			RoleInContext:  models.RoleEmployee,
+			TokenInContext: &models.TokenPayload{Role: models.RoleEmployee, Dummy: true, TokenID: tokenID},
+		}
+	}
+	user := map[string]any{RoleInContext: models.RoleEmployee, UserIDInContext: uuid.New()}
+
+	tests := []struct {
+		name          string
+		first, second *http.Request
+		same          bool
+	}{
+		{name: "dummy sessions", first: request("10.0.0.1:1", dummy("a")), second: request("10.0.0.1:1", dummy("b"))},
+		{name: "same dummy session", first: request("10.0.0.1:1", dummy("a")), second: request("10.0.0.2:1", dummy("a")), same: true},
+		{name: "anonymous clients", first: request("10.0.0.1:1", nil), second: request("10.0.0.2:1", nil)},
+		{name: "anonymous client", first: request("10.0.0.1:1", nil), second: request("10.0.0.1:2", nil), same: true},
+		{name: "user from two addresses", first: request("10.0.0.1:1", user), second: request("10.0.0.2:1", user), same: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.same {
+				assert.Equal(t, idempotencyScope(tt.first), idempotencyScope(tt.second))
+			} else {
+				assert.NotEqual(t, idempotencyScope(tt.first), idempotencyScope(tt.second))
+			}
+		})
+	}
+}
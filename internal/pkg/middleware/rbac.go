@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/pkg/responser"
+	"go.uber.org/fx"
+	"log/slog"
+	"net/http"
+)
+
+type RBACMiddlewareParams struct {
+	fx.In
+
+	Policy *rbac.Policy
+	Logger *slog.Logger
+}
+
+type RBACMiddleware struct {
+	policy *rbac.Policy
+	log    *slog.Logger
+}
+
+func NewRBACMiddleware(p RBACMiddlewareParams) *RBACMiddleware {
+	return &RBACMiddleware{
+		policy: p.Policy,
+		log:    p.Logger,
+	}
+}
+
+// Require wraps a route handler so it is only reachable by roles that are
+// granted the permission. It relies on AuthMiddleware having put the role
+// into the request context. API key scopes and PVZ restrictions are checked
+// here as well, the latter only for routes with a pvzId path variable.
+// Collection routes filter their results by AccessiblePvzIDs instead.
+func (m *RBACMiddleware) Require(permission rbac.Permission, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.Allowed(r.Context(), permission) {
+			responser.SendErr(w, http.StatusForbidden, auth.ErrNoAccess.Error())
+			return
+		}
+
+		if principal := PrincipalFromContext(r.Context()); principal != nil {
+			if pvzIDStr, ok := mux.Vars(r)["pvzId"]; ok {
+				if pvzID, err := uuid.Parse(pvzIDStr); err == nil && !principal.CanAccessPvz(pvzID) {
+					m.log.Warn("pvz denied", "api_key_id", principal.APIKeyID, "pvz_id", pvzID)
+					responser.SendErr(w, http.StatusForbidden, auth.ErrNoAccess.Error())
+					return
+				}
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Allowed reports whether the caller put into ctx by AuthMiddleware is
+// granted the permission by its role and API key scopes.
+func (m *RBACMiddleware) Allowed(ctx context.Context, permission rbac.Permission) bool {
+	role, _ := ctx.Value(RoleInContext).(models.Role)
+	if !m.policy.Allowed(role, permission) {
+		m.log.Warn("access denied", "role", role, "permission", permission)
+		return false
+	}
+
+	if principal := PrincipalFromContext(ctx); principal != nil && !principal.HasScope(string(permission)) {
+		m.log.Warn("scope denied", "api_key_id", principal.APIKeyID, "permission", permission)
+		return false
+	}
+	return true
+}
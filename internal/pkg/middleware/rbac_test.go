@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRBACMiddleware_Require(t *testing.T) {
+	policy, err := rbac.New(rbac.DefaultRoles)
+	require.NoError(t, err)
+
+	m := NewRBACMiddleware(RBACMiddlewareParams{
+		Policy: policy,
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	handler := m.Require(rbac.PvzCreate, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name         string
+		role         any
+		expectedCode int
+	}{
+		{name: "allowed", role: models.RoleModerator, expectedCode: http.StatusCreated},
+		{name: "forbidden", role: models.RoleAuditor, expectedCode: http.StatusForbidden},
+		{name: "no role", role: nil, expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/pvz", nil)
+			if tt.role != nil {
+				req = req.WithContext(context.WithValue(req.Context(), RoleInContext, tt.role))
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestRBACMiddleware_RequireAPIKey(t *testing.T) {
+	policy, err := rbac.New(rbac.DefaultRoles)
+	require.NoError(t, err)
+
+	m := NewRBACMiddleware(RBACMiddlewareParams{
+		Policy: policy,
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	allowedPvz := uuid.New()
+	principal := &models.Principal{
+		Role:     models.RoleEmployee,
+		APIKeyID: uuid.New(),
+		Scopes:   []string{string(rbac.ReceptionClose)},
+		PvzIDs:   []uuid.UUID{allowedPvz},
+	}
+
+	router := mux.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	router.Handle("/pvz/{pvzId}/close_last_reception", m.Require(rbac.ReceptionClose, ok))
+	router.Handle("/pvz/{pvzId}/delete_last_product", m.Require(rbac.ProductDelete, ok))
+
+	tests := []struct {
+		name         string
+		path         string
+		expectedCode int
+	}{
+		{name: "allowed", path: "/pvz/" + allowedPvz.String() + "/close_last_reception", expectedCode: http.StatusOK},
+		{name: "other pvz", path: "/pvz/" + uuid.NewString() + "/close_last_reception", expectedCode: http.StatusForbidden},
+		{name: "out of scope", path: "/pvz/" + allowedPvz.String() + "/delete_last_product", expectedCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			ctx := context.WithValue(req.Context(), RoleInContext, principal.Role)
+			ctx = context.WithValue(ctx, PrincipalInContext, principal)
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req.WithContext(ctx))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+}
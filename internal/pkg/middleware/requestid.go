@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"log/slog"
+	"net/http"
+)
+
+type RequestIDMiddlewareParams struct {
+	fx.In
+
+	Logger *slog.Logger
+}
+
+type RequestIDMiddleware struct {
+	log *slog.Logger
+}
+
+func NewRequestIDMiddleware(p RequestIDMiddlewareParams) *RequestIDMiddleware {
+	return &RequestIDMiddleware{
+		log: p.Logger,
+	}
+}
+
+// RequestIDMiddleware reuses the X-Request-ID of the client or generates one,
+// echoes it in the response and puts a logger carrying it into the request
+// context, handlers, usecases and repositories get it with logctx.From.
+// AuthMiddleware adds the user to that logger. Registered after
+// TracingMiddleware the logger carries the trace id as well.
+func (m *RequestIDMiddleware) RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(logctx.RequestIDHeader)
+		if !logctx.ValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		w.Header().Set(logctx.RequestIDHeader, requestID)
+
+		logger := m.log.With("request_id", requestID, "method", r.Method, "route", routeTemplate(r))
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+			logger = logger.With("trace_id", spanContext.TraceID().String())
+		}
+		ctx := logctx.WithRequestID(r.Context(), requestID)
+		ctx = logctx.With(ctx, logger)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// routeTemplate returns the matched path template, so that log lines of one
+// route can be grouped regardless of the ids in the path.
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/gorilla/mux"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		expectedID string
+	}{
+		{name: "reuses client id", header: "req-1", expectedID: "req-1"},
+		{name: "generates id", header: ""},
+		{name: "rejects control characters", header: "req-1\nforged=1"},
+		{name: "rejects long id", header: strings.Repeat("a", 129)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			m := NewRequestIDMiddleware(RequestIDMiddlewareParams{Logger: slog.New(slog.NewJSONHandler(&logs, nil))})
+
+			var requestID string
+			router := mux.NewRouter()
+			router.Use(m.RequestIDMiddleware)
+			router.HandleFunc("/pvz/{pvzId}", func(w http.ResponseWriter, r *http.Request) {
+				requestID = logctx.RequestID(r.Context())
+				logctx.From(r.Context(), nil).Info("inside handler")
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/pvz/42", nil)
+			req.Header.Set(logctx.RequestIDHeader, tt.header)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if tt.expectedID != "" {
+				assert.Equal(t, tt.expectedID, requestID)
+			} else {
+				assert.NotEqual(t, tt.header, requestID)
+				assert.True(t, logctx.ValidRequestID(requestID))
+			}
+			assert.Equal(t, requestID, rec.Header().Get(logctx.RequestIDHeader))
+			assert.Contains(t, logs.String(), `"request_id":"`+requestID+`"`)
+			assert.Contains(t, logs.String(), `"route":"/pvz/{pvzId}"`)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+	"net/http"
+)
+
+type TracingMiddlewareParams struct {
+	fx.In
+
+	TracerProvider trace.TracerProvider
+}
+
+type TracingMiddleware struct {
+	provider trace.TracerProvider
+}
+
+func NewTracingMiddleware(p TracingMiddlewareParams) *TracingMiddleware {
+	return &TracingMiddleware{
+		provider: p.TracerProvider,
+	}
+}
+
+// TracingMiddleware starts a server span per request, continuing the trace
+// of the caller if it sent a traceparent header. Spans are named after the
+// matched route, so it has to be registered with Router.Use.
+func (m *TracingMiddleware) TracingMiddleware(next http.Handler) http.Handler {
+	withRoute := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace.SpanFromContext(r.Context()).SetAttributes(semconv.HTTPRoute(routeTemplate(r)))
+		next.ServeHTTP(w, r)
+	})
+	return otelhttp.NewHandler(withRoute, "",
+		otelhttp.WithTracerProvider(m.provider),
+		otelhttp.WithPropagators(otel.GetTextMapPropagator()),
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + routeTemplate(r)
+		}),
+	)
+}
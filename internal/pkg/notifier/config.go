@@ -0,0 +1,8 @@
+package notifier
+
+type Config struct {
+	// Driver selects the implementation: "log" writes messages to the
+	// service log, "file" appends them to FilePath.
+	Driver   string `yaml:"driver" env:"NOTIFIER_DRIVER" env-default:"log"`
+	FilePath string `yaml:"filePath" env-default:"notifications.log"`
+}
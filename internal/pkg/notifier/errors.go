@@ -0,0 +1,5 @@
+package notifier
+
+import "errors"
+
+var ErrUnknownDriver = errors.New("unknown notifier driver")
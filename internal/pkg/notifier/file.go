@@ -0,0 +1,50 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileNotifier appends every message as a JSON line to a file.
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+type fileRecord struct {
+	Time    time.Time `json:"time"`
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Notify(_ context.Context, msg Message) error {
+	line, err := json.Marshal(fileRecord{
+		Time:    time.Now(),
+		To:      msg.To,
+		Subject: msg.Subject,
+		Body:    msg.Body,
+	})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
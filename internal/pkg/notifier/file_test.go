@@ -0,0 +1,41 @@
+package notifier
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileNotifier_Notify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	n := NewFileNotifier(path)
+
+	require.NoError(t, n.Notify(context.Background(), Message{To: "a@example.com", Subject: "first", Body: "1"}))
+	require.NoError(t, n.Notify(context.Background(), Message{To: "b@example.com", Subject: "second", Body: "2"}))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var records []fileRecord
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := fileRecord{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+		records = append(records, record)
+	}
+
+	require.Len(t, records, 2)
+	assert.Equal(t, "a@example.com", records[0].To)
+	assert.Equal(t, "second", records[1].Subject)
+}
+
+func TestNew_UnknownDriver(t *testing.T) {
+	_, err := New(Params{Config: Config{Driver: "smtp"}})
+	assert.ErrorIs(t, err, ErrUnknownDriver)
+}
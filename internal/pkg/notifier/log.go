@@ -0,0 +1,21 @@
+package notifier
+
+import (
+	"context"
+	"log/slog"
+)
+
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+// Notify logs the message without its body, which may carry secrets such as
+// password reset tokens. Use the file driver to read the messages.
+func (n *LogNotifier) Notify(ctx context.Context, msg Message) error {
+	n.log.InfoContext(ctx, "notification", "to", msg.To, "subject", msg.Subject, "body_bytes", len(msg.Body))
+	return nil
+}
@@ -0,0 +1,20 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"log/slog"
+	"testing"
+)
+
+func TestLogNotifier_NotifyHidesBody(t *testing.T) {
+	buf := &bytes.Buffer{}
+	n := NewLogNotifier(slog.New(slog.NewTextHandler(buf, nil)))
+
+	require.NoError(t, n.Notify(context.Background(), Message{To: "a@example.com", Subject: "Password reset", Body: "token s3cret"}))
+
+	assert.Contains(t, buf.String(), "a@example.com")
+	assert.NotContains(t, buf.String(), "s3cret")
+}
@@ -0,0 +1,38 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/fx"
+	"log/slog"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users. Production deployments plug in a
+// mail or messaging implementation, the bundled ones are for local testing.
+type Notifier interface {
+	Notify(ctx context.Context, msg Message) error
+}
+
+type Params struct {
+	fx.In
+
+	Config Config
+	Logger *slog.Logger
+}
+
+func New(p Params) (Notifier, error) {
+	switch p.Config.Driver {
+	case "log":
+		return NewLogNotifier(p.Logger), nil
+	case "file":
+		return NewFileNotifier(p.Config.FilePath), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDriver, p.Config.Driver)
+	}
+}
@@ -0,0 +1,7 @@
+package rbac
+
+import "github.com/marrgancovka/pvzService/internal/models"
+
+type Config struct {
+	Roles map[models.Role][]Permission `yaml:"roles"`
+}
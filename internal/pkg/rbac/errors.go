@@ -0,0 +1,8 @@
+package rbac
+
+import "errors"
+
+var (
+	ErrUnknownRole       = errors.New("unknown role in rbac policy")
+	ErrUnknownPermission = errors.New("unknown permission in rbac policy")
+)
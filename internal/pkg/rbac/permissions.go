@@ -0,0 +1,90 @@
+package rbac
+
+import "github.com/marrgancovka/pvzService/internal/models"
+
+type Permission string
+
+const (
+	PermissionAll Permission = "*"
+
+	PvzCreate Permission = "pvz:create"
+	PvzRead   Permission = "pvz:read"
+	PvzUpdate Permission = "pvz:update"
+	PvzImport Permission = "pvz:import"
+
+	ReceptionOpen   Permission = "reception:open"
+	ReceptionClose  Permission = "reception:close"
+	ReceptionRead   Permission = "reception:read"
+	ReceptionExport Permission = "reception:export"
+
+	ProductAdd    Permission = "product:add"
+	ProductDelete Permission = "product:delete"
+
+	StatsRead Permission = "stats:read"
+
+	UserRead   Permission = "user:read"
+	UserManage Permission = "user:manage"
+
+	APIKeyManage Permission = "apikey:manage"
+
+	TokenIntrospect Permission = "token:introspect"
+
+	LogLevelManage Permission = "log:manage"
+)
+
+var knownPermissions = map[Permission]struct{}{
+	PermissionAll:   {},
+	PvzCreate:       {},
+	PvzRead:         {},
+	PvzUpdate:       {},
+	PvzImport:       {},
+	ReceptionOpen:   {},
+	ReceptionClose:  {},
+	ReceptionRead:   {},
+	ReceptionExport: {},
+	ProductAdd:      {},
+	ProductDelete:   {},
+	StatsRead:       {},
+	UserRead:        {},
+	UserManage:      {},
+	APIKeyManage:    {},
+	TokenIntrospect: {},
+	LogLevelManage:  {},
+}
+
+func (p Permission) IsValid() bool {
+	_, ok := knownPermissions[p]
+	return ok
+}
+
+// DefaultRoles is the policy used when the config does not define any roles.
+// It reproduces the access rules that used to be hard-coded in the handlers.
+var DefaultRoles = map[models.Role][]Permission{
+	models.RoleModerator: {
+		PvzCreate, PvzRead, PvzUpdate, PvzImport,
+		ReceptionRead, ReceptionExport,
+		StatsRead,
+		UserRead, UserManage,
+		APIKeyManage,
+		TokenIntrospect,
+		LogLevelManage,
+	},
+	models.RoleEmployee: {
+		PvzRead,
+		ReceptionOpen, ReceptionClose, ReceptionRead, ReceptionExport,
+		ProductAdd, ProductDelete,
+		StatsRead,
+	},
+	models.RoleSupervisor: {
+		PvzCreate, PvzRead, PvzUpdate, PvzImport,
+		ReceptionOpen, ReceptionClose, ReceptionRead, ReceptionExport,
+		ProductAdd, ProductDelete,
+		StatsRead,
+		UserRead,
+	},
+	models.RoleAuditor: {
+		PvzRead,
+		ReceptionRead, ReceptionExport,
+		StatsRead,
+	},
+}
@@ -0,0 +1,74 @@
+package rbac
+
+import (
+	"fmt"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"go.uber.org/fx"
+	"log/slog"
+)
+
+type Params struct {
+	fx.In
+
+	Config Config
+	Logger *slog.Logger
+}
+
+type Policy struct {
+	roles map[models.Role]map[Permission]struct{}
+}
+
+func NewPolicy(p Params) (*Policy, error) {
+	const op = "rbac.NewPolicy"
+	logger := p.Logger.With("op", op)
+
+	roles := p.Config.Roles
+	if len(roles) == 0 {
+		logger.Info("rbac roles are not configured, using default policy")
+		roles = DefaultRoles
+	}
+
+	policy, err := New(roles)
+	if err != nil {
+		logger.Error("invalid rbac policy: " + err.Error())
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func New(roles map[models.Role][]Permission) (*Policy, error) {
+	policy := &Policy{
+		roles: make(map[models.Role]map[Permission]struct{}, len(roles)),
+	}
+
+	for role, permissions := range roles {
+		if !role.IsValid() {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRole, role)
+		}
+
+		set := make(map[Permission]struct{}, len(permissions))
+		for _, permission := range permissions {
+			if !permission.IsValid() {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, permission)
+			}
+			set[permission] = struct{}{}
+		}
+		policy.roles[role] = set
+	}
+
+	return policy, nil
+}
+
+func (p *Policy) Allowed(role models.Role, permission Permission) bool {
+	permissions, ok := p.roles[role]
+	if !ok {
+		return false
+	}
+
+	if _, ok = permissions[PermissionAll]; ok {
+		return true
+	}
+	_, ok = permissions[permission]
+	return ok
+}
@@ -0,0 +1,58 @@
+package rbac
+
+import (
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPolicy_Allowed(t *testing.T) {
+	policy, err := New(DefaultRoles)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		role       models.Role
+		permission Permission
+		want       bool
+	}{
+		{name: "moderator creates pvz", role: models.RoleModerator, permission: PvzCreate, want: true},
+		{name: "moderator can't open reception", role: models.RoleModerator, permission: ReceptionOpen, want: false},
+		{name: "employee adds product", role: models.RoleEmployee, permission: ProductAdd, want: true},
+		{name: "employee can't create pvz", role: models.RoleEmployee, permission: PvzCreate, want: false},
+		{name: "auditor reads stats", role: models.RoleAuditor, permission: StatsRead, want: true},
+		{name: "auditor can't close reception", role: models.RoleAuditor, permission: ReceptionClose, want: false},
+		{name: "supervisor opens reception", role: models.RoleSupervisor, permission: ReceptionOpen, want: true},
+		{name: "supervisor can't manage users", role: models.RoleSupervisor, permission: UserManage, want: false},
+		{name: "unknown role", role: "guest", permission: PvzRead, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, policy.Allowed(tt.role, tt.permission))
+		})
+	}
+}
+
+func TestPolicy_Wildcard(t *testing.T) {
+	policy, err := New(map[models.Role][]Permission{
+		models.RoleModerator: {PermissionAll},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, policy.Allowed(models.RoleModerator, ProductDelete))
+	assert.False(t, policy.Allowed(models.RoleEmployee, ProductDelete))
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, err := New(map[models.Role][]Permission{
+		"guest": {PvzRead},
+	})
+	assert.ErrorIs(t, err, ErrUnknownRole)
+
+	_, err = New(map[models.Role][]Permission{
+		models.RoleEmployee: {"pvz:destroy"},
+	})
+	assert.ErrorIs(t, err, ErrUnknownPermission)
+}
@@ -0,0 +1,74 @@
+package grpcServer
+
+import (
+	"context"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	pvz "github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"strings"
+)
+
+// authInterceptors authenticate the calls of PVZService with the same
+// credentials and permissions as the HTTP routes. Health checks and
+// reflection stay public.
+type authInterceptors struct {
+	auth *middleware.AuthMiddleware
+	rbac *middleware.RBACMiddleware
+	log  *slog.Logger
+}
+
+func (a *authInterceptors) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authInterceptors) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+func (a *authInterceptors) authorize(ctx context.Context, method string) (context.Context, error) {
+	if !strings.HasPrefix(method, "/"+gen.PVZService_ServiceDesc.ServiceName+"/") {
+		return ctx, nil
+	}
+
+	ctx, err := a.auth.Authenticate(ctx, credentialsFromMetadata(ctx))
+	if err != nil {
+		if middleware.IsAuthError(err) {
+			return ctx, status.Error(codes.Unauthenticated, err.Error())
+		}
+		logctx.From(ctx, a.log).Error("authenticate: " + err.Error())
+		return ctx, status.Error(codes.Internal, "internal error")
+	}
+
+	permission, ok := pvz.Permissions[method]
+	if !ok || !a.rbac.Allowed(ctx, permission) {
+		return ctx, status.Error(codes.PermissionDenied, auth.ErrNoAccess.Error())
+	}
+	return ctx, nil
+}
+
+func credentialsFromMetadata(ctx context.Context) middleware.Credentials {
+	md, _ := metadata.FromIncomingContext(ctx)
+	creds := middleware.Credentials{}
+	if values := md.Get(middleware.APIKeyMetadataKey); len(values) > 0 {
+		creds.APIKey = values[0]
+	}
+	if values := md.Get(middleware.AuthorizationMetadataKey); len(values) > 0 {
+		creds.Authorization = values[0]
+	}
+	return creds
+}
@@ -0,0 +1,131 @@
+package grpcServer
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type staticAPIKeys struct {
+	principal *models.Principal
+}
+
+func (s staticAPIKeys) AuthenticateAPIKey(context.Context, string) (*models.Principal, error) {
+	if s.principal == nil {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	return s.principal, nil
+}
+
+type listServer struct {
+	gen.UnimplementedPVZServiceServer
+}
+
+func (listServer) GetPVZList(context.Context, *gen.GetPVZListRequest) (*gen.GetPVZListResponse, error) {
+	return &gen.GetPVZListResponse{}, nil
+}
+
+type acceptingSessions struct{}
+
+func (acceptingSessions) CheckSession(context.Context, *models.TokenPayload) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func newAuthClient(t *testing.T, apiKeys auth.APIKeyAuthenticator) (*grpc.ClientConn, *jwter.JWTer) {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	jwt, err := jwter.New(jwter.Params{
+		Config: jwter.Config{ExpirationTime: time.Hour, KeyJWT: []byte("secret"), Issuer: "pvz-service", Audience: []string{"pvz-service"}},
+		Logger: logger,
+	})
+	require.NoError(t, err)
+	policy, err := rbac.New(rbac.DefaultRoles)
+	require.NoError(t, err)
+
+	ai := &authInterceptors{
+		auth: middleware.NewAuthMiddleware(middleware.AuthMiddlewareParams{JWTer: jwt, APIKeys: apiKeys, Sessions: acceptingSessions{}}),
+		rbac: middleware.NewRBACMiddleware(middleware.RBACMiddlewareParams{Policy: policy, Logger: logger}),
+		log:  logger,
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(ai.unary), grpc.ChainStreamInterceptor(ai.stream))
+	gen.RegisterPVZServiceServer(srv, listServer{})
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn, jwt
+}
+
+func TestAuthInterceptors(t *testing.T) {
+	restricted := &models.Principal{APIKeyID: uuid.New(), Role: models.RoleEmployee, Scopes: []string{string(rbac.StatsRead)}}
+	conn, jwt := newAuthClient(t, staticAPIKeys{principal: restricted})
+	client := gen.NewPVZServiceClient(conn)
+
+	token, err := jwt.GenerateJWT(&models.TokenPayload{ID: uuid.New(), Role: models.RoleEmployee})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		md           metadata.MD
+		expectedCode codes.Code
+	}{
+		{name: "no credentials", md: metadata.MD{}, expectedCode: codes.Unauthenticated},
+		{name: "malformed authorization", md: metadata.Pairs(middleware.AuthorizationMetadataKey, "Basic abc"), expectedCode: codes.Unauthenticated},
+		{name: "invalid token", md: metadata.Pairs(middleware.AuthorizationMetadataKey, "Bearer a.b.c"), expectedCode: codes.Unauthenticated},
+		{name: "valid token", md: metadata.Pairs(middleware.AuthorizationMetadataKey, "Bearer "+token.Token), expectedCode: codes.OK},
+		{name: "api key without scope", md: metadata.Pairs(middleware.APIKeyMetadataKey, "pvz_key"), expectedCode: codes.PermissionDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewOutgoingContext(context.Background(), tt.md)
+			_, err := client.GetPVZList(ctx, &gen.GetPVZListRequest{})
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+		})
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		stream, err := client.WatchPVZEvents(context.Background(), &gen.WatchPVZEventsRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("health check is public", func(t *testing.T) {
+		resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	})
+}
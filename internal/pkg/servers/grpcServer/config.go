@@ -1,9 +1,23 @@
 package grpcServer
 
-import "time"
+import (
+	"github.com/marrgancovka/pvzService/internal/pkg/tlsconfig"
+	"time"
+)
 
 type Config struct {
 	Address     string        `yaml:"address" env-default:"localhost:3000"`
 	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
 	IdleTimeout time.Duration `yaml:"idleTimeout" env-default:"60s"`
+	// Reflection exposes the service descriptors to tools like grpcurl.
+	Reflection bool `yaml:"reflection" env-default:"false"`
+	// ShutdownTimeout bounds how long in-flight calls and streams may take
+	// to finish before the server is stopped forcibly.
+	ShutdownTimeout     time.Duration `yaml:"shutdownTimeout" env-default:"10s"`
+	HealthCheckInterval time.Duration `yaml:"healthCheckInterval" env-default:"5s"`
+	HealthCheckTimeout  time.Duration `yaml:"healthCheckTimeout" env-default:"2s"`
+	// KeepaliveMinTime is the most frequent client keepalive ping tolerated,
+	// it must not exceed the keepalive time of the clients.
+	KeepaliveMinTime time.Duration    `yaml:"keepaliveMinTime" env-default:"10s"`
+	TLS              tlsconfig.Config `yaml:"tls"`
 }
@@ -0,0 +1,65 @@
+package grpcServer
+
+import (
+	"context"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"log/slog"
+	"time"
+)
+
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// healthChecker reports the services as NOT_SERVING while the database
+// doesn't answer pings.
+type healthChecker struct {
+	server   *health.Server
+	db       pinger
+	services []string
+	interval time.Duration
+	timeout  time.Duration
+	log      *slog.Logger
+}
+
+func (h *healthChecker) run(ctx context.Context) {
+	h.check(ctx)
+	if h.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			h.check(ctx)
+		}
+	}
+}
+
+func (h *healthChecker) check(ctx context.Context) {
+	const op = "grpcServer.healthChecker.check"
+	logger := h.log.With("op", op)
+
+	pingCtx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	status := healthpb.HealthCheckResponse_SERVING
+	if err := h.db.Ping(pingCtx); err != nil {
+		if ctx.Err() != nil {
+			// shutting down, the status is already NOT_SERVING
+			return
+		}
+		logger.Error("database ping failed: " + err.Error())
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	for _, service := range h.services {
+		h.server.SetServingStatus(service, status)
+	}
+}
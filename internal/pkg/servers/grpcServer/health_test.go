@@ -0,0 +1,75 @@
+package grpcServer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type fakePinger struct {
+	err error
+}
+
+func (f *fakePinger) Ping(context.Context) error {
+	return f.err
+}
+
+func TestHealthChecker_Check(t *testing.T) {
+	tests := []struct {
+		name           string
+		pingErr        error
+		expectedStatus healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{name: "database up", expectedStatus: healthpb.HealthCheckResponse_SERVING},
+		{name: "database down", pingErr: errors.New("connection refused"), expectedStatus: healthpb.HealthCheckResponse_NOT_SERVING},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := health.NewServer()
+			checker := &healthChecker{
+				server:   server,
+				db:       &fakePinger{err: tt.pingErr},
+				services: []string{"", "pvz.PVZService"},
+				timeout:  time.Second,
+				log:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			checker.check(context.Background())
+
+			for _, service := range checker.services {
+				resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedStatus, resp.GetStatus())
+			}
+		})
+	}
+}
+
+func TestHealthChecker_Recovers(t *testing.T) {
+	server := health.NewServer()
+	db := &fakePinger{err: errors.New("connection refused")}
+	checker := &healthChecker{
+		server:   server,
+		db:       db,
+		services: []string{""},
+		timeout:  time.Second,
+		log:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	checker.check(context.Background())
+	db.err = nil
+	checker.check(context.Background())
+
+	resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
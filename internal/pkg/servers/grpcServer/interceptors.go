@@ -0,0 +1,120 @@
+package grpcServer
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/metrics"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log/slog"
+	"runtime/debug"
+	"time"
+)
+
+// interceptors observe every call: the request id and a logger carrying it
+// are put into the context first, then the call is logged and measured,
+// and a panic of the handler is turned into codes.Internal last.
+type interceptors struct {
+	log     *slog.Logger
+	metrics metrics.Metrics
+}
+
+func (i *interceptors) unary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx = i.withRequestLogger(ctx, info.FullMethod)
+	start := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = i.recovered(ctx, r)
+		}
+		i.observe(ctx, info.FullMethod, err, time.Since(start))
+	}()
+
+	return handler(ctx, req)
+}
+
+func (i *interceptors) stream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx := i.withRequestLogger(ss.Context(), info.FullMethod)
+	start := time.Now()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = i.recovered(ctx, r)
+		}
+		i.observe(ctx, info.FullMethod, err, time.Since(start))
+	}()
+
+	return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+}
+
+// withRequestLogger takes the request id from the incoming metadata or
+// generates one and echoes it back in the response header.
+func (i *interceptors) withRequestLogger(ctx context.Context, method string) context.Context {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(logctx.RequestIDMetadataKey); len(values) > 0 && logctx.ValidRequestID(values[0]) {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(logctx.RequestIDMetadataKey, requestID))
+
+	logger := i.log.With("request_id", requestID, "grpc_method", method)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		logger = logger.With("trace_id", spanContext.TraceID().String())
+	}
+	ctx = logctx.WithRequestID(ctx, requestID)
+	return logctx.With(ctx, logger)
+}
+
+func (i *interceptors) recovered(ctx context.Context, r any) error {
+	logctx.From(ctx, i.log).Error(fmt.Sprintf("panic in grpc handler: %v", r), "stack", string(debug.Stack()))
+	return status.Error(codes.Internal, "internal error")
+}
+
+func (i *interceptors) observe(ctx context.Context, method string, err error, duration time.Duration) {
+	code := status.Code(err)
+	i.metrics.GRPCServerHandled(method, code.String(), duration)
+
+	log := logctx.From(ctx, i.log)
+	attrs := []any{"code", code.String(), "duration", duration}
+	switch codeLevel(code) {
+	case slog.LevelError:
+		log.Error("grpc call failed: "+err.Error(), attrs...)
+	case slog.LevelWarn:
+		log.Warn("grpc call rejected: "+err.Error(), attrs...)
+	default:
+		log.Info("grpc call finished", attrs...)
+	}
+}
+
+// codeLevel logs problems of the server as errors and problems of the
+// request as warnings.
+func codeLevel(code codes.Code) slog.Level {
+	switch code {
+	case codes.OK, codes.Canceled:
+		return slog.LevelInfo
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented, codes.Internal, codes.Unavailable, codes.DataLoss:
+		return slog.LevelError
+	default:
+		return slog.LevelWarn
+	}
+}
+
+// contextStream replaces the context of a stream with the one carrying the
+// request logger.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
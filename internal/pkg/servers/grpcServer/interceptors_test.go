@@ -0,0 +1,122 @@
+package grpcServer
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/metrics/mocks"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type testServer struct {
+	gen.UnimplementedPVZServiceServer
+	requestID string
+}
+
+func (s *testServer) GetPVZList(ctx context.Context, _ *gen.GetPVZListRequest) (*gen.GetPVZListResponse, error) {
+	s.requestID = logctx.RequestID(ctx)
+	logctx.From(ctx, nil).Info("inside handler")
+	return &gen.GetPVZListResponse{}, nil
+}
+
+func (s *testServer) GetStats(context.Context, *gen.GetStatsRequest) (*gen.GetStatsResponse, error) {
+	panic("boom")
+}
+
+func (s *testServer) WatchPVZEvents(_ *gen.WatchPVZEventsRequest, stream grpc.ServerStreamingServer[gen.PVZEvent]) error {
+	s.requestID = logctx.RequestID(stream.Context())
+	return status.Error(codes.InvalidArgument, "bad filter")
+}
+
+func newInterceptedClient(t *testing.T, server *testServer, expectedMethod, expectedCode string) (gen.PVZServiceClient, *bytes.Buffer) {
+	t.Helper()
+
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockMetrics(ctrl)
+	m.EXPECT().GRPCServerHandled(expectedMethod, expectedCode, gomock.Any())
+
+	logs := &bytes.Buffer{}
+	ic := &interceptors{log: slog.New(slog.NewJSONHandler(logs, nil)), metrics: m}
+
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(ic.unary), grpc.ChainStreamInterceptor(ic.stream))
+	gen.RegisterPVZServiceServer(srv, server)
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return gen.NewPVZServiceClient(conn), logs
+}
+
+func TestInterceptors_RequestID(t *testing.T) {
+	t.Run("from metadata", func(t *testing.T) {
+		server := &testServer{}
+		client, logs := newInterceptedClient(t, server, gen.PVZService_GetPVZList_FullMethodName, "OK")
+
+		ctx := metadata.AppendToOutgoingContext(context.Background(), logctx.RequestIDMetadataKey, "req-1")
+		var header metadata.MD
+		_, err := client.GetPVZList(ctx, &gen.GetPVZListRequest{}, grpc.Header(&header))
+
+		require.NoError(t, err)
+		assert.Equal(t, "req-1", server.requestID)
+		assert.Equal(t, []string{"req-1"}, header.Get(logctx.RequestIDMetadataKey))
+		assert.Contains(t, logs.String(), `"msg":"inside handler","request_id":"req-1"`)
+		assert.Contains(t, logs.String(), `"msg":"grpc call finished"`)
+	})
+
+	t.Run("generated", func(t *testing.T) {
+		server := &testServer{}
+		client, _ := newInterceptedClient(t, server, gen.PVZService_GetPVZList_FullMethodName, "OK")
+
+		var header metadata.MD
+		_, err := client.GetPVZList(context.Background(), &gen.GetPVZListRequest{}, grpc.Header(&header))
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, server.requestID)
+		assert.Equal(t, []string{server.requestID}, header.Get(logctx.RequestIDMetadataKey))
+	})
+}
+
+func TestInterceptors_Recovery(t *testing.T) {
+	client, logs := newInterceptedClient(t, &testServer{}, gen.PVZService_GetStats_FullMethodName, "Internal")
+
+	_, err := client.GetStats(context.Background(), &gen.GetStatsRequest{})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Contains(t, logs.String(), "panic in grpc handler: boom")
+	assert.Contains(t, logs.String(), `"level":"ERROR","msg":"grpc call failed`)
+}
+
+func TestInterceptors_Stream(t *testing.T) {
+	server := &testServer{}
+	client, logs := newInterceptedClient(t, server, gen.PVZService_WatchPVZEvents_FullMethodName, "InvalidArgument")
+
+	stream, err := client.WatchPVZEvents(context.Background(), &gen.WatchPVZEventsRequest{})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.NotEmpty(t, server.requestID)
+	assert.Contains(t, logs.String(), `"level":"WARN","msg":"grpc call rejected: rpc error: code = InvalidArgument desc = bad filter"`)
+}
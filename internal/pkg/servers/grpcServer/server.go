@@ -1,34 +1,145 @@
 package grpcServer
 
 import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marrgancovka/pvzService/internal/pkg/metrics"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/pkg/tlsconfig"
 	pvz "github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc"
 	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
 	"log/slog"
 	"net"
+	"time"
 )
 
 type In struct {
 	fx.In
 
-	Config      Config
-	GRPCHandler *pvz.Handler
-	Logger      *slog.Logger
+	Lifecycle      fx.Lifecycle
+	Config         Config
+	GRPCHandler    *pvz.Handler
+	Pool           *pgxpool.Pool
+	Metrics        metrics.Metrics
+	TracerProvider trace.TracerProvider
+	AuthMiddleware *middleware.AuthMiddleware
+	RBAC           *middleware.RBACMiddleware
+	Logger         *slog.Logger
 }
 
-func RunServer(in In) {
-	srv := grpc.NewServer()
-	gen.RegisterPVZServiceServer(srv, in.GRPCHandler)
-	go func() {
-		listener, err := net.Listen("tcp", in.Config.Address)
+func RunServer(in In) error {
+	interceptors := &interceptors{log: in.Logger, metrics: in.Metrics}
+	authInterceptors := &authInterceptors{auth: in.AuthMiddleware, rbac: in.RBAC, log: in.Logger}
+	opts := []grpc.ServerOption{
+		// the stats handler runs before the interceptors, so their logs carry the trace id
+		grpc.StatsHandler(otelgrpc.NewServerHandler(
+			otelgrpc.WithTracerProvider(in.TracerProvider),
+			otelgrpc.WithPropagators(otel.GetTextMapPropagator()),
+		)),
+		// rejected calls are logged and measured as well
+		grpc.ChainUnaryInterceptor(interceptors.unary, authInterceptors.unary),
+		grpc.ChainStreamInterceptor(interceptors.stream, authInterceptors.stream),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             in.Config.KeepaliveMinTime,
+			PermitWithoutStream: true,
+		}),
+	}
+	if in.Config.TLS.Enabled {
+		reloader, err := tlsconfig.NewServer(in.Config.TLS, in.Logger)
 		if err != nil {
-			in.Logger.Error("listen returned err: " + err.Error())
-		}
-		in.Logger.Info("grpc mainServer started", slog.String("addr", listener.Addr().String()))
-		if err = srv.Serve(listener); err != nil {
-			in.Logger.Error("serve returned err: " + err.Error())
+			in.Logger.Error("tls config: " + err.Error())
+			return err
 		}
+		reloader.Start(in.Lifecycle)
+		opts = append(opts, grpc.Creds(credentials.NewTLS(reloader.ServerConfig())))
+	}
+
+	srv := grpc.NewServer(opts...)
+	gen.RegisterPVZServiceServer(srv, in.GRPCHandler)
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(srv, healthServer)
+	if in.Config.Reflection {
+		reflection.Register(srv)
+	}
+
+	checker := &healthChecker{
+		server: healthServer,
+		db:     in.Pool,
+		// the empty name is the overall server health
+		services: []string{"", gen.PVZService_ServiceDesc.ServiceName},
+		interval: in.Config.HealthCheckInterval,
+		timeout:  in.Config.HealthCheckTimeout,
+		log:      in.Logger,
+	}
 
+	ctx, cancel := context.WithCancel(context.Background())
+	checkDone := make(chan struct{})
+	serveDone := make(chan struct{})
+
+	in.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			listener, err := net.Listen("tcp", in.Config.Address)
+			if err != nil {
+				in.Logger.Error("listen returned err: " + err.Error())
+				return fmt.Errorf("grpc listen on %s: %w", in.Config.Address, err)
+			}
+
+			go func() {
+				defer close(checkDone)
+				checker.run(ctx)
+			}()
+			go func() {
+				defer close(serveDone)
+				in.Logger.Info("grpc mainServer started", slog.String("addr", listener.Addr().String()))
+				if err := srv.Serve(listener); err != nil {
+					in.Logger.Error("serve returned err: " + err.Error())
+				}
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			<-checkDone
+			// let load balancers stop routing here before connections drain
+			healthServer.Shutdown()
+			gracefulStop(srv, in.Config.ShutdownTimeout, in.Logger)
+			<-serveDone
+			return nil
+		},
+	})
+	return nil
+}
+
+// gracefulStop waits for in-flight calls up to timeout and then closes the
+// remaining connections, long-lived streams would block it forever otherwise.
+func gracefulStop(srv *grpc.Server, timeout time.Duration, logger *slog.Logger) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
 	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+		logger.Info("grpc mainServer stopped")
+	case <-timer.C:
+		logger.Warn("grpc graceful stop timed out, closing remaining connections")
+		srv.Stop()
+		<-stopped
+	}
 }
@@ -0,0 +1,227 @@
+package grpcServer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marrgancovka/pvzService/internal/pkg/grpcconn"
+	"github.com/marrgancovka/pvzService/internal/pkg/metrics/mocks"
+	"github.com/marrgancovka/pvzService/internal/pkg/tlsconfig"
+	pvz "github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestRunServer_ListenFails(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+
+	lc := fxtest.NewLifecycle(t)
+	err = RunServer(In{
+		Lifecycle:      lc,
+		Config:         Config{Address: busy.Addr().String(), ShutdownTimeout: time.Second},
+		GRPCHandler:    &pvz.Handler{},
+		TracerProvider: noop.NewTracerProvider(),
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	require.NoError(t, err)
+
+	err = lc.Start(context.Background())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "grpc listen on "+busy.Addr().String())
+}
+
+// startHealthServer serves only the health service, its Watch stream stays
+// open until the server goes away.
+func startHealthServer(t *testing.T) (*grpc.Server, healthpb.HealthClient) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return srv, healthpb.NewHealthClient(conn)
+}
+
+func TestGracefulStop(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("no calls in flight", func(t *testing.T) {
+		srv, client := startHealthServer(t)
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		start := time.Now()
+		gracefulStop(srv, time.Minute, logger)
+
+		assert.Less(t, time.Since(start), time.Minute)
+	})
+
+	t.Run("open stream is closed after the timeout", func(t *testing.T) {
+		srv, client := startHealthServer(t)
+		stream, err := client.Watch(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		// the first message means the stream is registered on the server
+		_, err = stream.Recv()
+		require.NoError(t, err)
+
+		const timeout = 50 * time.Millisecond
+		start := time.Now()
+		gracefulStop(srv, timeout, logger)
+
+		assert.GreaterOrEqual(t, time.Since(start), timeout)
+		_, err = stream.Recv()
+		assert.Error(t, err)
+	})
+}
+
+// writeSelfSigned stores a self-signed certificate for localhost and its key
+// in dir, the certificate doubles as the CA bundle of the client.
+func writeSelfSigned(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile
+}
+
+func freeAddress(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
+
+func TestRunServer_TLS(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	certFile, keyFile := writeSelfSigned(t, t.TempDir())
+	address := freeAddress(t)
+
+	ctrl := gomock.NewController(t)
+	metrics := mocks.NewMockMetrics(ctrl)
+	metrics.EXPECT().GRPCServerHandled(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	metrics.EXPECT().GRPCClientHandled(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+	// the pool connects lazily, the health checker only sees failed pings
+	pool, err := pgxpool.New(context.Background(), "postgres://127.0.0.1:1/pvz")
+	require.NoError(t, err)
+	defer pool.Close()
+
+	serverLc := fxtest.NewLifecycle(t)
+	err = RunServer(In{
+		Lifecycle: serverLc,
+		Config: Config{
+			Address:            address,
+			ShutdownTimeout:    time.Second,
+			HealthCheckTimeout: 100 * time.Millisecond,
+			TLS:                tlsconfig.Config{Enabled: true, CertFile: certFile, KeyFile: keyFile},
+		},
+		GRPCHandler:    &pvz.Handler{},
+		Pool:           pool,
+		Metrics:        metrics,
+		TracerProvider: noop.NewTracerProvider(),
+		Logger:         logger,
+	})
+	require.NoError(t, err)
+	serverLc.RequireStart()
+	defer serverLc.RequireStop()
+
+	tests := []struct {
+		name      string
+		tls       tlsconfig.Config
+		expectErr bool
+	}{
+		{name: "trusted server", tls: tlsconfig.Config{Enabled: true, CAFile: certFile, ServerName: "localhost"}},
+		{name: "server not in the system roots", tls: tlsconfig.Config{Enabled: true}, expectErr: true},
+		{name: "server name mismatch", tls: tlsconfig.Config{Enabled: true, CAFile: certFile, ServerName: "example.com"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientLc := fxtest.NewLifecycle(t)
+			conn, err := grpcconn.Provide(grpcconn.In{
+				Lifecycle:      clientLc,
+				Config:         grpcconn.Config{Address: address, Timeout: 5 * time.Second, TLS: tt.tls},
+				Metrics:        metrics,
+				TracerProvider: noop.NewTracerProvider(),
+				Logger:         logger,
+			})
+			require.NoError(t, err)
+			clientLc.RequireStart()
+			defer clientLc.RequireStop()
+
+			_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRunServer_TLSWithoutCertificate(t *testing.T) {
+	err := RunServer(In{
+		Lifecycle:      fxtest.NewLifecycle(t),
+		Config:         Config{TLS: tlsconfig.Config{Enabled: true}},
+		TracerProvider: noop.NewTracerProvider(),
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+
+	assert.ErrorIs(t, err, tlsconfig.ErrNoCertificate)
+}
@@ -2,7 +2,12 @@ package mainServer
 
 import (
 	"github.com/gorilla/mux"
+	"github.com/marrgancovka/pvzService/internal/config/profile"
+	"github.com/marrgancovka/pvzService/internal/pkg/gateway"
+	"github.com/marrgancovka/pvzService/internal/pkg/logger"
 	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
 	authHandler "github.com/marrgancovka/pvzService/internal/services/auth/delivery/http"
 	pvzHandler "github.com/marrgancovka/pvzService/internal/services/pvz/delivery/http"
 	"go.uber.org/fx"
@@ -13,11 +18,19 @@ import (
 type RouterParams struct {
 	fx.In
 
-	Logger            *slog.Logger
-	AuthHandler       *authHandler.Handler
-	PvzHandler        *pvzHandler.Handler
-	AuthMiddleware    *middleware.AuthMiddleware
-	MetricsMiddleware *middleware.MetricsMiddleware
+	Logger                *slog.Logger
+	AuthHandler           *authHandler.Handler
+	PvzHandler            *pvzHandler.Handler
+	AuthMiddleware        *middleware.AuthMiddleware
+	MetricsMiddleware     *middleware.MetricsMiddleware
+	RequestIDMiddleware   *middleware.RequestIDMiddleware
+	TracingMiddleware     *middleware.TracingMiddleware
+	IdempotencyMiddleware *middleware.IdempotencyMiddleware
+	RBACMiddleware        *middleware.RBACMiddleware
+	Gateway               *gateway.Gateway
+	LogLevelHandler       *logger.LevelHandler
+	AuthConfig            auth.Config
+	Environment           profile.Profile
 }
 
 type Router struct {
@@ -25,34 +38,90 @@ type Router struct {
 }
 
 func NewRouter(p RouterParams) *Router {
-	api := mux.NewRouter().PathPrefix("/api").Subrouter()
+	root := mux.NewRouter()
+	root.Use(p.TracingMiddleware.TracingMiddleware, p.RequestIDMiddleware.RequestIDMiddleware)
+	root.HandleFunc("/.well-known/jwks.json", p.AuthHandler.JWKS).Methods(http.MethodGet)
+
+	api := root.PathPrefix("/api").Subrouter()
 	api.Use(middleware.CORSMiddleware, p.MetricsMiddleware.MetricsMiddleware)
 
 	v1 := api.PathPrefix("/v1").Subrouter()
-	v1.HandleFunc("/dummyLogin", p.AuthHandler.DummyLogin).Methods(http.MethodPost, http.MethodOptions)
+	// every mutating route accepts an Idempotency-Key, anonymous callers
+	// are told apart by their address. Routes responding with a token or
+	// an api key are left out, their responses must not be stored.
+	idempotent := p.IdempotencyMiddleware.IdempotencyMiddleware
+	if p.Environment.AllowsDummyLogin() && p.AuthConfig.DummyLogin {
+		v1.HandleFunc("/dummyLogin", p.AuthHandler.DummyLogin).Methods(http.MethodPost, http.MethodOptions)
+		p.Logger.Warn("dummy login is enabled", "environment", p.Environment)
+	}
 	v1.HandleFunc("/register", p.AuthHandler.Register).Methods(http.MethodPost, http.MethodOptions)
 	v1.HandleFunc("/login", p.AuthHandler.Login).Methods(http.MethodPost, http.MethodOptions)
 
+	v1.Handle("/me", p.AuthMiddleware.AuthMiddleware(http.HandlerFunc(p.AuthHandler.Me))).Methods(http.MethodGet, http.MethodOptions)
+	v1.Handle("/token/introspect", p.AuthMiddleware.AuthMiddleware(p.RBACMiddleware.Require(rbac.TokenIntrospect, p.AuthHandler.Introspect))).Methods(http.MethodPost, http.MethodOptions)
+
+	password := v1.PathPrefix("/password").Subrouter()
+	password.Handle("/reset", idempotent(http.HandlerFunc(p.AuthHandler.RequestPasswordReset))).Methods(http.MethodPost, http.MethodOptions)
+	password.Handle("/reset/confirm", idempotent(http.HandlerFunc(p.AuthHandler.ConfirmPasswordReset))).Methods(http.MethodPost, http.MethodOptions)
+	password.Handle("/change", p.AuthMiddleware.AuthMiddleware(idempotent(http.HandlerFunc(p.AuthHandler.ChangePassword)))).Methods(http.MethodPost, http.MethodOptions)
+
+	// the paths below /api/gateway are defined by the google.api.http annotations in proto/pvz.proto
+	gw := api.PathPrefix("/gateway").Subrouter()
+	gw.HandleFunc("/openapi.json", p.Gateway.OpenAPI).Methods(http.MethodGet, http.MethodOptions)
+	gw.PathPrefix("/").Handler(p.AuthMiddleware.AuthMiddleware(p.Gateway))
+
 	pvzGrpc := v1.PathPrefix("/pvzGrpc").Subrouter()
-	pvzGrpc.HandleFunc("", p.PvzHandler.GetPvzList).Methods(http.MethodGet, http.MethodOptions)
+	pvzGrpc.Use(p.AuthMiddleware.AuthMiddleware)
+	pvzGrpc.Handle("", p.RBACMiddleware.Require(rbac.PvzRead, p.PvzHandler.GetPvzList)).Methods(http.MethodGet, http.MethodOptions)
 
 	pvz := v1.PathPrefix("/pvz").Subrouter()
-	pvz.Use(p.AuthMiddleware.AuthMiddleware)
-	pvz.HandleFunc("", p.PvzHandler.CreatePvz).Methods(http.MethodPost, http.MethodOptions)
-	pvz.HandleFunc("", p.PvzHandler.GetPvzs).Methods(http.MethodGet, http.MethodOptions)
-	pvz.HandleFunc("/{pvzId}/close_last_reception", p.PvzHandler.CloseLastReception).Methods(http.MethodPost, http.MethodOptions)
-	pvz.HandleFunc("/{pvzId}/delete_last_product", p.PvzHandler.DeleteLastProduct).Methods(http.MethodPost, http.MethodOptions)
+	pvz.Use(p.AuthMiddleware.AuthMiddleware, p.IdempotencyMiddleware.IdempotencyMiddleware)
+	pvz.Handle("", p.RBACMiddleware.Require(rbac.PvzCreate, p.PvzHandler.CreatePvz)).Methods(http.MethodPost, http.MethodOptions)
+	pvz.Handle("", p.RBACMiddleware.Require(rbac.PvzRead, p.PvzHandler.GetPvzs)).Methods(http.MethodGet, http.MethodOptions)
+	pvz.Handle("/stats", p.RBACMiddleware.Require(rbac.StatsRead, p.PvzHandler.GetStats)).Methods(http.MethodGet, http.MethodOptions)
+	pvz.Handle("/export", p.RBACMiddleware.Require(rbac.ReceptionExport, p.PvzHandler.ExportReceptions)).Methods(http.MethodGet, http.MethodOptions)
+	pvz.Handle("/import", p.RBACMiddleware.Require(rbac.PvzImport, p.PvzHandler.ImportPvz)).Methods(http.MethodPost, http.MethodOptions)
+	pvz.Handle("/{pvzId}", p.RBACMiddleware.Require(rbac.PvzRead, p.PvzHandler.GetPvz)).Methods(http.MethodGet, http.MethodOptions)
+	pvz.Handle("/{pvzId}", p.RBACMiddleware.Require(rbac.PvzUpdate, p.PvzHandler.UpdatePvz)).Methods(http.MethodPut, http.MethodOptions)
+	pvz.Handle("/{pvzId}/last_reception", p.RBACMiddleware.Require(rbac.ReceptionRead, p.PvzHandler.GetLastReception)).Methods(http.MethodGet, http.MethodOptions)
+	pvz.Handle("/{pvzId}/events", p.RBACMiddleware.Require(rbac.ReceptionRead, p.PvzHandler.StreamEvents)).Methods(http.MethodGet, http.MethodOptions)
+	pvz.Handle("/{pvzId}/close_last_reception", p.RBACMiddleware.Require(rbac.ReceptionClose, p.PvzHandler.CloseLastReception)).Methods(http.MethodPost, http.MethodOptions)
+	pvz.Handle("/{pvzId}/delete_last_product", p.RBACMiddleware.Require(rbac.ProductDelete, p.PvzHandler.DeleteLastProduct)).Methods(http.MethodPost, http.MethodOptions)
 
 	reception := v1.PathPrefix("/receptions").Subrouter()
-	reception.Use(p.AuthMiddleware.AuthMiddleware)
-	reception.HandleFunc("", p.PvzHandler.CreateReception).Methods(http.MethodPost, http.MethodOptions)
+	reception.Use(p.AuthMiddleware.AuthMiddleware, p.IdempotencyMiddleware.IdempotencyMiddleware)
+	reception.Handle("", p.RBACMiddleware.Require(rbac.ReceptionOpen, p.PvzHandler.CreateReception)).Methods(http.MethodPost, http.MethodOptions)
 
 	product := v1.PathPrefix("/products").Subrouter()
-	product.Use(p.AuthMiddleware.AuthMiddleware)
-	product.HandleFunc("", p.PvzHandler.AddProduct).Methods(http.MethodPost, http.MethodOptions)
+	product.Use(p.AuthMiddleware.AuthMiddleware, p.IdempotencyMiddleware.IdempotencyMiddleware)
+	product.Handle("", p.RBACMiddleware.Require(rbac.ProductAdd, p.PvzHandler.AddProduct)).Methods(http.MethodPost, http.MethodOptions)
+
+	users := v1.PathPrefix("/users").Subrouter()
+	users.Use(p.AuthMiddleware.AuthMiddleware, p.IdempotencyMiddleware.IdempotencyMiddleware)
+	users.Handle("", p.RBACMiddleware.Require(rbac.UserRead, p.AuthHandler.ListUsers)).Methods(http.MethodGet, http.MethodOptions)
+	users.Handle("", p.RBACMiddleware.Require(rbac.UserManage, p.AuthHandler.CreateUser)).Methods(http.MethodPost, http.MethodOptions)
+	users.Handle("/{userId}", p.RBACMiddleware.Require(rbac.UserRead, p.AuthHandler.GetUser)).Methods(http.MethodGet, http.MethodOptions)
+	users.Handle("/{userId}", p.RBACMiddleware.Require(rbac.UserManage, p.AuthHandler.DeleteUser)).Methods(http.MethodDelete, http.MethodOptions)
+	users.Handle("/{userId}/role", p.RBACMiddleware.Require(rbac.UserManage, p.AuthHandler.ChangeRole)).Methods(http.MethodPatch, http.MethodOptions)
+	users.Handle("/{userId}/disable", p.RBACMiddleware.Require(rbac.UserManage, p.AuthHandler.DisableUser)).Methods(http.MethodPost, http.MethodOptions)
+	users.Handle("/{userId}/enable", p.RBACMiddleware.Require(rbac.UserManage, p.AuthHandler.EnableUser)).Methods(http.MethodPost, http.MethodOptions)
+	users.Handle("/{userId}/unlock", p.RBACMiddleware.Require(rbac.UserManage, p.AuthHandler.UnlockUser)).Methods(http.MethodPost, http.MethodOptions)
+	users.Handle("/{userId}/pvz", p.RBACMiddleware.Require(rbac.UserRead, p.AuthHandler.GetUserPvz)).Methods(http.MethodGet, http.MethodOptions)
+	users.Handle("/{userId}/pvz", p.RBACMiddleware.Require(rbac.UserManage, p.AuthHandler.SetUserPvz)).Methods(http.MethodPut, http.MethodOptions)
+
+	apiKeys := v1.PathPrefix("/api-keys").Subrouter()
+	apiKeys.Use(p.AuthMiddleware.AuthMiddleware)
+	apiKeys.Handle("", p.RBACMiddleware.Require(rbac.APIKeyManage, p.AuthHandler.IssueAPIKey)).Methods(http.MethodPost, http.MethodOptions)
+	apiKeys.Handle("", p.RBACMiddleware.Require(rbac.APIKeyManage, p.AuthHandler.ListAPIKeys)).Methods(http.MethodGet, http.MethodOptions)
+	apiKeys.Handle("/{keyId}", idempotent(p.RBACMiddleware.Require(rbac.APIKeyManage, p.AuthHandler.RevokeAPIKey))).Methods(http.MethodDelete, http.MethodOptions)
+
+	admin := v1.PathPrefix("/admin").Subrouter()
+	admin.Use(p.AuthMiddleware.AuthMiddleware, idempotent)
+	admin.Handle("/log-level", p.RBACMiddleware.Require(rbac.LogLevelManage, p.LogLevelHandler.GetLevel)).Methods(http.MethodGet, http.MethodOptions)
+	admin.Handle("/log-level", p.RBACMiddleware.Require(rbac.LogLevelManage, p.LogLevelHandler.SetLevel)).Methods(http.MethodPut, http.MethodOptions)
 
 	router := &Router{
-		handler: api,
+		handler: root,
 	}
 
 	p.Logger.Info("registered router")
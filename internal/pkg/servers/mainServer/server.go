@@ -2,6 +2,7 @@ package mainServer
 
 import (
 	"errors"
+	"github.com/marrgancovka/pvzService/internal/pkg/tlsconfig"
 	"go.uber.org/fx"
 	"log/slog"
 	"net/http"
@@ -10,22 +11,41 @@ import (
 type Params struct {
 	fx.In
 
-	Config Config
-	Router *Router
-	Logger *slog.Logger
+	Lifecycle fx.Lifecycle
+	Config    Config
+	Router    *Router
+	Logger    *slog.Logger
 }
 
-func RunServer(params Params) {
+func RunServer(params Params) error {
 	srv := &http.Server{
 		Addr:              params.Config.Address,
 		Handler:           params.Router.handler,
 		ReadHeaderTimeout: params.Config.ReadHeaderTimeout,
 		IdleTimeout:       params.Config.IdleTimeout,
 	}
+	if params.Config.TLS.Enabled {
+		reloader, err := tlsconfig.NewServer(params.Config.TLS, params.Logger)
+		if err != nil {
+			params.Logger.Error("tls config: " + err.Error())
+			return err
+		}
+		reloader.Start(params.Lifecycle)
+		srv.TLSConfig = reloader.ServerConfig()
+	}
+
 	go func() {
-		params.Logger.Info("starting mainServer", "address", srv.Addr)
-		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		params.Logger.Info("starting mainServer", "address", srv.Addr, "tls", srv.TLSConfig != nil)
+		var err error
+		if srv.TLSConfig != nil {
+			// the certificate comes from TLSConfig.GetCertificate
+			err = srv.ListenAndServeTLS("", "")
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			panic(err)
 		}
 	}()
+	return nil
 }
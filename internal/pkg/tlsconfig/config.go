@@ -0,0 +1,22 @@
+package tlsconfig
+
+import "time"
+
+// Config describes the certificates of one side of a connection. The same
+// struct configures servers and clients, CAFile is the bundle used to verify
+// the other side.
+type Config struct {
+	Enabled  bool   `yaml:"enabled" env-default:"false"`
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	CAFile   string `yaml:"caFile"`
+	// ClientAuth makes a server require client certificates signed by
+	// CAFile (mutual TLS).
+	ClientAuth bool `yaml:"clientAuth" env-default:"false"`
+	// ServerName overrides the name a client verifies the server
+	// certificate against, by default the host of the address is used.
+	ServerName string `yaml:"serverName"`
+	// ReloadInterval is how often the files are checked for changes, zero
+	// disables reloading.
+	ReloadInterval time.Duration `yaml:"reloadInterval" env-default:"30s"`
+}
@@ -0,0 +1,12 @@
+package tlsconfig
+
+import "errors"
+
+var (
+	ErrNoCertificate = errors.New("tls certificate and key files are required")
+	ErrNoCA          = errors.New("tls client auth requires a CA file")
+	ErrInvalidCA     = errors.New("tls CA file contains no certificates")
+	ErrNoPeerCert    = errors.New("tls peer sent no certificate")
+	ErrNoServerName  = errors.New("tls server name is unknown, set serverName")
+	ErrNotLoaded     = errors.New("tls certificate is not loaded")
+)
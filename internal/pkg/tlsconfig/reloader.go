@@ -0,0 +1,173 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"go.uber.org/fx"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// Reloader keeps the certificate and the CA bundle of a Config in memory
+// and picks up new versions of the files without a restart, so that
+// rotated certificates are used by the next handshake.
+type Reloader struct {
+	cfg  Config
+	log  *slog.Logger
+	cert atomic.Pointer[tls.Certificate]
+	pool atomic.Pointer[x509.CertPool]
+
+	mu     sync.Mutex
+	stamps map[string]fileStamp
+}
+
+// NewReloader loads the files once, so that a broken configuration fails
+// the startup instead of the first handshake.
+func NewReloader(cfg Config, logger *slog.Logger) (*Reloader, error) {
+	r := &Reloader{
+		cfg:    cfg,
+		log:    logger,
+		stamps: make(map[string]fileStamp),
+	}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the files again. On error the previously loaded
+// certificates stay in use.
+func (r *Reloader) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var cert *tls.Certificate
+	if r.cfg.CertFile != "" || r.cfg.KeyFile != "" {
+		loaded, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+		if err != nil {
+			return fmt.Errorf("load tls key pair: %w", err)
+		}
+		cert = &loaded
+	}
+
+	var pool *x509.CertPool
+	if r.cfg.CAFile != "" {
+		pem, err := os.ReadFile(r.cfg.CAFile)
+		if err != nil {
+			return fmt.Errorf("read tls CA file: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return ErrInvalidCA
+		}
+	}
+
+	r.cert.Store(cert)
+	r.pool.Store(pool)
+	for _, name := range r.files() {
+		r.stamps[name] = stat(name)
+	}
+	return nil
+}
+
+// Start checks the files every ReloadInterval while the app is running.
+func (r *Reloader) Start(lc fx.Lifecycle) {
+	if r.cfg.ReloadInterval <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				r.watch(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			<-done
+			return nil
+		},
+	})
+}
+
+func (r *Reloader) watch(ctx context.Context) {
+	const op = "tlsconfig.Reloader.watch"
+	logger := r.log.With("op", op)
+
+	ticker := time.NewTicker(r.cfg.ReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !r.changed() {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				logger.Error("failed to reload tls certificates: " + err.Error())
+				continue
+			}
+			logger.Info("reloaded tls certificates", "cert", r.cfg.CertFile, "ca", r.cfg.CAFile)
+		}
+	}
+}
+
+// changed reports whether any file was modified since the last load. A
+// failed reload is retried only after the next modification.
+func (r *Reloader) changed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed := false
+	for _, name := range r.files() {
+		current := stat(name)
+		if current != r.stamps[name] {
+			r.stamps[name] = current
+			changed = true
+		}
+	}
+	return changed
+}
+
+func (r *Reloader) files() []string {
+	var files []string
+	for _, name := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.CAFile} {
+		if name != "" {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+func stat(name string) fileStamp {
+	info, err := os.Stat(name)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+func (r *Reloader) certificate() (*tls.Certificate, error) {
+	cert := r.cert.Load()
+	if cert == nil {
+		return nil, ErrNotLoaded
+	}
+	return cert, nil
+}
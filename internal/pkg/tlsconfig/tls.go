@@ -0,0 +1,94 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log/slog"
+)
+
+// NewServer validates the server side configuration and loads its files.
+func NewServer(cfg Config, logger *slog.Logger) (*Reloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, ErrNoCertificate
+	}
+	if cfg.ClientAuth && cfg.CAFile == "" {
+		return nil, ErrNoCA
+	}
+	return NewReloader(cfg, logger)
+}
+
+// NewClient validates the client side configuration and loads its files.
+// Without CAFile the system roots verify the server, a certificate is only
+// needed when the server requires client auth.
+func NewClient(cfg Config, logger *slog.Logger) (*Reloader, error) {
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, ErrNoCertificate
+	}
+	return NewReloader(cfg, logger)
+}
+
+// ServerConfig returns a config that always hands out the latest loaded
+// certificate and, with ClientAuth, verifies clients against the latest
+// CA bundle.
+func (r *Reloader) ServerConfig() *tls.Config {
+	base := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return r.certificate()
+		},
+	}
+	if !r.cfg.ClientAuth {
+		return base
+	}
+
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cfg := base.Clone()
+		cfg.GetConfigForClient = nil
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		cfg.ClientCAs = r.pool.Load()
+		return cfg, nil
+	}
+	return base
+}
+
+// ClientConfig returns a config that presents the latest loaded certificate
+// and verifies the server against the latest CA bundle.
+func (r *Reloader) ClientConfig() *tls.Config {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: r.cfg.ServerName,
+	}
+	if r.cfg.CertFile != "" {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return r.certificate()
+		}
+	}
+	if r.cfg.CAFile != "" {
+		// RootCAs would be fixed for the lifetime of the config, so the
+		// chain is verified here against the current pool instead.
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyConnection = r.verifyServer
+	}
+	return cfg
+}
+
+func (r *Reloader) verifyServer(cs tls.ConnectionState) error {
+	if len(cs.PeerCertificates) == 0 {
+		return ErrNoPeerCert
+	}
+	// an empty DNSName would make Verify skip the hostname check
+	if cs.ServerName == "" {
+		return ErrNoServerName
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       cs.ServerName,
+		Roots:         r.pool.Load(),
+		Intermediates: intermediates,
+	})
+	return err
+}
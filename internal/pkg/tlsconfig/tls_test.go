@@ -0,0 +1,267 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx/fxtest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var testLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestNewServer_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	cfg, _ := writeFiles(t, dir, newTestCA(t), "server")
+	badCA := filepath.Join(dir, "bad-ca.crt")
+	require.NoError(t, os.WriteFile(badCA, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name        string
+		modify      func(cfg *Config)
+		expectedErr error
+	}{
+		{name: "no certificate", modify: func(cfg *Config) { cfg.CertFile = "" }, expectedErr: ErrNoCertificate},
+		{name: "client auth without CA", modify: func(cfg *Config) { cfg.ClientAuth = true; cfg.CAFile = "" }, expectedErr: ErrNoCA},
+		{name: "invalid CA", modify: func(cfg *Config) { cfg.CAFile = badCA }, expectedErr: ErrInvalidCA},
+		{name: "missing file", modify: func(cfg *Config) { cfg.KeyFile = filepath.Join(dir, "missing.key") }, expectedErr: os.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := cfg
+			tt.modify(&c)
+
+			_, err := NewServer(c, testLogger)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func startHTTPServer(t *testing.T, reloader *Reloader) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+		TLSConfig:         reloader.ServerConfig(),
+		ReadHeaderTimeout: time.Second,
+	}
+	go func() { _ = srv.ServeTLS(listener, "", "") }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	return "https://" + listener.Addr().String()
+}
+
+func newHTTPClient(t *testing.T, cfg Config) *http.Client {
+	t.Helper()
+
+	reloader, err := NewClient(cfg, testLogger)
+	require.NoError(t, err)
+	return &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig:   reloader.ClientConfig(),
+			DisableKeepAlives: true,
+		},
+	}
+}
+
+func servedSerial(t *testing.T, client *http.Client, url string) *big.Int {
+	t.Helper()
+
+	resp, err := client.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return resp.TLS.PeerCertificates[0].SerialNumber
+}
+
+func TestHTTPServer_TLS(t *testing.T) {
+	ca := newTestCA(t)
+	serverCfg, serverSerial := writeFiles(t, t.TempDir(), ca, "server")
+
+	reloader, err := NewServer(serverCfg, testLogger)
+	require.NoError(t, err)
+	url := startHTTPServer(t, reloader)
+
+	t.Run("trusted", func(t *testing.T) {
+		client := newHTTPClient(t, Config{CAFile: serverCfg.CAFile, ServerName: "localhost"})
+		assert.Equal(t, serverSerial, servedSerial(t, client, url))
+	})
+
+	t.Run("unknown CA", func(t *testing.T) {
+		otherCfg, _ := writeFiles(t, t.TempDir(), newTestCA(t), "other")
+		client := newHTTPClient(t, Config{CAFile: otherCfg.CAFile, ServerName: "localhost"})
+
+		_, err := client.Get(url)
+		assert.Error(t, err)
+	})
+
+	t.Run("wrong server name", func(t *testing.T) {
+		client := newHTTPClient(t, Config{CAFile: serverCfg.CAFile, ServerName: "pvz.example.com"})
+
+		_, err := client.Get(url)
+		assert.Error(t, err)
+	})
+}
+
+func TestReloader_HotReload(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	serverCfg, oldSerial := writeFiles(t, dir, ca, "server")
+	serverCfg.ReloadInterval = 10 * time.Millisecond
+
+	reloader, err := NewServer(serverCfg, testLogger)
+	require.NoError(t, err)
+	lc := fxtest.NewLifecycle(t)
+	reloader.Start(lc)
+	lc.RequireStart()
+	t.Cleanup(lc.RequireStop)
+
+	url := startHTTPServer(t, reloader)
+	client := newHTTPClient(t, Config{CAFile: serverCfg.CAFile, ServerName: "localhost"})
+	require.Equal(t, oldSerial, servedSerial(t, client, url))
+
+	certPEM, keyPEM, newSerial := ca.issue(t, "server")
+	require.NoError(t, os.WriteFile(serverCfg.KeyFile, keyPEM, 0o600))
+	require.NoError(t, os.WriteFile(serverCfg.CertFile, certPEM, 0o600))
+	later := time.Now().Add(time.Second)
+	require.NoError(t, os.Chtimes(serverCfg.CertFile, later, later))
+
+	assert.Eventually(t, func() bool {
+		return servedSerial(t, client, url).Cmp(newSerial) == 0
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestReloader_KeepsCertificateOnBrokenFiles(t *testing.T) {
+	serverCfg, _ := writeFiles(t, t.TempDir(), newTestCA(t), "server")
+
+	reloader, err := NewServer(serverCfg, testLogger)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(serverCfg.CertFile, []byte("garbage"), 0o600))
+
+	assert.Error(t, reloader.Reload())
+	cert, err := reloader.certificate()
+	assert.NoError(t, err)
+	assert.NotNil(t, cert)
+}
+
+func startGRPCServer(t *testing.T, reloader *Reloader) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(reloader.ServerConfig())))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	return listener.Addr().String()
+}
+
+func checkHealth(t *testing.T, addr string, cfg Config) error {
+	t.Helper()
+
+	reloader, err := NewClient(cfg, testLogger)
+	require.NoError(t, err)
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(reloader.ClientConfig())))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func TestGRPC_MutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	serverCfg, _ := writeFiles(t, t.TempDir(), ca, "server")
+	serverCfg.ClientAuth = true
+
+	reloader, err := NewServer(serverCfg, testLogger)
+	require.NoError(t, err)
+	addr := startGRPCServer(t, reloader)
+
+	t.Run("client certificate", func(t *testing.T) {
+		clientCfg, _ := writeFiles(t, t.TempDir(), ca, "client")
+		clientCfg.ServerName = "localhost"
+
+		assert.NoError(t, checkHealth(t, addr, clientCfg))
+	})
+
+	t.Run("no client certificate", func(t *testing.T) {
+		assert.Error(t, checkHealth(t, addr, Config{CAFile: serverCfg.CAFile, ServerName: "localhost"}))
+	})
+
+	t.Run("client certificate of another CA", func(t *testing.T) {
+		clientCfg, _ := writeFiles(t, t.TempDir(), newTestCA(t), "client")
+		clientCfg.CAFile = serverCfg.CAFile
+		clientCfg.ServerName = "localhost"
+
+		assert.Error(t, checkHealth(t, addr, clientCfg))
+	})
+}
+
+func TestReloader_VerifyServer(t *testing.T) {
+	ca := newTestCA(t)
+	serverCfg, _ := writeFiles(t, t.TempDir(), ca, "server")
+	reloader, err := NewClient(Config{CAFile: serverCfg.CAFile}, testLogger)
+	require.NoError(t, err)
+
+	certPEM, err := os.ReadFile(serverCfg.CertFile)
+	require.NoError(t, err)
+	block, _ := pem.Decode(certPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		state       tls.ConnectionState
+		expectedErr error
+	}{
+		{name: "valid", state: tls.ConnectionState{ServerName: "localhost", PeerCertificates: []*x509.Certificate{cert}}},
+		{name: "no certificate", state: tls.ConnectionState{ServerName: "localhost"}, expectedErr: ErrNoPeerCert},
+		{name: "no server name", state: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}, expectedErr: ErrNoServerName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := reloader.verifyServer(tt.state)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("other host", func(t *testing.T) {
+		err := reloader.verifyServer(tls.ConnectionState{ServerName: "example.com", PeerCertificates: []*x509.Certificate{cert}})
+		assert.Error(t, err)
+	})
+}
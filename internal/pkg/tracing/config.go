@@ -0,0 +1,16 @@
+package tracing
+
+type Config struct {
+	Enabled     bool   `yaml:"enabled" env:"TRACING_ENABLED" env-default:"false"`
+	ServiceName string `yaml:"serviceName" env-default:"pvz-service"`
+	// Exporter selects where spans go: "otlp" sends them to a collector at
+	// Endpoint over gRPC, "stdout" prints them and "file" appends them to
+	// FilePath, the last two work without any collector.
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"stdout"`
+	Endpoint string `yaml:"endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" env-default:"localhost:4317"`
+	Insecure bool   `yaml:"insecure" env-default:"true"`
+	FilePath string `yaml:"filePath" env-default:"traces.json"`
+	// SampleRatio is the share of new traces that are recorded, calls
+	// continuing a trace follow the decision of the caller.
+	SampleRatio float64 `yaml:"sampleRatio" env-default:"1"`
+}
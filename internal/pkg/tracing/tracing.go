@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx"
+	"io"
+	"log/slog"
+	"os"
+)
+
+const instrumentationName = "github.com/marrgancovka/pvzService"
+
+type Params struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Config    Config
+	Logger    *slog.Logger
+}
+
+// New sets up the global tracer provider and the W3C trace context
+// propagation used by the HTTP, gRPC and database instrumentation. With
+// tracing disabled spans are not recorded, but incoming trace context is
+// still passed on.
+func New(p Params) (trace.TracerProvider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !p.Config.Enabled {
+		provider := noop.NewTracerProvider()
+		otel.SetTracerProvider(provider)
+		return provider, nil
+	}
+
+	exporter, closer, err := newExporter(p.Config)
+	if err != nil {
+		p.Logger.Error("tracing exporter: " + err.Error())
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(p.Config.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(p.Config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			// shutdown flushes the spans still buffered by the batcher
+			err := provider.Shutdown(ctx)
+			if closer != nil {
+				_ = closer.Close()
+			}
+			return err
+		},
+	})
+
+	p.Logger.Info("tracing enabled", "exporter", p.Config.Exporter, "service", p.Config.ServiceName)
+	return provider, nil
+}
+
+func newExporter(cfg Config) (sdktrace.SpanExporter, io.Closer, error) {
+	switch cfg.Exporter {
+	case "otlp":
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.Endpoint)}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		// the client connects lazily, an unreachable collector doesn't fail the startup
+		exporter, err := otlptracegrpc.New(context.Background(), opts...)
+		return exporter, nil, err
+	case "stdout":
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		return exporter, nil, err
+	case "file":
+		file, err := os.OpenFile(cfg.FilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			_ = file.Close()
+			return nil, nil, err
+		}
+		return exporter, file, nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownExporter, cfg.Exporter)
+	}
+}
+
+// Start starts a span of the application code, e.g. a usecase method,
+// named after the op of the method.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End records the error the method returns, if any, and ends span. It is
+// deferred with the named error result: defer tracing.End(span, &err). A
+// canceled context means the caller went away, it isn't recorded.
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil && !errors.Is(*err, context.Canceled) {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
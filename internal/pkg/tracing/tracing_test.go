@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/fx/fxtest"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	t.Run("disabled", func(t *testing.T) {
+		lc := fxtest.NewLifecycle(t)
+		provider, err := New(Params{Lifecycle: lc, Config: Config{Enabled: false}, Logger: logger})
+		require.NoError(t, err)
+
+		_, span := provider.Tracer("test").Start(context.Background(), "span")
+		assert.False(t, span.IsRecording())
+	})
+
+	t.Run("unknown exporter", func(t *testing.T) {
+		lc := fxtest.NewLifecycle(t)
+		_, err := New(Params{Lifecycle: lc, Config: Config{Enabled: true, Exporter: "zipkin"}, Logger: logger})
+		assert.ErrorIs(t, err, ErrUnknownExporter)
+	})
+
+	t.Run("file exporter", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "traces.json")
+		lc := fxtest.NewLifecycle(t)
+		_, err := New(Params{Lifecycle: lc, Config: Config{
+			Enabled:     true,
+			ServiceName: "pvz-test",
+			Exporter:    "file",
+			FilePath:    path,
+			SampleRatio: 1,
+		}, Logger: logger})
+		require.NoError(t, err)
+		lc.RequireStart()
+
+		_, span := Start(context.Background(), "pvz.Usecase.GetPvz")
+		span.End()
+		lc.RequireStop()
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"Name":"pvz.Usecase.GetPvz"`)
+		assert.Contains(t, string(data), `"Value":"pvz-test"`)
+	})
+}
+
+func TestEnd(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus codes.Code
+		expectedEvents int
+	}{
+		{name: "success", expectedStatus: codes.Unset},
+		{name: "error", err: errors.New("pvz not found"), expectedStatus: codes.Error, expectedEvents: 1},
+		{name: "canceled", err: fmt.Errorf("watch: %w", context.Canceled), expectedStatus: codes.Unset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			func() (err error) {
+				_, span := provider.Tracer("test").Start(context.Background(), "pvz.Usecase.GetPvz")
+				defer End(span, &err)
+				return tt.err
+			}()
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.expectedStatus, spans[0].Status().Code)
+			assert.Len(t, spans[0].Events(), tt.expectedEvents)
+		})
+	}
+}
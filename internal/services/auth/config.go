@@ -0,0 +1,40 @@
+package auth
+
+import (
+	"github.com/marrgancovka/pvzService/pkg/validator"
+	"time"
+)
+
+type Config struct {
+	AllowRegistration bool `yaml:"allowRegistration" env-default:"true"`
+	// DummyLogin exposes /dummyLogin, which issues a token for any role
+	// without credentials. It is refused at startup in production.
+	DummyLogin bool          `yaml:"dummyLogin" env:"AUTH_DUMMY_LOGIN" env-default:"false"`
+	Lockout    LockoutConfig `yaml:"lockout"`
+	// ResetTokenTTL is how long a password reset token stays usable.
+	ResetTokenTTL time.Duration `yaml:"resetTokenTtl" env-default:"30m"`
+	// ResetURL, when set, is sent to the user with the token appended as
+	// the "token" query parameter.
+	ResetURL string `yaml:"resetUrl"`
+	// APIKeyTouchInterval is how stale the last usage of an API key may get
+	// before it is updated, so that not every request writes to the table.
+	APIKeyTouchInterval time.Duration `yaml:"apiKeyTouchInterval" env-default:"5m"`
+	// Password is applied whenever a password is set: on registration,
+	// user creation, password change and reset.
+	Password validator.PasswordPolicy `yaml:"password"`
+}
+
+// LockoutConfig limits failed logins. After MaxFailures failures for an email
+// from one client ip (MaxAccountFailures for an email from any client,
+// MaxIPFailures for a client ip) within Window attempts are refused for
+// Duration. Every failure is answered after a delay that doubles from
+// BaseDelay up to MaxDelay.
+type LockoutConfig struct {
+	MaxFailures        int           `yaml:"maxFailures" env-default:"5"`
+	MaxAccountFailures int           `yaml:"maxAccountFailures" env-default:"20"`
+	MaxIPFailures      int           `yaml:"maxIpFailures" env-default:"50"`
+	Window             time.Duration `yaml:"window" env-default:"15m"`
+	Duration           time.Duration `yaml:"duration" env-default:"15m"`
+	BaseDelay          time.Duration `yaml:"baseDelay" env-default:"250ms"`
+	MaxDelay           time.Duration `yaml:"maxDelay" env-default:"4s"`
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"errors"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/pkg/reader"
+	"github.com/marrgancovka/pvzService/pkg/responser"
+	"net/http"
+)
+
+func (h *Handler) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.IssueAPIKey"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	keyData := &models.APIKeyRequest{}
+	if err := reader.ReadRequestData(r, keyData); err != nil {
+		logger.Error("error read request data: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	issuedKey, err := h.usecase.IssueAPIKey(r.Context(), actorID(r), keyData)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrBadRequest):
+			responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+			return
+		case errors.Is(err, auth.ErrIncorrectRole):
+			responser.SendErr(w, http.StatusBadRequest, auth.ErrIncorrectRole.Error())
+			return
+		case errors.Is(err, auth.ErrInvalidScope):
+			responser.SendErr(w, http.StatusBadRequest, auth.ErrInvalidScope.Error())
+			return
+		default:
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+			return
+		}
+	}
+
+	logger.Info("success issue api key", "key_id", issuedKey.ID)
+	responser.SendOk(w, http.StatusCreated, issuedKey)
+}
+
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.ListAPIKeys"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	keys, err := h.usecase.ListAPIKeys(r.Context())
+	if err != nil {
+		responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+		return
+	}
+
+	logger.Info("success list api keys", "count", len(keys))
+	responser.SendOk(w, http.StatusOK, keys)
+}
+
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.RevokeAPIKey"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	keyId, err := reader.ReadVarsUUID(r, "keyId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	key, err := h.usecase.RevokeAPIKey(r.Context(), keyId)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrAPIKeyNotFound):
+			responser.SendErr(w, http.StatusNotFound, auth.ErrAPIKeyNotFound.Error())
+			return
+		default:
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+			return
+		}
+	}
+
+	logger.Info("success revoke api key", "key_id", key.ID)
+	responser.SendOk(w, http.StatusOK, key)
+}
@@ -3,12 +3,17 @@ package http
 import (
 	"errors"
 	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
 	"github.com/marrgancovka/pvzService/internal/services/auth"
 	"github.com/marrgancovka/pvzService/pkg/reader"
 	"github.com/marrgancovka/pvzService/pkg/responser"
+	"github.com/marrgancovka/pvzService/pkg/validator"
 	"go.uber.org/fx"
 	"log/slog"
+	"math"
+	"net"
 	"net/http"
+	"strconv"
 )
 
 type Params struct {
@@ -16,23 +21,26 @@ type Params struct {
 
 	Logger  *slog.Logger
 	Usecase auth.Usecase
+	JWTer   auth.JWTer
 }
 
 type Handler struct {
 	logger  *slog.Logger
 	usecase auth.Usecase
+	jwt     auth.JWTer
 }
 
 func NewHandler(params Params) *Handler {
 	return &Handler{
 		logger:  params.Logger,
 		usecase: params.Usecase,
+		jwt:     params.JWTer,
 	}
 }
 
 func (h *Handler) DummyLogin(w http.ResponseWriter, r *http.Request) {
 	const op = "auth.Handler.DummyLogin"
-	logger := h.logger.With("op", op)
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
 
 	var role *models.DummyLogin
 
@@ -54,19 +62,22 @@ func (h *Handler) DummyLogin(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, auth.ErrIncorrectRole):
 			responser.SendErr(w, http.StatusBadRequest, auth.ErrIncorrectRole.Error())
 			return
+		case errors.Is(err, auth.ErrDummyLoginDisabled):
+			responser.SendErr(w, http.StatusNotFound, auth.ErrDummyLoginDisabled.Error())
+			return
 		default:
 			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
 			return
 		}
 	}
 
-	logger.Info("success dummy login: " + token)
+	logger.Info("success dummy login", "role", role.Role)
 	responser.SendOk(w, http.StatusOK, token)
 }
 
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	const op = "auth.Handler.Login"
-	logger := h.logger.With("op", op)
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
 
 	userData := &models.Users{}
 	if err := reader.ReadRequestData(r, userData); err != nil {
@@ -81,25 +92,47 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := h.usecase.Login(r.Context(), userData)
+	token, err := h.usecase.Login(r.Context(), userData, clientIP(r))
 	if err != nil {
+		lockoutErr := &auth.LockoutError{}
 		switch {
+		case errors.As(err, &lockoutErr):
+			sendLockoutErr(w, lockoutErr)
+			return
 		case errors.Is(err, auth.ErrUserNotFound) || errors.Is(err, auth.ErrIncorrectPasswordOrEmail):
 			responser.SendErr(w, http.StatusBadRequest, auth.ErrIncorrectPasswordOrEmail.Error())
 			return
+		case errors.Is(err, auth.ErrUserDisabled):
+			responser.SendErr(w, http.StatusForbidden, auth.ErrUserDisabled.Error())
+			return
 		default:
 			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
 			return
 		}
 	}
 
-	logger.Info("success login user: " + token)
+	logger.Info("success login user")
 	responser.SendOk(w, http.StatusOK, token)
 }
 
+func sendLockoutErr(w http.ResponseWriter, lockoutErr *auth.LockoutError) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(lockoutErr.RetryAfter.Seconds()))))
+	responser.SendErr(w, http.StatusTooManyRequests, auth.ErrTooManyLoginAttempts.Error())
+}
+
+// clientIP is the address login attempts are counted by. Forwarding headers
+// are ignored since any client can set them.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	const op = "auth.Handler.Register"
-	logger := h.logger.With("op", op)
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
 
 	userData := &models.Users{}
 	if err := reader.ReadRequestData(r, userData); err != nil {
@@ -108,14 +141,11 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if userData.Email == "" || userData.Password == "" || userData.Role == "" {
-		logger.Error("email, password or role is empty")
-		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
-		return
-	}
-
 	token, err := h.usecase.Register(r.Context(), userData)
 	if err != nil {
+		if sendValidationErr(w, err) {
+			return
+		}
 		switch {
 		case errors.Is(err, auth.ErrUserAlreadyExists):
 			responser.SendErr(w, http.StatusBadRequest, auth.ErrUserAlreadyExists.Error())
@@ -123,12 +153,34 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		case errors.Is(err, auth.ErrIncorrectRole):
 			responser.SendErr(w, http.StatusBadRequest, auth.ErrIncorrectRole.Error())
 			return
+		case errors.Is(err, auth.ErrRegistrationDisabled):
+			responser.SendErr(w, http.StatusForbidden, auth.ErrRegistrationDisabled.Error())
+			return
+		case errors.Is(err, auth.ErrSelfRegistrationRole):
+			responser.SendErr(w, http.StatusForbidden, auth.ErrSelfRegistrationRole.Error())
+			return
 		default:
 			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
 			return
 		}
 	}
 
-	logger.Info("success register user: " + token)
+	logger.Info("success register user")
 	responser.SendOk(w, http.StatusCreated, token)
 }
+
+// sendValidationErr answers 400 listing the rejected fields and reports
+// whether err carried field errors at all.
+func sendValidationErr(w http.ResponseWriter, err error) bool {
+	var fieldErrs validator.Errors
+	if !errors.As(err, &fieldErrs) {
+		return false
+	}
+	responser.SendFieldErrs(w, http.StatusBadRequest, auth.ErrValidation.Error(), fieldErrs)
+	return true
+}
+
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	responser.SendOk(w, http.StatusOK, h.jwt.JWKS())
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/marrgancovka/pvzService/internal/models"
 	"github.com/marrgancovka/pvzService/internal/services/auth"
 	authMocks "github.com/marrgancovka/pvzService/internal/services/auth/mocks"
+	"github.com/marrgancovka/pvzService/pkg/validator"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"log/slog"
@@ -13,6 +14,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestHandler_DummyLogin(t *testing.T) {
@@ -119,12 +121,13 @@ func TestHandler_Login(t *testing.T) {
 	type mockBehavior func(m *authMocks.MockUsecase, user *models.Users)
 
 	testTable := []struct {
-		name         string
-		inputBody    string
-		inputUser    *models.Users
-		mockBehavior mockBehavior
-		expectedCode int
-		expectedBody string
+		name               string
+		inputBody          string
+		inputUser          *models.Users
+		mockBehavior       mockBehavior
+		expectedCode       int
+		expectedBody       string
+		expectedRetryAfter string
 	}{
 		{
 			name:      "success login",
@@ -134,7 +137,7 @@ func TestHandler_Login(t *testing.T) {
 				Password: "password123",
 			},
 			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {
-				m.EXPECT().Login(gomock.Any(), user).Return("valid_token", nil)
+				m.EXPECT().Login(gomock.Any(), user, gomock.Any()).Return("valid_token", nil)
 			},
 			expectedCode: http.StatusOK,
 			expectedBody: `"valid_token"`,
@@ -166,7 +169,7 @@ func TestHandler_Login(t *testing.T) {
 				Password: "wrong",
 			},
 			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {
-				m.EXPECT().Login(gomock.Any(), user).Return("", auth.ErrUserNotFound)
+				m.EXPECT().Login(gomock.Any(), user, gomock.Any()).Return("", auth.ErrUserNotFound)
 			},
 			expectedCode: http.StatusBadRequest,
 			expectedBody: `{"msg": "` + auth.ErrIncorrectPasswordOrEmail.Error() + `"}`,
@@ -179,11 +182,26 @@ func TestHandler_Login(t *testing.T) {
 				Password: "wrong",
 			},
 			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {
-				m.EXPECT().Login(gomock.Any(), user).Return("", auth.ErrIncorrectPasswordOrEmail)
+				m.EXPECT().Login(gomock.Any(), user, gomock.Any()).Return("", auth.ErrIncorrectPasswordOrEmail)
 			},
 			expectedCode: http.StatusBadRequest,
 			expectedBody: `{"msg": "` + auth.ErrIncorrectPasswordOrEmail.Error() + `"}`,
 		},
+		{
+			name:      "locked out",
+			inputBody: `{"email":"test@example.com", "password":"wrong"}`,
+			inputUser: &models.Users{
+				Email:    "test@example.com",
+				Password: "wrong",
+			},
+			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {
+				m.EXPECT().Login(gomock.Any(), user, "192.0.2.1").
+					Return("", &auth.LockoutError{RetryAfter: 90*time.Second + time.Millisecond})
+			},
+			expectedCode:       http.StatusTooManyRequests,
+			expectedBody:       `{"msg": "` + auth.ErrTooManyLoginAttempts.Error() + `"}`,
+			expectedRetryAfter: "91",
+		},
 	}
 
 	for _, tt := range testTable {
@@ -213,6 +231,7 @@ func TestHandler_Login(t *testing.T) {
 
 			assert.Equal(t, tt.expectedCode, rec.Code)
 			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+			assert.Equal(t, tt.expectedRetryAfter, rec.Header().Get("Retry-After"))
 		})
 	}
 }
@@ -229,7 +248,7 @@ func TestHandler_Register(t *testing.T) {
 		expectedBody string
 	}{
 		{
-			name: "successful registration moderator",
+			name: "self registration as moderator",
 			inputBody: `{
 				"email": "test@example.com",
 				"password": "12345",
@@ -240,12 +259,44 @@ func TestHandler_Register(t *testing.T) {
 				Password: "12345",
 				Role:     "moderator",
 			},
+			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {
+				m.EXPECT().Register(gomock.Any(), user).Return("", auth.ErrSelfRegistrationRole)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedBody: `{"msg": "` + auth.ErrSelfRegistrationRole.Error() + `"}`,
+		},
+		{
+			name: "successful registration without role",
+			inputBody: `{
+				"email": "test@example.com",
+				"password": "12345"
+			}`,
+			inputUser: &models.Users{
+				Email:    "test@example.com",
+				Password: "12345",
+			},
 			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {
 				m.EXPECT().Register(gomock.Any(), user).Return("testToken", nil)
 			},
 			expectedCode: http.StatusCreated,
 			expectedBody: `"testToken"`,
 		},
+		{
+			name: "registration disabled",
+			inputBody: `{
+				"email": "test@example.com",
+				"password": "12345"
+			}`,
+			inputUser: &models.Users{
+				Email:    "test@example.com",
+				Password: "12345",
+			},
+			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {
+				m.EXPECT().Register(gomock.Any(), user).Return("", auth.ErrRegistrationDisabled)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedBody: `{"msg": "` + auth.ErrRegistrationDisabled.Error() + `"}`,
+		},
 		{
 			name: "successful registration employee",
 			inputBody: `{
@@ -265,12 +316,20 @@ func TestHandler_Register(t *testing.T) {
 			expectedBody: `"testToken"`,
 		},
 		{
-			name:         "empty body",
-			inputBody:    `{}`,
-			inputUser:    nil,
-			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {},
+			name:      "empty body",
+			inputBody: `{}`,
+			inputUser: &models.Users{},
+			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {
+				m.EXPECT().Register(gomock.Any(), user).Return("", validator.Errors{
+					{Field: "email", Msg: "is required"},
+					{Field: "password", Msg: "is required"},
+				})
+			},
 			expectedCode: http.StatusBadRequest,
-			expectedBody: `{"msg": "` + auth.ErrBadRequest.Error() + `"}`,
+			expectedBody: `{"msg": "` + auth.ErrValidation.Error() + `", "errors": [
+				{"field": "email", "msg": "is required"},
+				{"field": "password", "msg": "is required"}
+			]}`,
 		},
 		{
 			name:         "invalid JSON",
@@ -285,10 +344,18 @@ func TestHandler_Register(t *testing.T) {
 			inputBody: `{
 				"email": "test@example.com"
 			}`,
-			inputUser:    nil,
-			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {},
+			inputUser: &models.Users{
+				Email: "test@example.com",
+			},
+			mockBehavior: func(m *authMocks.MockUsecase, user *models.Users) {
+				m.EXPECT().Register(gomock.Any(), user).Return("", validator.Errors{
+					{Field: "password", Msg: "is required"},
+				})
+			},
 			expectedCode: http.StatusBadRequest,
-			expectedBody: `{"msg": "` + auth.ErrBadRequest.Error() + `"}`,
+			expectedBody: `{"msg": "` + auth.ErrValidation.Error() + `", "errors": [
+				{"field": "password", "msg": "is required"}
+			]}`,
 		},
 		{
 			name: "user already exists",
@@ -358,3 +425,70 @@ func TestHandler_Register(t *testing.T) {
 		})
 	}
 }
+
+func TestHandler_Introspect(t *testing.T) {
+	type mockBehavior func(m *authMocks.MockUsecase)
+
+	testTable := []struct {
+		name         string
+		inputBody    string
+		mockBehavior mockBehavior
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:      "active token",
+			inputBody: "token=valid&token_type_hint=access_token",
+			mockBehavior: func(m *authMocks.MockUsecase) {
+				m.EXPECT().Introspect(gomock.Any(), "valid").Return(&models.TokenIntrospection{
+					Active:    true,
+					Role:      models.RoleEmployee,
+					TokenType: "Bearer",
+					Exp:       1700000000,
+				}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"active": true, "role": "employee", "token_type": "Bearer", "exp": 1700000000}`,
+		},
+		{
+			name:      "inactive token",
+			inputBody: "token=expired",
+			mockBehavior: func(m *authMocks.MockUsecase) {
+				m.EXPECT().Introspect(gomock.Any(), "expired").Return(&models.TokenIntrospection{}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedBody: `{"active": false}`,
+		},
+		{
+			name:         "missing token",
+			inputBody:    "token_type_hint=access_token",
+			mockBehavior: func(m *authMocks.MockUsecase) {},
+			expectedCode: http.StatusBadRequest,
+			expectedBody: `{"msg": "` + auth.ErrBadRequest.Error() + `"}`,
+		},
+	}
+
+	for _, tt := range testTable {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockUsecase := authMocks.NewMockUsecase(ctrl)
+			tt.mockBehavior(mockUsecase)
+
+			handler := &Handler{
+				usecase: mockUsecase,
+				logger:  slog.New(slog.NewTextHandler(os.Stdout, nil)),
+			}
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/token/introspect", bytes.NewBufferString(tt.inputBody))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			handler.Introspect(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
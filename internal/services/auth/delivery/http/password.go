@@ -0,0 +1,118 @@
+package http
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/pkg/reader"
+	"github.com/marrgancovka/pvzService/pkg/responser"
+	"net/http"
+)
+
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.ChangePassword"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	userID := actorID(r)
+	if userID == uuid.Nil {
+		logger.Error("no user in context")
+		responser.SendErr(w, http.StatusForbidden, auth.ErrNoAccess.Error())
+		return
+	}
+
+	req := &models.ChangePasswordRequest{}
+	if err := reader.ReadRequestData(r, req); err != nil {
+		logger.Error("error read request data: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	if req.OldPassword == "" {
+		logger.Error("old password is empty")
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	if err := h.usecase.ChangePassword(r.Context(), userID, req, clientIP(r)); err != nil {
+		if sendValidationErr(w, err) {
+			return
+		}
+		lockoutErr := &auth.LockoutError{}
+		switch {
+		case errors.As(err, &lockoutErr):
+			sendLockoutErr(w, lockoutErr)
+		case errors.Is(err, auth.ErrIncorrectOldPassword):
+			responser.SendErr(w, http.StatusBadRequest, auth.ErrIncorrectOldPassword.Error())
+		case errors.Is(err, auth.ErrSamePassword):
+			responser.SendErr(w, http.StatusBadRequest, auth.ErrSamePassword.Error())
+		default:
+			sendUserErr(w, err)
+		}
+		return
+	}
+
+	logger.Info("success change password", "user_id", userID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RequestPasswordReset answers 202 whether or not the email is registered.
+func (h *Handler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.RequestPasswordReset"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	req := &models.PasswordResetRequest{}
+	if err := reader.ReadRequestData(r, req); err != nil {
+		logger.Error("error read request data: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	if req.Email == "" {
+		logger.Error("email is empty")
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	if err := h.usecase.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		logger.Error("failed to request password reset: " + err.Error())
+		responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) ConfirmPasswordReset(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.ConfirmPasswordReset"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	req := &models.PasswordResetConfirm{}
+	if err := reader.ReadRequestData(r, req); err != nil {
+		logger.Error("error read request data: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	if req.Token == "" {
+		logger.Error("token is empty")
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	if err := h.usecase.ResetPassword(r.Context(), req); err != nil {
+		if sendValidationErr(w, err) {
+			return
+		}
+		if errors.Is(err, auth.ErrInvalidResetToken) {
+			responser.SendErr(w, http.StatusBadRequest, auth.ErrInvalidResetToken.Error())
+			return
+		}
+		responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+		return
+	}
+
+	logger.Info("success reset password")
+	w.WriteHeader(http.StatusNoContent)
+}
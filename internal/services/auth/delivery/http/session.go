@@ -0,0 +1,59 @@
+package http
+
+import (
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/pkg/responser"
+	"net/http"
+)
+
+func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.Me"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	principal := middleware.PrincipalFromContext(r.Context())
+	if principal == nil {
+		logger.Error("no principal in context")
+		responser.SendErr(w, http.StatusForbidden, auth.ErrNoAccess.Error())
+		return
+	}
+
+	me, err := h.usecase.Me(r.Context(), principal, middleware.TokenFromContext(r.Context()))
+	if err != nil {
+		sendUserErr(w, err)
+		return
+	}
+
+	responser.SendOk(w, http.StatusOK, me)
+}
+
+// Introspect implements RFC 7662, the token is passed as the "token" form
+// parameter. Tokens the service would reject are reported with
+// "active": false and status 200.
+func (h *Handler) Introspect(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.Introspect"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	if err := r.ParseForm(); err != nil {
+		logger.Error("error parse form: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		logger.Error("token is empty")
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	result, err := h.usecase.Introspect(r.Context(), token)
+	if err != nil {
+		responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	responser.SendOk(w, http.StatusOK, result)
+}
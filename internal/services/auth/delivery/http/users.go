@@ -0,0 +1,270 @@
+package http
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/pkg/reader"
+	"github.com/marrgancovka/pvzService/pkg/responser"
+	"net/http"
+	"strconv"
+)
+
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.ListUsers"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	queryParams := r.URL.Query()
+
+	limit := uint64(10)
+	if limitStr := queryParams.Get("limit"); limitStr != "" {
+		limitInt, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil || limitInt == 0 {
+			logger.Error("error conv limit")
+			responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+			return
+		}
+		limit = limitInt
+	}
+
+	page := uint64(1)
+	if pageStr := queryParams.Get("page"); pageStr != "" {
+		pageInt, err := strconv.ParseUint(pageStr, 10, 64)
+		if err != nil || pageInt == 0 {
+			logger.Error("error conv page")
+			responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+			return
+		}
+		page = pageInt
+	}
+
+	users, err := h.usecase.ListUsers(r.Context(), limit, page)
+	if err != nil {
+		responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+		return
+	}
+
+	logger.Info("success list users", "count", len(users))
+	responser.SendOk(w, http.StatusOK, users)
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.GetUser"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	userId, err := reader.ReadVarsUUID(r, "userId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	user, err := h.usecase.GetUser(r.Context(), userId)
+	if err != nil {
+		sendUserErr(w, err)
+		return
+	}
+
+	logger.Info("success get user", "user_id", user.ID)
+	responser.SendOk(w, http.StatusOK, user)
+}
+
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.CreateUser"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	userData := &models.Users{}
+	if err := reader.ReadRequestData(r, userData); err != nil {
+		logger.Error("error read request data: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	if userData.Role == "" {
+		logger.Error("role is empty")
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	user, err := h.usecase.CreateUser(r.Context(), userData)
+	if err != nil {
+		sendUserErr(w, err)
+		return
+	}
+
+	logger.Info("success create user", "user_id", user.ID)
+	responser.SendOk(w, http.StatusCreated, user)
+}
+
+func (h *Handler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.ChangeRole"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	userId, err := reader.ReadVarsUUID(r, "userId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	roleUpdate := &models.RoleUpdate{}
+	if err = reader.ReadRequestData(r, roleUpdate); err != nil {
+		logger.Error("error read request data: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	user, err := h.usecase.ChangeRole(r.Context(), actorID(r), userId, roleUpdate.Role)
+	if err != nil {
+		sendUserErr(w, err)
+		return
+	}
+
+	logger.Info("success change role", "user_id", user.ID, "role", user.Role)
+	responser.SendOk(w, http.StatusOK, user)
+}
+
+func (h *Handler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, true)
+}
+
+func (h *Handler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setUserDisabled(w, r, false)
+}
+
+func (h *Handler) setUserDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	const op = "auth.Handler.setUserDisabled"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	userId, err := reader.ReadVarsUUID(r, "userId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	user, err := h.usecase.SetUserDisabled(r.Context(), actorID(r), userId, disabled)
+	if err != nil {
+		sendUserErr(w, err)
+		return
+	}
+
+	logger.Info("success update user", "user_id", user.ID, "disabled", user.Disabled)
+	responser.SendOk(w, http.StatusOK, user)
+}
+
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.DeleteUser"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	userId, err := reader.ReadVarsUUID(r, "userId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	if err = h.usecase.DeleteUser(r.Context(), actorID(r), userId); err != nil {
+		sendUserErr(w, err)
+		return
+	}
+
+	logger.Info("success delete user", "user_id", userId)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.UnlockUser"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	userId, err := reader.ReadVarsUUID(r, "userId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	if err = h.usecase.UnlockUser(r.Context(), userId); err != nil {
+		sendUserErr(w, err)
+		return
+	}
+
+	logger.Info("success unlock user", "user_id", userId)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func actorID(r *http.Request) uuid.UUID {
+	id, _ := r.Context().Value(middleware.UserIDInContext).(uuid.UUID)
+	return id
+}
+
+func sendUserErr(w http.ResponseWriter, err error) {
+	if sendValidationErr(w, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, auth.ErrUserNotFound):
+		responser.SendErr(w, http.StatusNotFound, auth.ErrUserNotFound.Error())
+	case errors.Is(err, auth.ErrUserAlreadyExists):
+		responser.SendErr(w, http.StatusConflict, auth.ErrUserAlreadyExists.Error())
+	case errors.Is(err, auth.ErrIncorrectRole):
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrIncorrectRole.Error())
+	case errors.Is(err, auth.ErrPvzNotFound):
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrPvzNotFound.Error())
+	case errors.Is(err, auth.ErrCannotModifySelf):
+		responser.SendErr(w, http.StatusConflict, auth.ErrCannotModifySelf.Error())
+	default:
+		responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+	}
+}
+
+func (h *Handler) GetUserPvz(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.GetUserPvz"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	userId, err := reader.ReadVarsUUID(r, "userId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	pvzIDs, err := h.usecase.GetUserPvz(r.Context(), userId)
+	if err != nil {
+		sendUserErr(w, err)
+		return
+	}
+
+	responser.SendOk(w, http.StatusOK, &models.UserPvzRequest{PvzIDs: pvzIDs})
+}
+
+func (h *Handler) SetUserPvz(w http.ResponseWriter, r *http.Request) {
+	const op = "auth.Handler.SetUserPvz"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	userId, err := reader.ReadVarsUUID(r, "userId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	req := &models.UserPvzRequest{}
+	if err = reader.ReadRequestData(r, req); err != nil {
+		logger.Error("error read request data: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, auth.ErrBadRequest.Error())
+		return
+	}
+
+	pvzIDs, err := h.usecase.SetUserPvz(r.Context(), userId, req.PvzIDs)
+	if err != nil {
+		sendUserErr(w, err)
+		return
+	}
+
+	logger.Info("success set user pvz", "user_id", userId)
+	responser.SendOk(w, http.StatusOK, &models.UserPvzRequest{PvzIDs: pvzIDs})
+}
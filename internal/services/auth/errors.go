@@ -1,6 +1,9 @@
 package auth
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrUserNotFound             = errors.New("user not found")
@@ -8,4 +11,33 @@ var (
 	ErrIncorrectPasswordOrEmail = errors.New("incorrect password or email")
 	ErrIncorrectRole            = errors.New("incorrect access level")
 	ErrBadRequest               = errors.New("bad request")
+	ErrNoAccess                 = errors.New("no access")
+	ErrRegistrationDisabled     = errors.New("registration is disabled")
+	ErrSelfRegistrationRole     = errors.New("only employees can register themselves")
+	ErrUserDisabled             = errors.New("user is disabled")
+	ErrCannotModifySelf         = errors.New("cannot change own account")
+	ErrAPIKeyNotFound           = errors.New("api key not found")
+	ErrInvalidAPIKey            = errors.New("invalid api key")
+	ErrInvalidScope             = errors.New("invalid api key scope")
+	ErrDummyLoginDisabled       = errors.New("dummy login is disabled")
+	ErrTooManyLoginAttempts     = errors.New("too many login attempts")
+	ErrIncorrectOldPassword     = errors.New("incorrect old password")
+	ErrSamePassword             = errors.New("new password must differ from the old one")
+	ErrInvalidResetToken        = errors.New("invalid or expired reset token")
+	ErrTokenRevoked             = errors.New("token revoked")
+	ErrValidation               = errors.New("validation failed")
+	ErrPvzNotFound              = errors.New("pvz not found")
 )
+
+// LockoutError is returned by Login while the account or client is locked.
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return ErrTooManyLoginAttempts.Error()
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
@@ -2,21 +2,75 @@ package auth
 
 import (
 	"context"
+	"github.com/google/uuid"
 	"github.com/marrgancovka/pvzService/internal/models"
+	"time"
 )
 
 type Usecase interface {
 	DummyLogin(ctx context.Context, role *models.DummyLogin) (string, error)
-	Login(ctx context.Context, userData *models.Users) (string, error)
+	Login(ctx context.Context, userData *models.Users, clientIP string) (string, error)
 	Register(ctx context.Context, userData *models.Users) (string, error)
+
+	ListUsers(ctx context.Context, limit, page uint64) ([]*models.UserInfo, error)
+	GetUser(ctx context.Context, id uuid.UUID) (*models.UserInfo, error)
+	CreateUser(ctx context.Context, userData *models.Users) (*models.UserInfo, error)
+	ChangeRole(ctx context.Context, actorID, id uuid.UUID, role models.Role) (*models.UserInfo, error)
+	SetUserDisabled(ctx context.Context, actorID, id uuid.UUID, disabled bool) (*models.UserInfo, error)
+	DeleteUser(ctx context.Context, actorID, id uuid.UUID) error
+	UnlockUser(ctx context.Context, id uuid.UUID) error
+	GetUserPvz(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error)
+	SetUserPvz(ctx context.Context, id uuid.UUID, pvzIDs []uuid.UUID) ([]uuid.UUID, error)
+
+	Me(ctx context.Context, principal *models.Principal, payload *models.TokenPayload) (*models.Me, error)
+	Introspect(ctx context.Context, token string) (*models.TokenIntrospection, error)
+
+	ChangePassword(ctx context.Context, userID uuid.UUID, req *models.ChangePasswordRequest, clientIP string) error
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, req *models.PasswordResetConfirm) error
+
+	IssueAPIKey(ctx context.Context, actorID uuid.UUID, req *models.APIKeyRequest) (*models.IssuedAPIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+}
+
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*models.Principal, error)
+}
+
+type SessionChecker interface {
+	CheckSession(ctx context.Context, payload *models.TokenPayload) ([]uuid.UUID, error)
 }
 
 type Repository interface {
 	GetUserByEmail(ctx context.Context, email string) (*models.Users, error)
 	CreateUser(ctx context.Context, user *models.Users) (*models.Users, error)
+	GetUserByID(ctx context.Context, id uuid.UUID) (*models.Users, error)
+	ListUsers(ctx context.Context, limit, page uint64) ([]*models.Users, error)
+	UpdateUserRole(ctx context.Context, id uuid.UUID, role models.Role) (*models.Users, error)
+	SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) (*models.Users, error)
+	DeleteUser(ctx context.Context, id uuid.UUID) error
+	GetSessionUser(ctx context.Context, id uuid.UUID) (*models.Users, []uuid.UUID, error)
+	GetUserPvzIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error)
+	SetUserPvzIDs(ctx context.Context, userID uuid.UUID, pvzIDs []uuid.UUID) error
+
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID) error
+
+	GetLoginLockout(ctx context.Context, keys []models.LoginAttemptKey) (*time.Time, error)
+	RegisterLoginFailure(ctx context.Context, key models.LoginAttemptKey, window time.Duration, maxFailures int, lockout time.Duration) (*models.LoginAttempts, error)
+	ResetLoginFailures(ctx context.Context, key models.LoginAttemptKey) error
+
+	UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error
+	CreatePasswordResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error
+	ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (*models.Users, error)
 }
 
 type JWTer interface {
 	GenerateJWT(payload *models.TokenPayload) (*models.Token, error)
 	ValidateJWT(tokenString string) (*models.TokenPayload, error)
+	JWKS() *models.JWKSet
 }
@@ -12,7 +12,9 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
+	uuid "github.com/google/uuid"
 	models "github.com/marrgancovka/pvzService/internal/models"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,6 +43,64 @@ func (m *MockUsecase) EXPECT() *MockUsecaseMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockUsecase) ChangePassword(ctx context.Context, userID uuid.UUID, req *models.ChangePasswordRequest, clientIP string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, req, clientIP)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUsecaseMockRecorder) ChangePassword(ctx, userID, req, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUsecase)(nil).ChangePassword), ctx, userID, req, clientIP)
+}
+
+// ChangeRole mocks base method.
+func (m *MockUsecase) ChangeRole(ctx context.Context, actorID, id uuid.UUID, role models.Role) (*models.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeRole", ctx, actorID, id, role)
+	ret0, _ := ret[0].(*models.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeRole indicates an expected call of ChangeRole.
+func (mr *MockUsecaseMockRecorder) ChangeRole(ctx, actorID, id, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeRole", reflect.TypeOf((*MockUsecase)(nil).ChangeRole), ctx, actorID, id, role)
+}
+
+// CreateUser mocks base method.
+func (m *MockUsecase) CreateUser(ctx context.Context, userData *models.Users) (*models.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, userData)
+	ret0, _ := ret[0].(*models.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockUsecaseMockRecorder) CreateUser(ctx, userData any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockUsecase)(nil).CreateUser), ctx, userData)
+}
+
+// DeleteUser mocks base method.
+func (m *MockUsecase) DeleteUser(ctx context.Context, actorID, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, actorID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUsecaseMockRecorder) DeleteUser(ctx, actorID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUsecase)(nil).DeleteUser), ctx, actorID, id)
+}
+
 // DummyLogin mocks base method.
 func (m *MockUsecase) DummyLogin(ctx context.Context, role *models.DummyLogin) (string, error) {
 	m.ctrl.T.Helper()
@@ -56,19 +116,124 @@ func (mr *MockUsecaseMockRecorder) DummyLogin(ctx, role any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DummyLogin", reflect.TypeOf((*MockUsecase)(nil).DummyLogin), ctx, role)
 }
 
+// GetUser mocks base method.
+func (m *MockUsecase) GetUser(ctx context.Context, id uuid.UUID) (*models.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", ctx, id)
+	ret0, _ := ret[0].(*models.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUsecaseMockRecorder) GetUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUsecase)(nil).GetUser), ctx, id)
+}
+
+// GetUserPvz mocks base method.
+func (m *MockUsecase) GetUserPvz(ctx context.Context, id uuid.UUID) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPvz", ctx, id)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPvz indicates an expected call of GetUserPvz.
+func (mr *MockUsecaseMockRecorder) GetUserPvz(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPvz", reflect.TypeOf((*MockUsecase)(nil).GetUserPvz), ctx, id)
+}
+
+// Introspect mocks base method.
+func (m *MockUsecase) Introspect(ctx context.Context, token string) (*models.TokenIntrospection, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Introspect", ctx, token)
+	ret0, _ := ret[0].(*models.TokenIntrospection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Introspect indicates an expected call of Introspect.
+func (mr *MockUsecaseMockRecorder) Introspect(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Introspect", reflect.TypeOf((*MockUsecase)(nil).Introspect), ctx, token)
+}
+
+// IssueAPIKey mocks base method.
+func (m *MockUsecase) IssueAPIKey(ctx context.Context, actorID uuid.UUID, req *models.APIKeyRequest) (*models.IssuedAPIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueAPIKey", ctx, actorID, req)
+	ret0, _ := ret[0].(*models.IssuedAPIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IssueAPIKey indicates an expected call of IssueAPIKey.
+func (mr *MockUsecaseMockRecorder) IssueAPIKey(ctx, actorID, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueAPIKey", reflect.TypeOf((*MockUsecase)(nil).IssueAPIKey), ctx, actorID, req)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockUsecase) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", ctx)
+	ret0, _ := ret[0].([]*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockUsecaseMockRecorder) ListAPIKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockUsecase)(nil).ListAPIKeys), ctx)
+}
+
+// ListUsers mocks base method.
+func (m *MockUsecase) ListUsers(ctx context.Context, limit, page uint64) ([]*models.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, limit, page)
+	ret0, _ := ret[0].([]*models.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockUsecaseMockRecorder) ListUsers(ctx, limit, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockUsecase)(nil).ListUsers), ctx, limit, page)
+}
+
 // Login mocks base method.
-func (m *MockUsecase) Login(ctx context.Context, userData *models.Users) (string, error) {
+func (m *MockUsecase) Login(ctx context.Context, userData *models.Users, clientIP string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Login", ctx, userData)
+	ret := m.ctrl.Call(m, "Login", ctx, userData, clientIP)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Login indicates an expected call of Login.
-func (mr *MockUsecaseMockRecorder) Login(ctx, userData any) *gomock.Call {
+func (mr *MockUsecaseMockRecorder) Login(ctx, userData, clientIP any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUsecase)(nil).Login), ctx, userData, clientIP)
+}
+
+// Me mocks base method.
+func (m *MockUsecase) Me(ctx context.Context, principal *models.Principal, payload *models.TokenPayload) (*models.Me, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Me", ctx, principal, payload)
+	ret0, _ := ret[0].(*models.Me)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Me indicates an expected call of Me.
+func (mr *MockUsecaseMockRecorder) Me(ctx, principal, payload any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockUsecase)(nil).Login), ctx, userData)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Me", reflect.TypeOf((*MockUsecase)(nil).Me), ctx, principal, payload)
 }
 
 // Register mocks base method.
@@ -86,6 +251,171 @@ func (mr *MockUsecaseMockRecorder) Register(ctx, userData any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Register", reflect.TypeOf((*MockUsecase)(nil).Register), ctx, userData)
 }
 
+// RequestPasswordReset mocks base method.
+func (m *MockUsecase) RequestPasswordReset(ctx context.Context, email string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestPasswordReset", ctx, email)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestPasswordReset indicates an expected call of RequestPasswordReset.
+func (mr *MockUsecaseMockRecorder) RequestPasswordReset(ctx, email any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestPasswordReset", reflect.TypeOf((*MockUsecase)(nil).RequestPasswordReset), ctx, email)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUsecase) ResetPassword(ctx context.Context, req *models.PasswordResetConfirm) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUsecaseMockRecorder) ResetPassword(ctx, req any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUsecase)(nil).ResetPassword), ctx, req)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockUsecase) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockUsecaseMockRecorder) RevokeAPIKey(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockUsecase)(nil).RevokeAPIKey), ctx, id)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockUsecase) SetUserDisabled(ctx context.Context, actorID, id uuid.UUID, disabled bool) (*models.UserInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, actorID, id, disabled)
+	ret0, _ := ret[0].(*models.UserInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockUsecaseMockRecorder) SetUserDisabled(ctx, actorID, id, disabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockUsecase)(nil).SetUserDisabled), ctx, actorID, id, disabled)
+}
+
+// SetUserPvz mocks base method.
+func (m *MockUsecase) SetUserPvz(ctx context.Context, id uuid.UUID, pvzIDs []uuid.UUID) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPvz", ctx, id, pvzIDs)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserPvz indicates an expected call of SetUserPvz.
+func (mr *MockUsecaseMockRecorder) SetUserPvz(ctx, id, pvzIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPvz", reflect.TypeOf((*MockUsecase)(nil).SetUserPvz), ctx, id, pvzIDs)
+}
+
+// UnlockUser mocks base method.
+func (m *MockUsecase) UnlockUser(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockUsecaseMockRecorder) UnlockUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockUsecase)(nil).UnlockUser), ctx, id)
+}
+
+// MockAPIKeyAuthenticator is a mock of APIKeyAuthenticator interface.
+type MockAPIKeyAuthenticator struct {
+	ctrl     *gomock.Controller
+	recorder *MockAPIKeyAuthenticatorMockRecorder
+	isgomock struct{}
+}
+
+// MockAPIKeyAuthenticatorMockRecorder is the mock recorder for MockAPIKeyAuthenticator.
+type MockAPIKeyAuthenticatorMockRecorder struct {
+	mock *MockAPIKeyAuthenticator
+}
+
+// NewMockAPIKeyAuthenticator creates a new mock instance.
+func NewMockAPIKeyAuthenticator(ctrl *gomock.Controller) *MockAPIKeyAuthenticator {
+	mock := &MockAPIKeyAuthenticator{ctrl: ctrl}
+	mock.recorder = &MockAPIKeyAuthenticatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAPIKeyAuthenticator) EXPECT() *MockAPIKeyAuthenticatorMockRecorder {
+	return m.recorder
+}
+
+// AuthenticateAPIKey mocks base method.
+func (m *MockAPIKeyAuthenticator) AuthenticateAPIKey(ctx context.Context, key string) (*models.Principal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateAPIKey", ctx, key)
+	ret0, _ := ret[0].(*models.Principal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateAPIKey indicates an expected call of AuthenticateAPIKey.
+func (mr *MockAPIKeyAuthenticatorMockRecorder) AuthenticateAPIKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateAPIKey", reflect.TypeOf((*MockAPIKeyAuthenticator)(nil).AuthenticateAPIKey), ctx, key)
+}
+
+// MockSessionChecker is a mock of SessionChecker interface.
+type MockSessionChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockSessionCheckerMockRecorder
+	isgomock struct{}
+}
+
+// MockSessionCheckerMockRecorder is the mock recorder for MockSessionChecker.
+type MockSessionCheckerMockRecorder struct {
+	mock *MockSessionChecker
+}
+
+// NewMockSessionChecker creates a new mock instance.
+func NewMockSessionChecker(ctrl *gomock.Controller) *MockSessionChecker {
+	mock := &MockSessionChecker{ctrl: ctrl}
+	mock.recorder = &MockSessionCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSessionChecker) EXPECT() *MockSessionCheckerMockRecorder {
+	return m.recorder
+}
+
+// CheckSession mocks base method.
+func (m *MockSessionChecker) CheckSession(ctx context.Context, payload *models.TokenPayload) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckSession", ctx, payload)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckSession indicates an expected call of CheckSession.
+func (mr *MockSessionCheckerMockRecorder) CheckSession(ctx, payload any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckSession", reflect.TypeOf((*MockSessionChecker)(nil).CheckSession), ctx, payload)
+}
+
 // MockRepository is a mock of Repository interface.
 type MockRepository struct {
 	ctrl     *gomock.Controller
@@ -110,6 +440,35 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CreateAPIKey mocks base method.
+func (m *MockRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", ctx, key)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockRepositoryMockRecorder) CreateAPIKey(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockRepository)(nil).CreateAPIKey), ctx, key)
+}
+
+// CreatePasswordResetToken mocks base method.
+func (m *MockRepository) CreatePasswordResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreatePasswordResetToken", ctx, userID, tokenHash, expiresAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreatePasswordResetToken indicates an expected call of CreatePasswordResetToken.
+func (mr *MockRepositoryMockRecorder) CreatePasswordResetToken(ctx, userID, tokenHash, expiresAt any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePasswordResetToken", reflect.TypeOf((*MockRepository)(nil).CreatePasswordResetToken), ctx, userID, tokenHash, expiresAt)
+}
+
 // CreateUser mocks base method.
 func (m *MockRepository) CreateUser(ctx context.Context, user *models.Users) (*models.Users, error) {
 	m.ctrl.T.Helper()
@@ -125,6 +484,66 @@ func (mr *MockRepositoryMockRecorder) CreateUser(ctx, user any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockRepository)(nil).CreateUser), ctx, user)
 }
 
+// DeleteUser mocks base method.
+func (m *MockRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockRepositoryMockRecorder) DeleteUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockRepository)(nil).DeleteUser), ctx, id)
+}
+
+// GetAPIKeyByHash mocks base method.
+func (m *MockRepository) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeyByHash", ctx, hash)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeyByHash indicates an expected call of GetAPIKeyByHash.
+func (mr *MockRepositoryMockRecorder) GetAPIKeyByHash(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeyByHash", reflect.TypeOf((*MockRepository)(nil).GetAPIKeyByHash), ctx, hash)
+}
+
+// GetLoginLockout mocks base method.
+func (m *MockRepository) GetLoginLockout(ctx context.Context, keys []models.LoginAttemptKey) (*time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginLockout", ctx, keys)
+	ret0, _ := ret[0].(*time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginLockout indicates an expected call of GetLoginLockout.
+func (mr *MockRepositoryMockRecorder) GetLoginLockout(ctx, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginLockout", reflect.TypeOf((*MockRepository)(nil).GetLoginLockout), ctx, keys)
+}
+
+// GetSessionUser mocks base method.
+func (m *MockRepository) GetSessionUser(ctx context.Context, id uuid.UUID) (*models.Users, []uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSessionUser", ctx, id)
+	ret0, _ := ret[0].(*models.Users)
+	ret1, _ := ret[1].([]uuid.UUID)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetSessionUser indicates an expected call of GetSessionUser.
+func (mr *MockRepositoryMockRecorder) GetSessionUser(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSessionUser", reflect.TypeOf((*MockRepository)(nil).GetSessionUser), ctx, id)
+}
+
 // GetUserByEmail mocks base method.
 func (m *MockRepository) GetUserByEmail(ctx context.Context, email string) (*models.Users, error) {
 	m.ctrl.T.Helper()
@@ -140,6 +559,197 @@ func (mr *MockRepositoryMockRecorder) GetUserByEmail(ctx, email any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByEmail", reflect.TypeOf((*MockRepository)(nil).GetUserByEmail), ctx, email)
 }
 
+// GetUserByID mocks base method.
+func (m *MockRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*models.Users, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, id)
+	ret0, _ := ret[0].(*models.Users)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockRepositoryMockRecorder) GetUserByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockRepository)(nil).GetUserByID), ctx, id)
+}
+
+// GetUserPvzIDs mocks base method.
+func (m *MockRepository) GetUserPvzIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserPvzIDs", ctx, userID)
+	ret0, _ := ret[0].([]uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserPvzIDs indicates an expected call of GetUserPvzIDs.
+func (mr *MockRepositoryMockRecorder) GetUserPvzIDs(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserPvzIDs", reflect.TypeOf((*MockRepository)(nil).GetUserPvzIDs), ctx, userID)
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockRepository) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", ctx)
+	ret0, _ := ret[0].([]*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockRepositoryMockRecorder) ListAPIKeys(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockRepository)(nil).ListAPIKeys), ctx)
+}
+
+// ListUsers mocks base method.
+func (m *MockRepository) ListUsers(ctx context.Context, limit, page uint64) ([]*models.Users, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx, limit, page)
+	ret0, _ := ret[0].([]*models.Users)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockRepositoryMockRecorder) ListUsers(ctx, limit, page any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockRepository)(nil).ListUsers), ctx, limit, page)
+}
+
+// RegisterLoginFailure mocks base method.
+func (m *MockRepository) RegisterLoginFailure(ctx context.Context, key models.LoginAttemptKey, window time.Duration, maxFailures int, lockout time.Duration) (*models.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterLoginFailure", ctx, key, window, maxFailures, lockout)
+	ret0, _ := ret[0].(*models.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterLoginFailure indicates an expected call of RegisterLoginFailure.
+func (mr *MockRepositoryMockRecorder) RegisterLoginFailure(ctx, key, window, maxFailures, lockout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterLoginFailure", reflect.TypeOf((*MockRepository)(nil).RegisterLoginFailure), ctx, key, window, maxFailures, lockout)
+}
+
+// ResetLoginFailures mocks base method.
+func (m *MockRepository) ResetLoginFailures(ctx context.Context, key models.LoginAttemptKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetLoginFailures", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetLoginFailures indicates an expected call of ResetLoginFailures.
+func (mr *MockRepositoryMockRecorder) ResetLoginFailures(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetLoginFailures", reflect.TypeOf((*MockRepository)(nil).ResetLoginFailures), ctx, key)
+}
+
+// ResetPassword mocks base method.
+func (m *MockRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (*models.Users, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, tokenHash, passwordHash)
+	ret0, _ := ret[0].(*models.Users)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockRepositoryMockRecorder) ResetPassword(ctx, tokenHash, passwordHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockRepository)(nil).ResetPassword), ctx, tokenHash, passwordHash)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", ctx, id)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockRepositoryMockRecorder) RevokeAPIKey(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockRepository)(nil).RevokeAPIKey), ctx, id)
+}
+
+// SetUserDisabled mocks base method.
+func (m *MockRepository) SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) (*models.Users, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserDisabled", ctx, id, disabled)
+	ret0, _ := ret[0].(*models.Users)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetUserDisabled indicates an expected call of SetUserDisabled.
+func (mr *MockRepositoryMockRecorder) SetUserDisabled(ctx, id, disabled any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserDisabled", reflect.TypeOf((*MockRepository)(nil).SetUserDisabled), ctx, id, disabled)
+}
+
+// SetUserPvzIDs mocks base method.
+func (m *MockRepository) SetUserPvzIDs(ctx context.Context, userID uuid.UUID, pvzIDs []uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetUserPvzIDs", ctx, userID, pvzIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetUserPvzIDs indicates an expected call of SetUserPvzIDs.
+func (mr *MockRepositoryMockRecorder) SetUserPvzIDs(ctx, userID, pvzIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserPvzIDs", reflect.TypeOf((*MockRepository)(nil).SetUserPvzIDs), ctx, userID, pvzIDs)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockRepository) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockRepositoryMockRecorder) TouchAPIKey(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockRepository)(nil).TouchAPIKey), ctx, id)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockRepository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, id, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockRepositoryMockRecorder) UpdatePassword(ctx, id, passwordHash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockRepository)(nil).UpdatePassword), ctx, id, passwordHash)
+}
+
+// UpdateUserRole mocks base method.
+func (m *MockRepository) UpdateUserRole(ctx context.Context, id uuid.UUID, role models.Role) (*models.Users, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserRole", ctx, id, role)
+	ret0, _ := ret[0].(*models.Users)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserRole indicates an expected call of UpdateUserRole.
+func (mr *MockRepositoryMockRecorder) UpdateUserRole(ctx, id, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserRole", reflect.TypeOf((*MockRepository)(nil).UpdateUserRole), ctx, id, role)
+}
+
 // MockJWTer is a mock of JWTer interface.
 type MockJWTer struct {
 	ctrl     *gomock.Controller
@@ -179,6 +789,20 @@ func (mr *MockJWTerMockRecorder) GenerateJWT(payload any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWT", reflect.TypeOf((*MockJWTer)(nil).GenerateJWT), payload)
 }
 
+// JWKS mocks base method.
+func (m *MockJWTer) JWKS() *models.JWKSet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(*models.JWKSet)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockJWTerMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockJWTer)(nil).JWKS))
+}
+
 // ValidateJWT mocks base method.
 func (m *MockJWTer) ValidateJWT(tokenString string) (*models.TokenPayload, error) {
 	m.ctrl.T.Helper()
@@ -0,0 +1,178 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"strings"
+)
+
+var apiKeyColumns = []string{
+	"id", "name", "prefix", "key_hash", "role", "scopes", "pvz_ids",
+	"created_by", "created_at", "expires_at", "revoked_at", "last_used_at",
+}
+
+func (repo *Repository) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	const op = "auth.Repository.CreateAPIKey"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Insert("api_keys").
+		Columns("id", "name", "prefix", "key_hash", "role", "scopes", "pvz_ids", "created_by", "expires_at").
+		Values(key.ID, key.Name, key.Prefix, key.Hash, key.Role, key.Scopes, key.PvzIDs, key.CreatedBy, key.ExpiresAt).
+		Suffix("RETURNING " + strings.Join(apiKeyColumns, ", ")).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	createdKey, err := scanAPIKey(repo.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		logger.Error("failed to create api key: " + err.Error())
+		return nil, err
+	}
+
+	return createdKey, nil
+}
+
+func (repo *Repository) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	const op = "auth.Repository.ListAPIKeys"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Select(apiKeyColumns...).
+		From("api_keys").
+		OrderBy("created_at DESC").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	rows, err := repo.pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("failed to execute query: " + err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			logger.Error("failed to scan row: " + err.Error())
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		logger.Error("failed to read rows: " + err.Error())
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (repo *Repository) GetAPIKeyByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	const op = "auth.Repository.GetAPIKeyByHash"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Select(apiKeyColumns...).
+		From("api_keys").
+		Where(squirrel.Eq{"key_hash": hash}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	key, err := scanAPIKey(repo.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Warn("api key not found")
+			return nil, auth.ErrAPIKeyNotFound
+		}
+		logger.Error("failed to get api key: " + err.Error())
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (repo *Repository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	const op = "auth.Repository.RevokeAPIKey"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Update("api_keys").
+		Set("revoked_at", squirrel.Expr("COALESCE(revoked_at, now())")).
+		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING " + strings.Join(apiKeyColumns, ", ")).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	key, err := scanAPIKey(repo.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("api key not found")
+			return nil, auth.ErrAPIKeyNotFound
+		}
+		logger.Error("failed to revoke api key: " + err.Error())
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (repo *Repository) TouchAPIKey(ctx context.Context, id uuid.UUID) error {
+	const op = "auth.Repository.TouchAPIKey"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Update("api_keys").
+		Set("last_used_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	if _, err = repo.pool.Exec(ctx, query, args...); err != nil {
+		logger.Error("failed to update last usage: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	if err := row.Scan(
+		&key.ID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		&key.Role,
+		&key.Scopes,
+		&key.PvzIDs,
+		&key.CreatedBy,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.RevokedAt,
+		&key.LastUsedAt,
+	); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
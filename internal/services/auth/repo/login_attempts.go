@@ -0,0 +1,107 @@
+package repo
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"time"
+)
+
+func (repo *Repository) GetLoginLockout(ctx context.Context, keys []models.LoginAttemptKey) (*time.Time, error) {
+	const op = "auth.Repository.GetLoginLockout"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	subjects := squirrel.Or{}
+	for _, key := range keys {
+		subjects = append(subjects, squirrel.Eq{"kind": key.Kind, "subject": key.Subject, "client_ip": key.ClientIP})
+	}
+
+	query, args, err := repo.builder.
+		Select("max(locked_until)").
+		From("login_attempts").
+		Where(subjects).
+		Where("locked_until > now()").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	var lockedUntil *time.Time
+	if err = repo.pool.QueryRow(ctx, query, args...).Scan(&lockedUntil); err != nil {
+		logger.Error("failed to get lockout: " + err.Error())
+		return nil, err
+	}
+
+	return lockedUntil, nil
+}
+
+// RegisterLoginFailure counts a failed attempt in the current window and
+// locks the key once maxFailures is reached. The counter starts over when
+// the window has passed since its first failure.
+func (repo *Repository) RegisterLoginFailure(ctx context.Context, key models.LoginAttemptKey, window time.Duration, maxFailures int, lockout time.Duration) (*models.LoginAttempts, error) {
+	const op = "auth.Repository.RegisterLoginFailure"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	windowSecs := window.Seconds()
+	lockoutSecs := lockout.Seconds()
+	failures := "CASE WHEN login_attempts.window_start < now() - make_interval(secs => ?) THEN 1 ELSE login_attempts.failures + 1 END"
+
+	query, args, err := repo.builder.
+		Insert("login_attempts").
+		Columns("kind", "subject", "client_ip", "failures", "window_start", "last_failure_at", "locked_until").
+		Values(
+			key.Kind, key.Subject, key.ClientIP, 1, squirrel.Expr("now()"), squirrel.Expr("now()"),
+			squirrel.Expr("CASE WHEN 1 >= ? THEN now() + make_interval(secs => ?) END", maxFailures, lockoutSecs),
+		).
+		Suffix("ON CONFLICT (kind, subject, client_ip) DO UPDATE SET "+
+			"failures = "+failures+", "+
+			"window_start = CASE WHEN login_attempts.window_start < now() - make_interval(secs => ?) THEN now() ELSE login_attempts.window_start END, "+
+			"last_failure_at = now(), "+
+			"locked_until = CASE WHEN "+failures+" >= ? THEN now() + make_interval(secs => ?) ELSE login_attempts.locked_until END "+
+			"RETURNING failures, locked_until",
+			windowSecs, windowSecs, windowSecs, maxFailures, lockoutSecs,
+		).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	attempts := &models.LoginAttempts{}
+	if err = repo.pool.QueryRow(ctx, query, args...).Scan(&attempts.Failures, &attempts.LockedUntil); err != nil {
+		logger.Error("failed to register login failure: " + err.Error())
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// ResetLoginFailures removes the counter of key. A key without a client ip
+// removes the counters of the subject from every client.
+func (repo *Repository) ResetLoginFailures(ctx context.Context, key models.LoginAttemptKey) error {
+	const op = "auth.Repository.ResetLoginFailures"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	where := squirrel.Eq{"kind": key.Kind, "subject": key.Subject}
+	if key.ClientIP != "" {
+		where["client_ip"] = key.ClientIP
+	}
+
+	query, args, err := repo.builder.
+		Delete("login_attempts").
+		Where(where).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	if _, err = repo.pool.Exec(ctx, query, args...); err != nil {
+		logger.Error("failed to reset login failures: " + err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"strings"
+	"time"
+)
+
+// UpdatePassword sets a new password hash and bumps the token version, so
+// tokens issued before the change stop being accepted.
+func (repo *Repository) UpdatePassword(ctx context.Context, id uuid.UUID, passwordHash string) error {
+	const op = "auth.Repository.UpdatePassword"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Update("users").
+		Set("password", passwordHash).
+		Set("token_version", squirrel.Expr("token_version + 1")).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	tag, err := repo.pool.Exec(ctx, query, args...)
+	if err != nil {
+		logger.Error("failed to update password: " + err.Error())
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		logger.Error("user not found")
+		return auth.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (repo *Repository) CreatePasswordResetToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	const op = "auth.Repository.CreatePasswordResetToken"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Insert("password_reset_tokens").
+		Columns("token_hash", "user_id", "expires_at").
+		Values(tokenHash, userID, expiresAt).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	if _, err = repo.pool.Exec(ctx, query, args...); err != nil {
+		logger.Error("failed to create reset token: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// ResetPassword consumes the reset token and sets the new password. All
+// other reset tokens of the user are invalidated as well.
+func (repo *Repository) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (*models.Users, error) {
+	const op = "auth.Repository.ResetPassword"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		logger.Error("failed to begin transaction: " + err.Error())
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := repo.builder.
+		Update("password_reset_tokens").
+		Set("used_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"token_hash": tokenHash, "used_at": nil}).
+		Where("expires_at > now()").
+		Suffix("RETURNING user_id").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	var userID uuid.UUID
+	if err = tx.QueryRow(ctx, query, args...).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Warn("reset token is invalid")
+			return nil, auth.ErrInvalidResetToken
+		}
+		logger.Error("failed to consume reset token: " + err.Error())
+		return nil, err
+	}
+
+	query, args, err = repo.builder.
+		Update("users").
+		Set("password", passwordHash).
+		Set("token_version", squirrel.Expr("token_version + 1")).
+		Where(squirrel.Eq{"id": userID}).
+		Suffix("RETURNING " + strings.Join(userColumns, ", ")).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	user, err := scanUser(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		logger.Error("failed to update password: " + err.Error())
+		return nil, err
+	}
+
+	query, args, err = repo.builder.
+		Update("password_reset_tokens").
+		Set("used_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"user_id": userID, "used_at": nil}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		logger.Error("failed to invalidate reset tokens: " + err.Error())
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("failed to commit transaction: " + err.Error())
+		return nil, err
+	}
+
+	return user, nil
+}
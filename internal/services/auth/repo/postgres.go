@@ -4,19 +4,25 @@ import (
 	"context"
 	"errors"
 	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
 	"github.com/marrgancovka/pvzService/internal/services/auth"
 	"go.uber.org/fx"
 	"log/slog"
+	"strings"
 )
 
 const (
-	PgErrCodeAlreadyExists = "23505"
+	PgErrCodeAlreadyExists            = "23505"
+	PgErrViolatesForeignKeyConstraint = "23503"
 )
 
+var userColumns = []string{"id", "email", "role", "password", "disabled", "created_at", "token_version"}
+
 type Params struct {
 	fx.In
 
@@ -41,10 +47,10 @@ func NewRepository(params Params) *Repository {
 
 func (repo *Repository) GetUserByEmail(ctx context.Context, email string) (*models.Users, error) {
 	const op = "auth.Repository.GetUserByEmail"
-	logger := repo.log.With("op", op)
+	logger := logctx.From(ctx, repo.log).With("op", op)
 
 	query, args, err := repo.builder.
-		Select("id", "email", "role", "password").
+		Select(userColumns...).
 		From("users").
 		Where(squirrel.Eq{"email": email}).
 		ToSql()
@@ -53,13 +59,7 @@ func (repo *Repository) GetUserByEmail(ctx context.Context, email string) (*mode
 		return nil, err
 	}
 
-	user := &models.Users{}
-	err = repo.pool.QueryRow(ctx, query, args...).Scan(
-		&user.ID,
-		&user.Email,
-		&user.Role,
-		&user.Password,
-	)
+	user, err := scanUser(repo.pool.QueryRow(ctx, query, args...))
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -75,26 +75,21 @@ func (repo *Repository) GetUserByEmail(ctx context.Context, email string) (*mode
 
 func (repo *Repository) CreateUser(ctx context.Context, user *models.Users) (*models.Users, error) {
 	const op = "auth.Repository.CreateUser"
-	logger := repo.log.With("op", op)
+	logger := logctx.From(ctx, repo.log).With("op", op)
 
 	query, args, err := repo.builder.
 		Insert("users").
 		Columns("id", "email", "role", "password").
 		Values(user.ID, user.Email, user.Role, user.Password).
-		Suffix("RETURNING id, email, role, password").
+		Suffix("RETURNING " + strings.Join(userColumns, ", ")).
 		ToSql()
 	if err != nil {
 		logger.Error("build query error: " + err.Error())
 		return nil, err
 	}
 
-	createdUser := &models.Users{}
-	if err = repo.pool.QueryRow(ctx, query, args...).Scan(
-		&createdUser.ID,
-		&createdUser.Email,
-		&createdUser.Role,
-		&createdUser.Password,
-	); err != nil {
+	createdUser, err := scanUser(repo.pool.QueryRow(ctx, query, args...))
+	if err != nil {
 		pgErr := &pgconn.PgError{}
 		if errors.As(err, &pgErr) && pgErr.Code == PgErrCodeAlreadyExists {
 			logger.Error("user already exists")
@@ -106,3 +101,175 @@ func (repo *Repository) CreateUser(ctx context.Context, user *models.Users) (*mo
 
 	return createdUser, nil
 }
+
+func (repo *Repository) GetUserByID(ctx context.Context, id uuid.UUID) (*models.Users, error) {
+	const op = "auth.Repository.GetUserByID"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Select(userColumns...).
+		From("users").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	user, err := scanUser(repo.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("user not found")
+			return nil, auth.ErrUserNotFound
+		}
+		logger.Error("failed to get user: " + err.Error())
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (repo *Repository) ListUsers(ctx context.Context, limit, page uint64) ([]*models.Users, error) {
+	const op = "auth.Repository.ListUsers"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Select(userColumns...).
+		From("users").
+		OrderBy("created_at", "email").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	rows, err := repo.pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("failed to execute query: " + err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*models.Users{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			logger.Error("failed to scan row: " + err.Error())
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		logger.Error("failed to read rows: " + err.Error())
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (repo *Repository) UpdateUserRole(ctx context.Context, id uuid.UUID, role models.Role) (*models.Users, error) {
+	const op = "auth.Repository.UpdateUserRole"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Update("users").
+		Set("role", role).
+		// tokens carrying the previous role are revoked
+		Set("token_version", squirrel.Expr("token_version + 1")).
+		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING " + strings.Join(userColumns, ", ")).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	user, err := scanUser(repo.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("user not found")
+			return nil, auth.ErrUserNotFound
+		}
+		logger.Error("failed to update role: " + err.Error())
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (repo *Repository) SetUserDisabled(ctx context.Context, id uuid.UUID, disabled bool) (*models.Users, error) {
+	const op = "auth.Repository.SetUserDisabled"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	update := repo.builder.
+		Update("users").
+		Set("disabled", disabled)
+	if disabled {
+		// tokens issued before stay revoked once the user is enabled again
+		update = update.Set("token_version", squirrel.Expr("token_version + 1"))
+	}
+	query, args, err := update.
+		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING " + strings.Join(userColumns, ", ")).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	user, err := scanUser(repo.pool.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("user not found")
+			return nil, auth.ErrUserNotFound
+		}
+		logger.Error("failed to update disabled flag: " + err.Error())
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (repo *Repository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	const op = "auth.Repository.DeleteUser"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Delete("users").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	tag, err := repo.pool.Exec(ctx, query, args...)
+	if err != nil {
+		logger.Error("failed to delete user: " + err.Error())
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		logger.Error("user not found")
+		return auth.ErrUserNotFound
+	}
+
+	return nil
+}
+
+func scanUser(row pgx.Row) (*models.Users, error) {
+	user := &models.Users{}
+	if err := row.Scan(
+		&user.ID,
+		&user.Email,
+		&user.Role,
+		&user.Password,
+		&user.Disabled,
+		&user.CreatedAt,
+		&user.TokenVersion,
+	); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,173 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+)
+
+// GetSessionUser returns the user with the pvz assigned to them in a single
+// query, it runs for every request authenticated with a token.
+func (repo *Repository) GetSessionUser(ctx context.Context, id uuid.UUID) (*models.Users, []uuid.UUID, error) {
+	const op = "auth.Repository.GetSessionUser"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Select(userColumns...).
+		Column("ARRAY(SELECT pvz_id FROM user_pvz WHERE user_id = users.id ORDER BY assigned_at, pvz_id)").
+		From("users").
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, nil, err
+	}
+
+	user := &models.Users{}
+	pvzIDs := []uuid.UUID{}
+	if err = repo.pool.QueryRow(ctx, query, args...).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Role,
+		&user.Password,
+		&user.Disabled,
+		&user.CreatedAt,
+		&user.TokenVersion,
+		&pvzIDs,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("user not found")
+			return nil, nil, auth.ErrUserNotFound
+		}
+		logger.Error("failed to get user: " + err.Error())
+		return nil, nil, err
+	}
+
+	return user, pvzIDs, nil
+}
+
+func (repo *Repository) GetUserPvzIDs(ctx context.Context, userID uuid.UUID) ([]uuid.UUID, error) {
+	const op = "auth.Repository.GetUserPvzIDs"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Select("pvz_id").
+		From("user_pvz").
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("assigned_at", "pvz_id").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	rows, err := repo.pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("failed to execute query: " + err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	pvzIDs := []uuid.UUID{}
+	for rows.Next() {
+		var pvzID uuid.UUID
+		if err = rows.Scan(&pvzID); err != nil {
+			logger.Error("failed to scan row: " + err.Error())
+			return nil, err
+		}
+		pvzIDs = append(pvzIDs, pvzID)
+	}
+	if err = rows.Err(); err != nil {
+		logger.Error("failed to read rows: " + err.Error())
+		return nil, err
+	}
+
+	return pvzIDs, nil
+}
+
+// SetUserPvzIDs replaces the pvz assignments of the user.
+func (repo *Repository) SetUserPvzIDs(ctx context.Context, userID uuid.UUID, pvzIDs []uuid.UUID) error {
+	const op = "auth.Repository.SetUserPvzIDs"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		logger.Error("failed to begin transaction: " + err.Error())
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := repo.builder.
+		Select("id").
+		From("users").
+		Where(squirrel.Eq{"id": userID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	var id uuid.UUID
+	if err = tx.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("user not found")
+			return auth.ErrUserNotFound
+		}
+		logger.Error("failed to lock user: " + err.Error())
+		return err
+	}
+
+	query, args, err = repo.builder.
+		Delete("user_pvz").
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		logger.Error("failed to delete assignments: " + err.Error())
+		return err
+	}
+
+	if len(pvzIDs) > 0 {
+		insert := repo.builder.
+			Insert("user_pvz").
+			Columns("user_id", "pvz_id").
+			Suffix("ON CONFLICT DO NOTHING")
+		for _, pvzID := range pvzIDs {
+			insert = insert.Values(userID, pvzID)
+		}
+
+		query, args, err = insert.ToSql()
+		if err != nil {
+			logger.Error("build query error: " + err.Error())
+			return err
+		}
+
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			pgErr := &pgconn.PgError{}
+			if errors.As(err, &pgErr) && pgErr.Code == PgErrViolatesForeignKeyConstraint {
+				logger.Error("pvz not found")
+				return auth.ErrPvzNotFound
+			}
+			logger.Error("failed to insert assignments: " + err.Error())
+			return err
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("failed to commit transaction: " + err.Error())
+		return err
+	}
+
+	return nil
+}
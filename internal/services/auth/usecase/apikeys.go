@@ -0,0 +1,170 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/pkg/hasher"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyPrefix        = "pvz"
+	apiKeyPrefixBytes   = 4
+	apiKeySecretBytes   = 32
+	apiKeySeparator     = "_"
+	apiKeyDefaultRole   = models.RoleEmployee
+	apiKeyMaxNameLength = 128
+)
+
+func (uc *Usecase) IssueAPIKey(ctx context.Context, actorID uuid.UUID, req *models.APIKeyRequest) (_ *models.IssuedAPIKey, err error) {
+	const op = "auth.Usecase.IssueAPIKey"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if req.Name == "" || len(req.Name) > apiKeyMaxNameLength {
+		logger.Error("invalid api key name")
+		return nil, auth.ErrBadRequest
+	}
+	if req.Role == "" {
+		req.Role = apiKeyDefaultRole
+	}
+	if !req.Role.IsValid() {
+		logger.Error("invalid role: " + string(req.Role))
+		return nil, auth.ErrIncorrectRole
+	}
+	for _, scope := range req.Scopes {
+		if !rbac.Permission(scope).IsValid() {
+			logger.Error("invalid scope: " + scope)
+			return nil, auth.ErrInvalidScope
+		}
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		logger.Error("api key expiration is in the past")
+		return nil, auth.ErrBadRequest
+	}
+
+	prefix, key, err := generateAPIKey()
+	if err != nil {
+		logger.Error("failed to generate api key: " + err.Error())
+		return nil, err
+	}
+
+	apiKey := &models.APIKey{
+		ID:        uuid.New(),
+		Name:      req.Name,
+		Prefix:    prefix,
+		Hash:      hasher.GenerateHashString(key),
+		Role:      req.Role,
+		Scopes:    req.Scopes,
+		PvzIDs:    req.PvzIDs,
+		ExpiresAt: req.ExpiresAt,
+	}
+	if apiKey.Scopes == nil {
+		apiKey.Scopes = []string{}
+	}
+	if apiKey.PvzIDs == nil {
+		apiKey.PvzIDs = []uuid.UUID{}
+	}
+	if actorID != uuid.Nil {
+		apiKey.CreatedBy = &actorID
+	}
+
+	createdKey, err := uc.repo.CreateAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("issued api key", "key_id", createdKey.ID, "prefix", createdKey.Prefix)
+	return &models.IssuedAPIKey{
+		APIKey: *createdKey,
+		Key:    key,
+	}, nil
+}
+
+func (uc *Usecase) ListAPIKeys(ctx context.Context) (_ []*models.APIKey, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Usecase.ListAPIKeys")
+	defer tracing.End(span, &err)
+
+	return uc.repo.ListAPIKeys(ctx)
+}
+
+func (uc *Usecase) RevokeAPIKey(ctx context.Context, id uuid.UUID) (_ *models.APIKey, err error) {
+	const op = "auth.Usecase.RevokeAPIKey"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	key, err := uc.repo.RevokeAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("revoked api key", "key_id", key.ID)
+	return key, nil
+}
+
+func (uc *Usecase) AuthenticateAPIKey(ctx context.Context, key string) (_ *models.Principal, err error) {
+	const op = "auth.Usecase.AuthenticateAPIKey"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if !strings.HasPrefix(key, apiKeyPrefix+apiKeySeparator) {
+		logger.Warn("malformed api key")
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	apiKey, err := uc.repo.GetAPIKeyByHash(ctx, hasher.GenerateHashString(key))
+	if err != nil {
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if apiKey.RevokedAt != nil {
+		logger.Warn("revoked api key used", "key_id", apiKey.ID)
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		logger.Warn("expired api key used", "key_id", apiKey.ID)
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	if apiKey.LastUsedAt == nil || time.Since(*apiKey.LastUsedAt) >= uc.cfg.APIKeyTouchInterval {
+		if err = uc.repo.TouchAPIKey(ctx, apiKey.ID); err != nil {
+			logger.Warn("failed to record api key usage: " + err.Error())
+		}
+	}
+
+	return &models.Principal{
+		Role:      apiKey.Role,
+		APIKeyID:  apiKey.ID,
+		Scopes:    apiKey.Scopes,
+		PvzIDs:    apiKey.PvzIDs,
+		ExpiresAt: apiKey.ExpiresAt,
+	}, nil
+}
+
+// generateAPIKey returns a key of the form pvz_<prefix>_<secret> together
+// with its prefix, which is stored in plain text to identify the key.
+func generateAPIKey() (string, string, error) {
+	buf := make([]byte, apiKeyPrefixBytes+apiKeySecretBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	prefix := hex.EncodeToString(buf[:apiKeyPrefixBytes])
+	secret := hex.EncodeToString(buf[apiKeyPrefixBytes:])
+
+	return prefix, strings.Join([]string{apiKeyPrefix, prefix, secret}, apiKeySeparator), nil
+}
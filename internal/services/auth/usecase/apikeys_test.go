@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/internal/services/auth/mocks"
+	"github.com/marrgancovka/pvzService/pkg/hasher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUsecase_IssueAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:  log,
+		repo: mockRepo,
+	}
+
+	past := time.Now().Add(-time.Hour)
+	pvzID := uuid.New()
+
+	tests := []struct {
+		name       string
+		req        *models.APIKeyRequest
+		setupMocks func()
+		wantErr    error
+	}{
+		{
+			name: "successful",
+			req: &models.APIKeyRequest{
+				Name:   "warehouse",
+				Scopes: []string{"reception:open", "product:add"},
+				PvzIDs: []uuid.UUID{pvzID},
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().
+					CreateAPIKey(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, key *models.APIKey) (*models.APIKey, error) {
+						assert.Equal(t, models.RoleEmployee, key.Role)
+						assert.Equal(t, []uuid.UUID{pvzID}, key.PvzIDs)
+						assert.Len(t, key.Prefix, 8)
+						assert.NotEmpty(t, key.Hash)
+						return key, nil
+					})
+			},
+		},
+		{
+			name:       "empty name",
+			req:        &models.APIKeyRequest{},
+			setupMocks: func() {},
+			wantErr:    auth.ErrBadRequest,
+		},
+		{
+			name:       "invalid role",
+			req:        &models.APIKeyRequest{Name: "warehouse", Role: "root"},
+			setupMocks: func() {},
+			wantErr:    auth.ErrIncorrectRole,
+		},
+		{
+			name:       "invalid scope",
+			req:        &models.APIKeyRequest{Name: "warehouse", Scopes: []string{"pvz:destroy"}},
+			setupMocks: func() {},
+			wantErr:    auth.ErrInvalidScope,
+		},
+		{
+			name:       "expired",
+			req:        &models.APIKeyRequest{Name: "warehouse", ExpiresAt: &past},
+			setupMocks: func() {},
+			wantErr:    auth.ErrBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			issued, err := uc.IssueAPIKey(context.Background(), uuid.New(), tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(issued.Key, "pvz_"+issued.Prefix+"_"))
+			assert.Equal(t, hasher.GenerateHashString(issued.Key), issued.Hash)
+		})
+	}
+}
+
+func TestUsecase_AuthenticateAPIKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:  log,
+		repo: mockRepo,
+		cfg:  auth.Config{APIKeyTouchInterval: 5 * time.Minute},
+	}
+
+	const key = "pvz_0a1b2c3d_secret"
+	keyID := uuid.New()
+	past := time.Now().Add(-time.Hour)
+	recently := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name       string
+		key        string
+		setupMocks func()
+		wantErr    error
+	}{
+		{
+			name: "successful",
+			key:  key,
+			setupMocks: func() {
+				mockRepo.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), hasher.GenerateHashString(key)).
+					Return(&models.APIKey{ID: keyID, Role: models.RoleEmployee, Scopes: []string{"product:add"}}, nil)
+				mockRepo.EXPECT().TouchAPIKey(gomock.Any(), keyID).Return(nil)
+			},
+		},
+		{
+			name: "used recently",
+			key:  key,
+			setupMocks: func() {
+				mockRepo.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), hasher.GenerateHashString(key)).
+					Return(&models.APIKey{ID: keyID, Role: models.RoleEmployee, Scopes: []string{"product:add"}, LastUsedAt: &recently}, nil)
+			},
+		},
+		{
+			name: "usage is stale",
+			key:  key,
+			setupMocks: func() {
+				mockRepo.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), hasher.GenerateHashString(key)).
+					Return(&models.APIKey{ID: keyID, Role: models.RoleEmployee, Scopes: []string{"product:add"}, LastUsedAt: &past}, nil)
+				mockRepo.EXPECT().TouchAPIKey(gomock.Any(), keyID).Return(nil)
+			},
+		},
+		{
+			name:       "malformed",
+			key:        "secret",
+			setupMocks: func() {},
+			wantErr:    auth.ErrInvalidAPIKey,
+		},
+		{
+			name: "unknown",
+			key:  key,
+			setupMocks: func() {
+				mockRepo.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Return(nil, auth.ErrAPIKeyNotFound)
+			},
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+		{
+			name: "revoked",
+			key:  key,
+			setupMocks: func() {
+				mockRepo.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Return(&models.APIKey{ID: keyID, Role: models.RoleEmployee, RevokedAt: &past}, nil)
+			},
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+		{
+			name: "expired",
+			key:  key,
+			setupMocks: func() {
+				mockRepo.EXPECT().
+					GetAPIKeyByHash(gomock.Any(), gomock.Any()).
+					Return(&models.APIKey{ID: keyID, Role: models.RoleEmployee, ExpiresAt: &past}, nil)
+			},
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			principal, err := uc.AuthenticateAPIKey(context.Background(), tt.key)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, keyID, principal.APIKeyID)
+			assert.Equal(t, models.RoleEmployee, principal.Role)
+			assert.True(t, principal.HasScope("product:add"))
+			assert.False(t, principal.HasScope("product:delete"))
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"strings"
+	"time"
+)
+
+// loginAttemptKeys returns the counters a login attempt is tracked by: the
+// email and client ip key first, then the account-wide one and the ip one.
+// Without a client ip the first key covers every client, which is what
+// resetting the account needs.
+func loginAttemptKeys(email, clientIP string) []models.LoginAttemptKey {
+	subject := strings.ToLower(strings.TrimSpace(email))
+	keys := []models.LoginAttemptKey{
+		{Kind: models.LoginAttemptEmail, Subject: subject, ClientIP: clientIP},
+		{Kind: models.LoginAttemptAccount, Subject: subject},
+	}
+	if clientIP != "" {
+		keys = append(keys, models.LoginAttemptKey{
+			Kind:    models.LoginAttemptIP,
+			Subject: clientIP,
+		})
+	}
+	return keys
+}
+
+func (uc *Usecase) checkLockout(ctx context.Context, keys []models.LoginAttemptKey) error {
+	const op = "auth.Usecase.checkLockout"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+
+	lockedUntil, err := uc.repo.GetLoginLockout(ctx, keys)
+	if err != nil {
+		return err
+	}
+	if lockedUntil == nil {
+		return nil
+	}
+
+	retryAfter := time.Until(*lockedUntil)
+	if retryAfter <= 0 {
+		return nil
+	}
+
+	logger.Warn("login is locked", "email", keys[0].Subject, "retry_after", retryAfter)
+	uc.metrics.FailedLoginsTotal("locked")
+	return &auth.LockoutError{RetryAfter: retryAfter}
+}
+
+// loginFailed records the failure for every key and slows the caller down.
+// Storage errors are logged only, so they don't hide the login error.
+func (uc *Usecase) loginFailed(ctx context.Context, keys []models.LoginAttemptKey, reason string) {
+	const op = "auth.Usecase.loginFailed"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+
+	uc.metrics.FailedLoginsTotal(reason)
+
+	lockoutCfg := uc.cfg.Lockout
+	delayFailures := 0
+	for _, key := range keys {
+		maxFailures := lockoutCfg.MaxFailures
+		switch key.Kind {
+		case models.LoginAttemptAccount:
+			maxFailures = lockoutCfg.MaxAccountFailures
+		case models.LoginAttemptIP:
+			maxFailures = lockoutCfg.MaxIPFailures
+		}
+
+		attempts, err := uc.repo.RegisterLoginFailure(ctx, key, lockoutCfg.Window, maxFailures, lockoutCfg.Duration)
+		if err != nil {
+			logger.Error("failed to register login failure: " + err.Error())
+			continue
+		}
+
+		// the account counters set the delay, so guesses spread over many
+		// clients are slowed down too; the ip one sets it when the account
+		// is not tracked, so unknown emails are slowed down alike
+		if key.Kind != models.LoginAttemptIP || delayFailures == 0 {
+			delayFailures = max(delayFailures, attempts.Failures)
+		}
+		if attempts.Failures == maxFailures {
+			logger.Warn("login locked", "kind", key.Kind, "subject", key.Subject, "failures", attempts.Failures)
+			uc.metrics.LoginLockoutsTotal(string(key.Kind))
+		}
+	}
+
+	delay := failureDelay(lockoutCfg.BaseDelay, lockoutCfg.MaxDelay, delayFailures)
+	if delay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+}
+
+// failureDelay doubles the delay with every failure in the window.
+func failureDelay(base, maxDelay time.Duration, failures int) time.Duration {
+	if base <= 0 || failures <= 0 {
+		return 0
+	}
+
+	delay := base
+	for i := 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
+
+func (uc *Usecase) UnlockUser(ctx context.Context, id uuid.UUID) (err error) {
+	const op = "auth.Usecase.UnlockUser"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	user, err := uc.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err = uc.resetAccountFailures(ctx, user.Email); err != nil {
+		return err
+	}
+
+	logger.Info("user unlocked", "user_id", user.ID)
+	return nil
+}
+
+// resetAccountFailures removes the counters of the account from every
+// client, the ip counters are left alone.
+func (uc *Usecase) resetAccountFailures(ctx context.Context, email string) error {
+	for _, key := range loginAttemptKeys(email, "") {
+		if err := uc.repo.ResetLoginFailures(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
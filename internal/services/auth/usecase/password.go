@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/notifier"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/pkg/hasher"
+	"github.com/marrgancovka/pvzService/pkg/validator"
+	"net/url"
+	"time"
+)
+
+const resetTokenBytes = 32
+
+// ChangePassword shares the failed login counters with Login, so a stolen
+// token can't be used to guess the password.
+func (uc *Usecase) ChangePassword(ctx context.Context, userID uuid.UUID, req *models.ChangePasswordRequest, clientIP string) (err error) {
+	const op = "auth.Usecase.ChangePassword"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	user, err := uc.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	keys := loginAttemptKeys(user.Email, clientIP)
+	if err = uc.checkLockout(ctx, keys); err != nil {
+		return err
+	}
+	if !hasher.CompareStringHash(req.OldPassword, user.Password) {
+		logger.Warn("old password doesn't match", "user_id", userID)
+		uc.loginFailed(ctx, keys, "bad_old_password")
+		return auth.ErrIncorrectOldPassword
+	}
+	if req.OldPassword == req.NewPassword {
+		logger.Warn("new password equals the old one", "user_id", userID)
+		return auth.ErrSamePassword
+	}
+	if err = uc.validatePassword("newPassword", req.NewPassword); err != nil {
+		logger.Warn("weak password: " + err.Error())
+		return err
+	}
+
+	if err = uc.repo.UpdatePassword(ctx, userID, hasher.GenerateHashString(req.NewPassword)); err != nil {
+		return err
+	}
+	if err = uc.repo.ResetLoginFailures(ctx, keys[0]); err != nil {
+		logger.Warn("failed to reset login failures: " + err.Error())
+	}
+
+	logger.Info("password changed", "user_id", userID)
+	return nil
+}
+
+// RequestPasswordReset sends a reset token to the user. It succeeds for
+// unknown and disabled accounts too, and failures to issue or send the token
+// are only logged, so the response doesn't reveal which emails are registered.
+func (uc *Usecase) RequestPasswordReset(ctx context.Context, email string) (err error) {
+	const op = "auth.Usecase.RequestPasswordReset"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	user, err := uc.repo.GetUserByEmail(ctx, validator.NormalizeEmail(email))
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			logger.Info("password reset for unknown email")
+			return nil
+		}
+		return err
+	}
+	if user.Disabled {
+		logger.Info("password reset for disabled user", "user_id", user.ID)
+		return nil
+	}
+
+	token, err := generateResetToken()
+	if err != nil {
+		logger.Error("failed to generate reset token: " + err.Error())
+		return nil
+	}
+
+	expiresAt := time.Now().Add(uc.cfg.ResetTokenTTL)
+	if err = uc.repo.CreatePasswordResetToken(ctx, user.ID, hasher.GenerateHashString(token), expiresAt); err != nil {
+		logger.Error("failed to create reset token: " + err.Error())
+		return nil
+	}
+
+	if err = uc.notifier.Notify(ctx, uc.resetMessage(user.Email, token, expiresAt)); err != nil {
+		logger.Error("failed to send reset token: " + err.Error())
+		return nil
+	}
+
+	logger.Info("password reset requested", "user_id", user.ID)
+	return nil
+}
+
+func (uc *Usecase) ResetPassword(ctx context.Context, req *models.PasswordResetConfirm) (err error) {
+	const op = "auth.Usecase.ResetPassword"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if req.Token == "" {
+		return auth.ErrInvalidResetToken
+	}
+	if err := uc.validatePassword("newPassword", req.NewPassword); err != nil {
+		logger.Warn("weak password: " + err.Error())
+		return err
+	}
+
+	user, err := uc.repo.ResetPassword(ctx, hasher.GenerateHashString(req.Token), hasher.GenerateHashString(req.NewPassword))
+	if err != nil {
+		return err
+	}
+
+	if err = uc.resetAccountFailures(ctx, user.Email); err != nil {
+		logger.Warn("failed to reset login failures: " + err.Error())
+	}
+
+	logger.Info("password reset", "user_id", user.ID)
+	return nil
+}
+
+func (uc *Usecase) resetMessage(email, token string, expiresAt time.Time) notifier.Message {
+	body := "Use this token to reset your password: " + token
+	if uc.cfg.ResetURL != "" {
+		if resetURL, err := url.Parse(uc.cfg.ResetURL); err == nil {
+			query := resetURL.Query()
+			query.Set("token", token)
+			resetURL.RawQuery = query.Encode()
+			body = "Follow the link to reset your password: " + resetURL.String()
+		}
+	}
+	body += "\nIt expires at " + expiresAt.UTC().Format(time.RFC3339) + "."
+
+	return notifier.Message{
+		To:      email,
+		Subject: "Password reset",
+		Body:    body,
+	}
+}
+
+func generateResetToken() (string, error) {
+	buf := make([]byte, resetTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
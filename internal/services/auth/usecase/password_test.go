@@ -0,0 +1,302 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	metricsMocks "github.com/marrgancovka/pvzService/internal/pkg/metrics/mocks"
+	"github.com/marrgancovka/pvzService/internal/pkg/notifier"
+	notifierMocks "github.com/marrgancovka/pvzService/internal/pkg/notifier/mocks"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/internal/services/auth/mocks"
+	"github.com/marrgancovka/pvzService/pkg/hasher"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUsecase_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMetrics := metricsMocks.NewMockMetrics(ctrl)
+	mockMetrics.EXPECT().FailedLoginsTotal(gomock.Any()).AnyTimes()
+	mockMetrics.EXPECT().LoginLockoutsTotal(gomock.Any()).AnyTimes()
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:     log,
+		repo:    mockRepo,
+		metrics: mockMetrics,
+		cfg: auth.Config{
+			Lockout: auth.LockoutConfig{MaxFailures: 3, MaxAccountFailures: 10, MaxIPFailures: 50, Window: time.Minute, Duration: time.Minute},
+		},
+	}
+
+	const clientIP = "192.0.2.1"
+	emailKey := models.LoginAttemptKey{Kind: models.LoginAttemptEmail, Subject: "test@example.com", ClientIP: clientIP}
+	accountKey := models.LoginAttemptKey{Kind: models.LoginAttemptAccount, Subject: "test@example.com"}
+	ipKey := models.LoginAttemptKey{Kind: models.LoginAttemptIP, Subject: clientIP}
+	lockedUntil := time.Now().Add(time.Minute)
+
+	userID := uuid.New()
+	user := &models.Users{
+		ID:       userID,
+		Email:    "test@example.com",
+		Password: hasher.GenerateHashString("old"),
+		Role:     models.RoleEmployee,
+	}
+
+	tests := []struct {
+		name       string
+		req        *models.ChangePasswordRequest
+		setupMocks func()
+		wantErr    error
+	}{
+		{
+			name: "successful",
+			req:  &models.ChangePasswordRequest{OldPassword: "old", NewPassword: "new"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), userID).Return(user, nil)
+				mockRepo.EXPECT().GetLoginLockout(gomock.Any(), []models.LoginAttemptKey{emailKey, accountKey, ipKey}).Return(nil, nil)
+				mockRepo.EXPECT().UpdatePassword(gomock.Any(), userID, hasher.GenerateHashString("new")).Return(nil)
+				mockRepo.EXPECT().ResetLoginFailures(gomock.Any(), emailKey).Return(nil)
+			},
+		},
+		{
+			name: "incorrect old password",
+			req:  &models.ChangePasswordRequest{OldPassword: "wrong", NewPassword: "new"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), userID).Return(user, nil)
+				mockRepo.EXPECT().GetLoginLockout(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().
+					RegisterLoginFailure(gomock.Any(), emailKey, time.Minute, 3, time.Minute).
+					Return(&models.LoginAttempts{Failures: 1}, nil)
+				mockRepo.EXPECT().
+					RegisterLoginFailure(gomock.Any(), accountKey, time.Minute, 10, time.Minute).
+					Return(&models.LoginAttempts{Failures: 1}, nil)
+				mockRepo.EXPECT().
+					RegisterLoginFailure(gomock.Any(), ipKey, time.Minute, 50, time.Minute).
+					Return(&models.LoginAttempts{Failures: 1}, nil)
+			},
+			wantErr: auth.ErrIncorrectOldPassword,
+		},
+		{
+			name: "locked out",
+			req:  &models.ChangePasswordRequest{OldPassword: "old", NewPassword: "new"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), userID).Return(user, nil)
+				mockRepo.EXPECT().GetLoginLockout(gomock.Any(), gomock.Any()).Return(&lockedUntil, nil)
+			},
+			wantErr: auth.ErrTooManyLoginAttempts,
+		},
+		{
+			name: "same password",
+			req:  &models.ChangePasswordRequest{OldPassword: "old", NewPassword: "old"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), userID).Return(user, nil)
+				mockRepo.EXPECT().GetLoginLockout(gomock.Any(), gomock.Any()).Return(nil, nil)
+			},
+			wantErr: auth.ErrSamePassword,
+		},
+		{
+			name: "user not found",
+			req:  &models.ChangePasswordRequest{OldPassword: "old", NewPassword: "new"},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetUserByID(gomock.Any(), userID).Return(nil, auth.ErrUserNotFound)
+			},
+			wantErr: auth.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := uc.ChangePassword(context.Background(), userID, tt.req, clientIP)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUsecase_RequestPasswordReset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockNotifier := notifierMocks.NewMockNotifier(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:      log,
+		repo:     mockRepo,
+		notifier: mockNotifier,
+		cfg: auth.Config{
+			ResetTokenTTL: 30 * time.Minute,
+			ResetURL:      "https://pvz.example.com/reset",
+		},
+	}
+
+	user := &models.Users{
+		ID:    uuid.New(),
+		Email: "test@example.com",
+		Role:  models.RoleEmployee,
+	}
+	notifyErr := errors.New("smtp is down")
+
+	tests := []struct {
+		name       string
+		email      string
+		setupMocks func()
+		wantErr    error
+	}{
+		{
+			name:  "successful",
+			email: user.Email,
+			setupMocks: func() {
+				var tokenHash string
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Return(user, nil)
+				mockRepo.EXPECT().
+					CreatePasswordResetToken(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, hash string, expiresAt time.Time) error {
+						tokenHash = hash
+						assert.WithinDuration(t, time.Now().Add(30*time.Minute), expiresAt, time.Minute)
+						return nil
+					})
+				mockNotifier.EXPECT().
+					Notify(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, msg notifier.Message) error {
+						assert.Equal(t, user.Email, msg.To)
+						_, after, found := strings.Cut(msg.Body, "https://pvz.example.com/reset?token=")
+						assert.True(t, found)
+						token, _, _ := strings.Cut(after, "\n")
+						assert.Equal(t, tokenHash, hasher.GenerateHashString(token))
+						return nil
+					})
+			},
+		},
+		{
+			name:  "unknown email",
+			email: "nobody@example.com",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "nobody@example.com").Return(nil, auth.ErrUserNotFound)
+			},
+		},
+		{
+			name:  "disabled user",
+			email: "disabled@example.com",
+			setupMocks: func() {
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), "disabled@example.com").
+					Return(&models.Users{ID: uuid.New(), Email: "disabled@example.com", Disabled: true}, nil)
+			},
+		},
+		{
+			name:  "notifier error is hidden",
+			email: user.Email,
+			setupMocks: func() {
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Return(user, nil)
+				mockRepo.EXPECT().CreatePasswordResetToken(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).Return(nil)
+				mockNotifier.EXPECT().Notify(gomock.Any(), gomock.Any()).Return(notifyErr)
+			},
+		},
+		{
+			name:  "repository error is hidden",
+			email: user.Email,
+			setupMocks: func() {
+				mockRepo.EXPECT().GetUserByEmail(gomock.Any(), user.Email).Return(user, nil)
+				mockRepo.EXPECT().CreatePasswordResetToken(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).Return(errors.New("db is down"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := uc.RequestPasswordReset(context.Background(), tt.email)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUsecase_ResetPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:  log,
+		repo: mockRepo,
+	}
+
+	user := &models.Users{ID: uuid.New(), Email: "test@example.com"}
+
+	tests := []struct {
+		name       string
+		req        *models.PasswordResetConfirm
+		setupMocks func()
+		wantErr    error
+	}{
+		{
+			name: "successful",
+			req:  &models.PasswordResetConfirm{Token: "token", NewPassword: "new"},
+			setupMocks: func() {
+				mockRepo.EXPECT().
+					ResetPassword(gomock.Any(), hasher.GenerateHashString("token"), hasher.GenerateHashString("new")).
+					Return(user, nil)
+				mockRepo.EXPECT().
+					ResetLoginFailures(gomock.Any(), models.LoginAttemptKey{Kind: models.LoginAttemptEmail, Subject: user.Email}).
+					Return(nil)
+				mockRepo.EXPECT().
+					ResetLoginFailures(gomock.Any(), models.LoginAttemptKey{Kind: models.LoginAttemptAccount, Subject: user.Email}).
+					Return(nil)
+			},
+		},
+		{
+			name: "invalid token",
+			req:  &models.PasswordResetConfirm{Token: "expired", NewPassword: "new"},
+			setupMocks: func() {
+				mockRepo.EXPECT().ResetPassword(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, auth.ErrInvalidResetToken)
+			},
+			wantErr: auth.ErrInvalidResetToken,
+		},
+		{
+			name:       "empty token",
+			req:        &models.PasswordResetConfirm{NewPassword: "new"},
+			setupMocks: func() {},
+			wantErr:    auth.ErrInvalidResetToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			err := uc.ResetPassword(context.Background(), tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
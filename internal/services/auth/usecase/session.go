@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+)
+
+// CheckSession rejects tokens of deleted or disabled users, tokens issued
+// before the user's password was changed and tokens carrying a role the
+// user no longer has. It returns the pvz the user is assigned to, they
+// restrict the caller like the pvz of an API key.
+func (uc *Usecase) CheckSession(ctx context.Context, payload *models.TokenPayload) (_ []uuid.UUID, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Usecase.CheckSession")
+	defer tracing.End(span, &err)
+
+	_, pvzIDs, err := uc.sessionUser(ctx, payload)
+	return pvzIDs, err
+}
+
+// Me describes the caller. payload is nil for callers authenticated with an
+// API key, they are described by the principal alone.
+func (uc *Usecase) Me(ctx context.Context, principal *models.Principal, payload *models.TokenPayload) (_ *models.Me, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Usecase.Me")
+	defer tracing.End(span, &err)
+
+	if payload == nil {
+		apiKeyID := principal.APIKeyID
+		me := &models.Me{
+			ID:        principal.ID,
+			Role:      principal.Role,
+			PvzIDs:    principal.PvzIDs,
+			APIKeyID:  &apiKeyID,
+			Scopes:    principal.Scopes,
+			ExpiresAt: principal.ExpiresAt,
+		}
+		if me.PvzIDs == nil {
+			me.PvzIDs = []uuid.UUID{}
+		}
+		return me, nil
+	}
+
+	exp := payload.Exp
+	me := &models.Me{
+		ID:        payload.ID,
+		Role:      payload.Role,
+		PvzIDs:    []uuid.UUID{},
+		ExpiresAt: &exp,
+	}
+	if payload.ID == uuid.Nil {
+		return me, nil
+	}
+
+	user, err := uc.repo.GetUserByID(ctx, payload.ID)
+	if err != nil {
+		return nil, err
+	}
+	me.Email = user.Email
+
+	if me.PvzIDs, err = uc.repo.GetUserPvzIDs(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	return me, nil
+}
+
+// Introspect reports whether the token would be accepted by the service
+// right now. Rejected tokens are not an error, they are reported inactive.
+func (uc *Usecase) Introspect(ctx context.Context, token string) (_ *models.TokenIntrospection, err error) {
+	const op = "auth.Usecase.Introspect"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	inactive := &models.TokenIntrospection{Active: false}
+
+	payload, err := uc.jwt.ValidateJWT(token)
+	if err != nil && !errors.Is(err, jwter.ErrNoID) {
+		logger.Info("introspected invalid token: " + err.Error())
+		return inactive, nil
+	}
+	if payload.Dummy && !uc.allowDummy {
+		logger.Info("introspected dummy token")
+		return inactive, nil
+	}
+
+	user, pvzIDs, err := uc.sessionUser(ctx, payload)
+	if err != nil {
+		if errors.Is(err, auth.ErrTokenRevoked) {
+			logger.Info("introspected revoked token", "user_id", payload.ID)
+			return inactive, nil
+		}
+		return nil, err
+	}
+
+	result := &models.TokenIntrospection{
+		Active:    true,
+		Role:      payload.Role,
+		TokenType: "Bearer",
+		Exp:       payload.Exp.Unix(),
+		Jti:       payload.TokenID,
+	}
+	if !payload.IssuedAt.IsZero() {
+		result.Iat = payload.IssuedAt.Unix()
+	}
+	if user != nil {
+		result.Subject = user.ID.String()
+		result.Username = user.Email
+		result.PvzIDs = pvzIDs
+	}
+
+	return result, nil
+}
+
+func (uc *Usecase) GetUserPvz(ctx context.Context, id uuid.UUID) (_ []uuid.UUID, err error) {
+	ctx, span := tracing.Start(ctx, "auth.Usecase.GetUserPvz")
+	defer tracing.End(span, &err)
+
+	if _, err := uc.repo.GetUserByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return uc.repo.GetUserPvzIDs(ctx, id)
+}
+
+func (uc *Usecase) SetUserPvz(ctx context.Context, id uuid.UUID, pvzIDs []uuid.UUID) (_ []uuid.UUID, err error) {
+	const op = "auth.Usecase.SetUserPvz"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if err := uc.repo.SetUserPvzIDs(ctx, id, pvzIDs); err != nil {
+		return nil, err
+	}
+
+	logger.Info("user pvz assignments updated", "user_id", id, "count", len(pvzIDs))
+	return uc.repo.GetUserPvzIDs(ctx, id)
+}
+
+// sessionUser returns the user the token belongs to and their pvz, nil for
+// dummy tokens, or auth.ErrTokenRevoked if the token must no longer be
+// accepted.
+func (uc *Usecase) sessionUser(ctx context.Context, payload *models.TokenPayload) (*models.Users, []uuid.UUID, error) {
+	if payload.Dummy || payload.ID == uuid.Nil {
+		return nil, nil, nil
+	}
+
+	user, pvzIDs, err := uc.repo.GetSessionUser(ctx, payload.ID)
+	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			return nil, nil, auth.ErrTokenRevoked
+		}
+		return nil, nil, err
+	}
+	if user.Disabled || user.TokenVersion != payload.Version || user.Role != payload.Role {
+		return nil, nil, auth.ErrTokenRevoked
+	}
+
+	return user, pvzIDs, nil
+}
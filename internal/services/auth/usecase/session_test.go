@@ -0,0 +1,268 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/internal/services/auth/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestUsecase_CheckSession(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:  log,
+		repo: mockRepo,
+	}
+
+	userID := uuid.New()
+	pvzID := uuid.New()
+
+	tests := []struct {
+		name       string
+		payload    *models.TokenPayload
+		setupMocks func()
+		wantPvzIDs []uuid.UUID
+		wantErr    error
+	}{
+		{
+			name:    "active",
+			payload: &models.TokenPayload{ID: userID, Role: models.RoleEmployee, Version: 2},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetSessionUser(gomock.Any(), userID).Return(&models.Users{ID: userID, Role: models.RoleEmployee, TokenVersion: 2}, []uuid.UUID{pvzID}, nil)
+			},
+			wantPvzIDs: []uuid.UUID{pvzID},
+		},
+		{
+			name:    "password changed",
+			payload: &models.TokenPayload{ID: userID, Version: 1},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetSessionUser(gomock.Any(), userID).Return(&models.Users{ID: userID, TokenVersion: 2}, nil, nil)
+			},
+			wantErr: auth.ErrTokenRevoked,
+		},
+		{
+			name:    "role changed",
+			payload: &models.TokenPayload{ID: userID, Role: models.RoleModerator, Version: 2},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetSessionUser(gomock.Any(), userID).Return(&models.Users{ID: userID, Role: models.RoleEmployee, TokenVersion: 2}, nil, nil)
+			},
+			wantErr: auth.ErrTokenRevoked,
+		},
+		{
+			name:    "disabled",
+			payload: &models.TokenPayload{ID: userID, Version: 2},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetSessionUser(gomock.Any(), userID).Return(&models.Users{ID: userID, TokenVersion: 2, Disabled: true}, nil, nil)
+			},
+			wantErr: auth.ErrTokenRevoked,
+		},
+		{
+			name:    "deleted",
+			payload: &models.TokenPayload{ID: userID},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetSessionUser(gomock.Any(), userID).Return(nil, nil, auth.ErrUserNotFound)
+			},
+			wantErr: auth.ErrTokenRevoked,
+		},
+		{
+			name:       "dummy",
+			payload:    &models.TokenPayload{Role: models.RoleModerator, Dummy: true},
+			setupMocks: func() {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			pvzIDs, err := uc.CheckSession(context.Background(), tt.payload)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPvzIDs, pvzIDs)
+		})
+	}
+}
+
+func TestUsecase_Me(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:  log,
+		repo: mockRepo,
+	}
+
+	userID := uuid.New()
+	pvzID := uuid.New()
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	t.Run("user", func(t *testing.T) {
+		mockRepo.EXPECT().GetUserByID(gomock.Any(), userID).Return(&models.Users{ID: userID, Email: "test@example.com"}, nil)
+		mockRepo.EXPECT().GetUserPvzIDs(gomock.Any(), userID).Return([]uuid.UUID{pvzID}, nil)
+
+		principal := &models.Principal{ID: userID, Role: models.RoleEmployee}
+		me, err := uc.Me(context.Background(), principal, &models.TokenPayload{ID: userID, Role: models.RoleEmployee, Exp: exp})
+		require.NoError(t, err)
+		assert.Equal(t, &models.Me{
+			ID:        userID,
+			Email:     "test@example.com",
+			Role:      models.RoleEmployee,
+			PvzIDs:    []uuid.UUID{pvzID},
+			ExpiresAt: &exp,
+		}, me)
+	})
+
+	t.Run("dummy", func(t *testing.T) {
+		principal := &models.Principal{Role: models.RoleModerator}
+		me, err := uc.Me(context.Background(), principal, &models.TokenPayload{Role: models.RoleModerator, Exp: exp, Dummy: true})
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleModerator, me.Role)
+		assert.Empty(t, me.PvzIDs)
+	})
+
+	t.Run("api key", func(t *testing.T) {
+		keyID := uuid.New()
+		principal := &models.Principal{
+			Role:      models.RoleEmployee,
+			APIKeyID:  keyID,
+			Scopes:    []string{"pvz:read"},
+			PvzIDs:    []uuid.UUID{pvzID},
+			ExpiresAt: &exp,
+		}
+		me, err := uc.Me(context.Background(), principal, nil)
+		require.NoError(t, err)
+		assert.Equal(t, &models.Me{
+			Role:      models.RoleEmployee,
+			PvzIDs:    []uuid.UUID{pvzID},
+			APIKeyID:  &keyID,
+			Scopes:    []string{"pvz:read"},
+			ExpiresAt: &exp,
+		}, me)
+	})
+}
+
+func TestUsecase_Introspect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockJWT := mocks.NewMockJWTer(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:  log,
+		repo: mockRepo,
+		jwt:  mockJWT,
+		// enabled in the config, but not allowed by the profile
+		cfg: auth.Config{DummyLogin: true},
+	}
+
+	userID := uuid.New()
+	pvzID := uuid.New()
+	now := time.Now().Truncate(time.Second)
+	payload := &models.TokenPayload{
+		ID:       userID,
+		Role:     models.RoleEmployee,
+		Exp:      now.Add(time.Hour),
+		IssuedAt: now,
+		TokenID:  "jti",
+		Version:  1,
+	}
+	dbErr := errors.New("db error")
+
+	tests := []struct {
+		name       string
+		setupMocks func()
+		want       *models.TokenIntrospection
+		wantErr    error
+	}{
+		{
+			name: "active",
+			setupMocks: func() {
+				mockJWT.EXPECT().ValidateJWT("token").Return(payload, nil)
+				mockRepo.EXPECT().GetSessionUser(gomock.Any(), userID).
+					Return(&models.Users{ID: userID, Email: "test@example.com", Role: models.RoleEmployee, TokenVersion: 1}, []uuid.UUID{pvzID}, nil)
+			},
+			want: &models.TokenIntrospection{
+				Active:    true,
+				Subject:   userID.String(),
+				Username:  "test@example.com",
+				Role:      models.RoleEmployee,
+				PvzIDs:    []uuid.UUID{pvzID},
+				TokenType: "Bearer",
+				Exp:       now.Add(time.Hour).Unix(),
+				Iat:       now.Unix(),
+				Jti:       "jti",
+			},
+		},
+		{
+			name: "invalid token",
+			setupMocks: func() {
+				mockJWT.EXPECT().ValidateJWT("token").Return(nil, jwter.ErrInvalidToken)
+			},
+			want: &models.TokenIntrospection{Active: false},
+		},
+		{
+			name: "revoked token",
+			setupMocks: func() {
+				mockJWT.EXPECT().ValidateJWT("token").Return(payload, nil)
+				mockRepo.EXPECT().GetSessionUser(gomock.Any(), userID).
+					Return(&models.Users{ID: userID, TokenVersion: 2}, nil, nil)
+			},
+			want: &models.TokenIntrospection{Active: false},
+		},
+		{
+			name: "dummy token with dummy login disabled by the profile",
+			setupMocks: func() {
+				mockJWT.EXPECT().ValidateJWT("token").Return(&models.TokenPayload{Role: models.RoleModerator, Dummy: true}, jwter.ErrNoID)
+			},
+			want: &models.TokenIntrospection{Active: false},
+		},
+		{
+			name: "repository error",
+			setupMocks: func() {
+				mockJWT.EXPECT().ValidateJWT("token").Return(payload, nil)
+				mockRepo.EXPECT().GetSessionUser(gomock.Any(), userID).Return(nil, nil, dbErr)
+			},
+			wantErr: dbErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMocks()
+
+			got, err := uc.Introspect(context.Background(), "token")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -4,10 +4,16 @@ import (
 	"context"
 	"errors"
 	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/config/profile"
 	"github.com/marrgancovka/pvzService/internal/models"
 	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/metrics"
+	"github.com/marrgancovka/pvzService/internal/pkg/notifier"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
 	"github.com/marrgancovka/pvzService/internal/services/auth"
 	"github.com/marrgancovka/pvzService/pkg/hasher"
+	"github.com/marrgancovka/pvzService/pkg/validator"
 	"go.uber.org/fx"
 	"log/slog"
 )
@@ -15,35 +21,59 @@ import (
 type Params struct {
 	fx.In
 
-	Logger *slog.Logger
-	Repo   auth.Repository
-	JWTer  auth.JWTer
+	Logger      *slog.Logger
+	Repo        auth.Repository
+	JWTer       auth.JWTer
+	Config      auth.Config
+	Environment profile.Profile
+	Metrics     metrics.Metrics
+	Notifier    notifier.Notifier
 }
 
 type Usecase struct {
-	log  *slog.Logger
-	repo auth.Repository
-	jwt  auth.JWTer
+	log      *slog.Logger
+	repo     auth.Repository
+	jwt      auth.JWTer
+	cfg      auth.Config
+	metrics  metrics.Metrics
+	notifier notifier.Notifier
+	// allowDummy matches the auth middleware, dummy tokens are accepted
+	// only where the profile allows dummy login
+	allowDummy bool
 }
 
 func NewUsecase(p Params) *Usecase {
 	return &Usecase{
-		log:  p.Logger,
-		repo: p.Repo,
-		jwt:  p.JWTer,
+		log:        p.Logger,
+		repo:       p.Repo,
+		jwt:        p.JWTer,
+		cfg:        p.Config,
+		metrics:    p.Metrics,
+		notifier:   p.Notifier,
+		allowDummy: p.Environment.AllowsDummyLogin() && p.Config.DummyLogin,
 	}
 }
 
-func (uc *Usecase) DummyLogin(ctx context.Context, role *models.DummyLogin) (string, error) {
+func (uc *Usecase) DummyLogin(ctx context.Context, role *models.DummyLogin) (_ string, err error) {
 	const op = "auth.Usecase.DummyLogin"
-	logger := uc.log.With("op", op)
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if !uc.cfg.DummyLogin {
+		logger.Warn("dummy login is disabled")
+		return "", auth.ErrDummyLoginDisabled
+	}
 
 	if !role.Role.IsValid() {
 		logger.Error("invalid role: " + string(role.Role))
 		return "", auth.ErrIncorrectRole
 	}
 
-	tokenPayload := &models.TokenPayload{Role: role.Role}
+	tokenPayload := &models.TokenPayload{
+		Role:  role.Role,
+		Dummy: true,
+	}
 
 	token, err := uc.jwt.GenerateJWT(tokenPayload)
 	if err != nil && !errors.Is(err, jwter.ErrNoID) {
@@ -53,22 +83,47 @@ func (uc *Usecase) DummyLogin(ctx context.Context, role *models.DummyLogin) (str
 	return token.Token, nil
 }
 
-func (uc *Usecase) Login(ctx context.Context, userData *models.Users) (string, error) {
+func (uc *Usecase) Login(ctx context.Context, userData *models.Users, clientIP string) (_ string, err error) {
 	const op = "auth.Usecase.Login"
-	logger := uc.log.With("op", op)
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	userData.Email = validator.NormalizeEmail(userData.Email)
+
+	keys := loginAttemptKeys(userData.Email, clientIP)
+	if err := uc.checkLockout(ctx, keys); err != nil {
+		return "", err
+	}
 
 	user, err := uc.repo.GetUserByEmail(ctx, userData.Email)
 	if err != nil {
+		if errors.Is(err, auth.ErrUserNotFound) {
+			// only the ip is counted, so that login_attempts does not get
+			// a row for every email somebody made up
+			uc.loginFailed(ctx, keys[2:], "unknown_user")
+		}
 		return "", err
 	}
 	if !hasher.CompareStringHash(userData.Password, user.Password) {
 		logger.Error("passwords don't match")
+		uc.loginFailed(ctx, keys, "bad_password")
 		return "", auth.ErrIncorrectPasswordOrEmail
 	}
+	if user.Disabled {
+		logger.Warn("login of disabled user", "user_id", user.ID)
+		uc.metrics.FailedLoginsTotal("disabled")
+		return "", auth.ErrUserDisabled
+	}
+
+	if err = uc.repo.ResetLoginFailures(ctx, keys[0]); err != nil {
+		logger.Warn("failed to reset login failures: " + err.Error())
+	}
 
 	tokenPayload := &models.TokenPayload{
-		ID:   user.ID,
-		Role: user.Role,
+		ID:      user.ID,
+		Role:    user.Role,
+		Version: user.TokenVersion,
 	}
 	token, err := uc.jwt.GenerateJWT(tokenPayload)
 	if err != nil {
@@ -78,25 +133,42 @@ func (uc *Usecase) Login(ctx context.Context, userData *models.Users) (string, e
 	return token.Token, nil
 }
 
-func (uc *Usecase) Register(ctx context.Context, userData *models.Users) (string, error) {
+func (uc *Usecase) Register(ctx context.Context, userData *models.Users) (_ string, err error) {
 	const op = "auth.Usecase.Register"
-	logger := uc.log.With("op", op)
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if !uc.cfg.AllowRegistration {
+		logger.Warn("registration is disabled")
+		return "", auth.ErrRegistrationDisabled
+	}
 
+	if userData.Role == "" {
+		userData.Role = models.RoleEmployee
+	}
 	if !userData.Role.IsValid() {
 		logger.Error("invalid role: " + string(userData.Role))
 		return "", auth.ErrIncorrectRole
 	}
+	if userData.Role != models.RoleEmployee {
+		logger.Warn("self registration with role: " + string(userData.Role))
+		return "", auth.ErrSelfRegistrationRole
+	}
+	if err := uc.validateUser(userData); err != nil {
+		logger.Warn("invalid user data: " + err.Error())
+		return "", err
+	}
 
-	userData.ID = uuid.New()
-	userData.Password = hasher.GenerateHashString(userData.Password)
-	newUser, err := uc.repo.CreateUser(ctx, userData)
+	newUser, err := uc.createUser(ctx, userData)
 	if err != nil {
 		return "", err
 	}
 
 	tokenPayload := &models.TokenPayload{
-		ID:   newUser.ID,
-		Role: newUser.Role,
+		ID:      newUser.ID,
+		Role:    newUser.Role,
+		Version: newUser.TokenVersion,
 	}
 	token, err := uc.jwt.GenerateJWT(tokenPayload)
 	if err != nil {
@@ -105,3 +177,114 @@ func (uc *Usecase) Register(ctx context.Context, userData *models.Users) (string
 
 	return token.Token, nil
 }
+
+func (uc *Usecase) ListUsers(ctx context.Context, limit, page uint64) (_ []*models.UserInfo, err error) {
+	const op = "auth.Usecase.ListUsers"
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	users, err := uc.repo.ListUsers(ctx, limit, page)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*models.UserInfo, len(users))
+	for i := range users {
+		result[i] = users[i].Info()
+	}
+	return result, nil
+}
+
+func (uc *Usecase) GetUser(ctx context.Context, id uuid.UUID) (_ *models.UserInfo, err error) {
+	const op = "auth.Usecase.GetUser"
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	user, err := uc.repo.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return user.Info(), nil
+}
+
+func (uc *Usecase) CreateUser(ctx context.Context, userData *models.Users) (_ *models.UserInfo, err error) {
+	const op = "auth.Usecase.CreateUser"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if !userData.Role.IsValid() {
+		logger.Error("invalid role: " + string(userData.Role))
+		return nil, auth.ErrIncorrectRole
+	}
+	if err := uc.validateUser(userData); err != nil {
+		logger.Warn("invalid user data: " + err.Error())
+		return nil, err
+	}
+
+	newUser, err := uc.createUser(ctx, userData)
+	if err != nil {
+		return nil, err
+	}
+	return newUser.Info(), nil
+}
+
+func (uc *Usecase) ChangeRole(ctx context.Context, actorID, id uuid.UUID, role models.Role) (_ *models.UserInfo, err error) {
+	const op = "auth.Usecase.ChangeRole"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if !role.IsValid() {
+		logger.Error("invalid role: " + string(role))
+		return nil, auth.ErrIncorrectRole
+	}
+	if actorID == id {
+		logger.Warn("attempt to change own role")
+		return nil, auth.ErrCannotModifySelf
+	}
+
+	user, err := uc.repo.UpdateUserRole(ctx, id, role)
+	if err != nil {
+		return nil, err
+	}
+	return user.Info(), nil
+}
+
+func (uc *Usecase) SetUserDisabled(ctx context.Context, actorID, id uuid.UUID, disabled bool) (_ *models.UserInfo, err error) {
+	const op = "auth.Usecase.SetUserDisabled"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if actorID == id {
+		logger.Warn("attempt to disable own account")
+		return nil, auth.ErrCannotModifySelf
+	}
+
+	user, err := uc.repo.SetUserDisabled(ctx, id, disabled)
+	if err != nil {
+		return nil, err
+	}
+	return user.Info(), nil
+}
+
+func (uc *Usecase) DeleteUser(ctx context.Context, actorID, id uuid.UUID) (err error) {
+	const op = "auth.Usecase.DeleteUser"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if actorID == id {
+		logger.Warn("attempt to delete own account")
+		return auth.ErrCannotModifySelf
+	}
+
+	return uc.repo.DeleteUser(ctx, id)
+}
+
+func (uc *Usecase) createUser(ctx context.Context, userData *models.Users) (*models.Users, error) {
+	userData.ID = uuid.New()
+	userData.Password = hasher.GenerateHashString(userData.Password)
+	return uc.repo.CreateUser(ctx, userData)
+}
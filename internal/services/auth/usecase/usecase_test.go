@@ -5,12 +5,15 @@ import (
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	metricsMocks "github.com/marrgancovka/pvzService/internal/pkg/metrics/mocks"
 	"github.com/marrgancovka/pvzService/internal/services/auth/mocks"
 	"github.com/marrgancovka/pvzService/pkg/hasher"
+	"github.com/marrgancovka/pvzService/pkg/validator"
 	"go.uber.org/mock/gomock"
 	"log/slog"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/marrgancovka/pvzService/internal/models"
 	"github.com/marrgancovka/pvzService/internal/services/auth"
@@ -31,11 +34,13 @@ func TestUsecase_DummyLogin(t *testing.T) {
 		log:  log,
 		repo: mockRepo,
 		jwt:  mockJWT,
+		cfg:  auth.Config{DummyLogin: true},
 	}
 
 	tests := []struct {
 		name       string
 		role       *models.DummyLogin
+		disabled   bool
 		setupMocks func()
 		wantToken  string
 		wantErr    error
@@ -45,7 +50,7 @@ func TestUsecase_DummyLogin(t *testing.T) {
 			role: &models.DummyLogin{Role: models.RoleEmployee},
 			setupMocks: func() {
 				mockJWT.EXPECT().
-					GenerateJWT(&models.TokenPayload{Role: models.RoleEmployee}).
+					GenerateJWT(&models.TokenPayload{Role: models.RoleEmployee, Dummy: true}).
 					Return(&models.Token{Token: "testToken"}, nil)
 			},
 			wantToken: "testToken",
@@ -58,11 +63,20 @@ func TestUsecase_DummyLogin(t *testing.T) {
 			wantToken:  "",
 			wantErr:    auth.ErrIncorrectRole,
 		},
+		{
+			name:       "disabled",
+			role:       &models.DummyLogin{Role: models.RoleModerator},
+			disabled:   true,
+			setupMocks: func() {},
+			wantToken:  "",
+			wantErr:    auth.ErrDummyLoginDisabled,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMocks()
+			uc.cfg.DummyLogin = !tt.disabled
 
 			token, err := uc.DummyLogin(context.Background(), tt.role)
 
@@ -87,15 +101,28 @@ func TestUsecase_Login(t *testing.T) {
 
 	mockRepo := mocks.NewMockRepository(ctrl)
 	mockJWT := mocks.NewMockJWTer(ctrl)
+	mockMetrics := metricsMocks.NewMockMetrics(ctrl)
+	mockMetrics.EXPECT().FailedLoginsTotal(gomock.Any()).AnyTimes()
+	mockMetrics.EXPECT().LoginLockoutsTotal(gomock.Any()).AnyTimes()
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	}))
 	uc := Usecase{
-		log:  log,
-		repo: mockRepo,
-		jwt:  mockJWT,
+		log:     log,
+		repo:    mockRepo,
+		jwt:     mockJWT,
+		metrics: mockMetrics,
+		cfg: auth.Config{
+			Lockout: auth.LockoutConfig{MaxFailures: 3, MaxAccountFailures: 10, MaxIPFailures: 50, Window: time.Minute, Duration: time.Minute},
+		},
 	}
 
+	const clientIP = "192.0.2.1"
+	emailKey := models.LoginAttemptKey{Kind: models.LoginAttemptEmail, Subject: "test@example.com", ClientIP: clientIP}
+	accountKey := models.LoginAttemptKey{Kind: models.LoginAttemptAccount, Subject: "test@example.com"}
+	ipKey := models.LoginAttemptKey{Kind: models.LoginAttemptIP, Subject: clientIP}
+	lockedUntil := time.Now().Add(time.Minute)
+
 	tests := []struct {
 		name       string
 		userData   *models.Users
@@ -110,6 +137,7 @@ func TestUsecase_Login(t *testing.T) {
 				Password: "password",
 			},
 			setupMocks: func() {
+				mockRepo.EXPECT().GetLoginLockout(gomock.Any(), []models.LoginAttemptKey{emailKey, accountKey, ipKey}).Return(nil, nil)
 				mockRepo.EXPECT().
 					GetUserByEmail(gomock.Any(), "test@example.com").
 					Return(&models.Users{
@@ -118,6 +146,7 @@ func TestUsecase_Login(t *testing.T) {
 						ID:       uuid.New(),
 						Role:     "employee",
 					}, nil)
+				mockRepo.EXPECT().ResetLoginFailures(gomock.Any(), emailKey).Return(nil)
 				mockJWT.EXPECT().
 					GenerateJWT(gomock.Any()).
 					Return(&models.Token{Token: "testToken"}, nil)
@@ -132,9 +161,13 @@ func TestUsecase_Login(t *testing.T) {
 				Password: "password",
 			},
 			setupMocks: func() {
+				mockRepo.EXPECT().GetLoginLockout(gomock.Any(), gomock.Any()).Return(nil, nil)
 				mockRepo.EXPECT().
 					GetUserByEmail(gomock.Any(), "nonexist@example.com").
 					Return(nil, auth.ErrUserNotFound)
+				mockRepo.EXPECT().
+					RegisterLoginFailure(gomock.Any(), ipKey, time.Minute, 50, time.Minute).
+					Return(&models.LoginAttempts{Failures: 1}, nil)
 			},
 			wantToken: "",
 			wantErr:   auth.ErrUserNotFound,
@@ -146,23 +179,68 @@ func TestUsecase_Login(t *testing.T) {
 				Password: "wrong_password",
 			},
 			setupMocks: func() {
+				mockRepo.EXPECT().GetLoginLockout(gomock.Any(), gomock.Any()).Return(nil, nil)
 				mockRepo.EXPECT().
 					GetUserByEmail(gomock.Any(), "test@example.com").
 					Return(&models.Users{
 						Email:    "test@example.com",
 						Password: "hashed_password",
 					}, nil)
+				mockRepo.EXPECT().
+					RegisterLoginFailure(gomock.Any(), emailKey, time.Minute, 3, time.Minute).
+					Return(&models.LoginAttempts{Failures: 3, LockedUntil: &lockedUntil}, nil)
+				mockRepo.EXPECT().
+					RegisterLoginFailure(gomock.Any(), accountKey, time.Minute, 10, time.Minute).
+					Return(&models.LoginAttempts{Failures: 3}, nil)
+				mockRepo.EXPECT().
+					RegisterLoginFailure(gomock.Any(), ipKey, time.Minute, 50, time.Minute).
+					Return(&models.LoginAttempts{Failures: 3}, nil)
 			},
 			wantToken: "",
 			wantErr:   auth.ErrIncorrectPasswordOrEmail,
 		},
+		{
+			name: "disabled user",
+			userData: &models.Users{
+				Email:    "test@example.com",
+				Password: "password",
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().GetLoginLockout(gomock.Any(), gomock.Any()).Return(nil, nil)
+				mockRepo.EXPECT().
+					GetUserByEmail(gomock.Any(), "test@example.com").
+					Return(&models.Users{
+						Email:    "test@example.com",
+						Password: hasher.GenerateHashString("password"),
+						ID:       uuid.New(),
+						Role:     "employee",
+						Disabled: true,
+					}, nil)
+			},
+			wantToken: "",
+			wantErr:   auth.ErrUserDisabled,
+		},
+		{
+			name: "locked out",
+			userData: &models.Users{
+				Email:    "Test@Example.com",
+				Password: "password",
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().
+					GetLoginLockout(gomock.Any(), []models.LoginAttemptKey{emailKey, accountKey, ipKey}).
+					Return(&lockedUntil, nil)
+			},
+			wantToken: "",
+			wantErr:   auth.ErrTooManyLoginAttempts,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.setupMocks()
 
-			token, err := uc.Login(context.Background(), tt.userData)
+			token, err := uc.Login(context.Background(), tt.userData, clientIP)
 			fmt.Println(err)
 			assert.Equal(t, tt.wantToken, token)
 			if tt.wantErr != nil {
@@ -192,6 +270,7 @@ func TestUsecase_Register(t *testing.T) {
 		log:  log,
 		repo: mockRepo,
 		jwt:  mockJWT,
+		cfg:  auth.Config{AllowRegistration: true},
 	}
 
 	userID := uuid.New()
@@ -255,6 +334,17 @@ func TestUsecase_Register(t *testing.T) {
 			wantToken: "",
 			wantErr:   auth.ErrUserAlreadyExists,
 		},
+		{
+			name: "self registration as moderator",
+			userData: &models.Users{
+				Email:    "example@example.com",
+				Password: "password",
+				Role:     "moderator",
+			},
+			setupMocks: func() {},
+			wantToken:  "",
+			wantErr:    auth.ErrSelfRegistrationRole,
+		},
 	}
 
 	for _, tt := range tests {
@@ -279,3 +369,189 @@ func TestUsecase_Register(t *testing.T) {
 		})
 	}
 }
+
+func TestUsecase_RegisterValidation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockJWT := mocks.NewMockJWTer(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:  log,
+		repo: mockRepo,
+		jwt:  mockJWT,
+		cfg: auth.Config{
+			AllowRegistration: true,
+			Password: validator.PasswordPolicy{
+				MinLength:    8,
+				RequireUpper: true,
+				RequireLower: true,
+				RequireDigit: true,
+				DenyCommon:   true,
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		userData   *models.Users
+		setupMocks func()
+		wantErrs   validator.Errors
+	}{
+		{
+			name: "email is normalized",
+			userData: &models.Users{
+				Email:    "  Example@Example.COM ",
+				Password: "Corr3ctHorse",
+			},
+			setupMocks: func() {
+				mockRepo.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, user *models.Users) (*models.Users, error) {
+						assert.Equal(t, "example@example.com", user.Email)
+						return user, nil
+					})
+				mockJWT.EXPECT().GenerateJWT(gomock.Any()).Return(&models.Token{Token: "testToken"}, nil)
+			},
+		},
+		{
+			name: "invalid email",
+			userData: &models.Users{
+				Email:    "x",
+				Password: "Corr3ctHorse",
+			},
+			wantErrs: validator.Errors{{Field: "email", Msg: "is not a valid email address"}},
+		},
+		{
+			name: "weak password",
+			userData: &models.Users{
+				Email:    "example@example.com",
+				Password: "a",
+			},
+			wantErrs: validator.Errors{
+				{Field: "password", Msg: "must be at least 8 characters long"},
+				{Field: "password", Msg: "must contain an uppercase letter"},
+				{Field: "password", Msg: "must contain a digit"},
+			},
+		},
+		{
+			name: "common password",
+			userData: &models.Users{
+				Email:    "example@example.com",
+				Password: "Password123",
+			},
+			wantErrs: validator.Errors{{Field: "password", Msg: "is too common"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.setupMocks != nil {
+				tt.setupMocks()
+			}
+
+			_, err := uc.Register(context.Background(), tt.userData)
+			if tt.wantErrs == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var errs validator.Errors
+			assert.True(t, errors.As(err, &errs))
+			assert.Equal(t, tt.wantErrs, errs)
+		})
+	}
+}
+
+func TestUsecase_RegisterDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:  log,
+		repo: mocks.NewMockRepository(ctrl),
+		jwt:  mocks.NewMockJWTer(ctrl),
+		cfg:  auth.Config{AllowRegistration: false},
+	}
+
+	token, err := uc.Register(context.Background(), &models.Users{
+		Email:    "example@example.com",
+		Password: "password",
+	})
+
+	assert.Empty(t, token)
+	assert.ErrorIs(t, err, auth.ErrRegistrationDisabled)
+}
+
+func TestUsecase_ChangeRoleSelf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+	uc := Usecase{
+		log:  log,
+		repo: mocks.NewMockRepository(ctrl),
+		jwt:  mocks.NewMockJWTer(ctrl),
+	}
+
+	actorID := uuid.New()
+	_, err := uc.ChangeRole(context.Background(), actorID, actorID, models.RoleEmployee)
+
+	assert.ErrorIs(t, err, auth.ErrCannotModifySelf)
+}
+
+func TestUsecase_LoginFailedAcrossClients(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockMetrics := metricsMocks.NewMockMetrics(ctrl)
+	uc := Usecase{
+		log:     slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		repo:    mockRepo,
+		metrics: mockMetrics,
+		cfg: auth.Config{
+			Lockout: auth.LockoutConfig{MaxFailures: 3, MaxAccountFailures: 10, MaxIPFailures: 50, Window: time.Minute, Duration: time.Minute},
+		},
+	}
+
+	// the tenth guess, each from another client: only the account counter
+	// reaches its threshold
+	keys := loginAttemptKeys("test@example.com", "192.0.2.10")
+	lockedUntil := time.Now().Add(time.Minute)
+	mockRepo.EXPECT().RegisterLoginFailure(gomock.Any(), keys[0], time.Minute, 3, time.Minute).
+		Return(&models.LoginAttempts{Failures: 1}, nil)
+	mockRepo.EXPECT().RegisterLoginFailure(gomock.Any(), keys[1], time.Minute, 10, time.Minute).
+		Return(&models.LoginAttempts{Failures: 10, LockedUntil: &lockedUntil}, nil)
+	mockRepo.EXPECT().RegisterLoginFailure(gomock.Any(), keys[2], time.Minute, 50, time.Minute).
+		Return(&models.LoginAttempts{Failures: 1}, nil)
+	mockMetrics.EXPECT().FailedLoginsTotal("bad_password")
+	mockMetrics.EXPECT().LoginLockoutsTotal(string(models.LoginAttemptAccount))
+
+	uc.loginFailed(context.Background(), keys, "bad_password")
+}
+
+func TestFailureDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{failures: 0, want: 0},
+		{failures: 1, want: 100 * time.Millisecond},
+		{failures: 2, want: 200 * time.Millisecond},
+		{failures: 4, want: 800 * time.Millisecond},
+		{failures: 10, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, failureDelay(100*time.Millisecond, time.Second, tt.failures))
+	}
+}
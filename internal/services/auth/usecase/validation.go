@@ -0,0 +1,29 @@
+package usecase
+
+import (
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/pkg/validator"
+)
+
+// validateUser normalizes the email of userData in place and checks it
+// together with the password against the configured policy.
+func (uc *Usecase) validateUser(userData *models.Users) error {
+	userData.Email = validator.NormalizeEmail(userData.Email)
+
+	var errs validator.Errors
+	if msg := validator.ValidateEmail(userData.Email); msg != "" {
+		errs.Add("email", msg)
+	}
+	for _, msg := range uc.cfg.Password.Validate(userData.Password) {
+		errs.Add("password", msg)
+	}
+	return errs.Err()
+}
+
+func (uc *Usecase) validatePassword(field, password string) error {
+	var errs validator.Errors
+	for _, msg := range uc.cfg.Password.Validate(password) {
+		errs.Add(field, msg)
+	}
+	return errs.Err()
+}
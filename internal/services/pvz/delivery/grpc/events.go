@@ -0,0 +1,85 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/services/pvz"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var eventTypes = map[models.PvzEventType]gen.PVZEventType{
+	models.EventReceptionOpened: gen.PVZEventType_PVZ_EVENT_TYPE_RECEPTION_OPENED,
+	models.EventReceptionClosed: gen.PVZEventType_PVZ_EVENT_TYPE_RECEPTION_CLOSED,
+	models.EventProductAdded:    gen.PVZEventType_PVZ_EVENT_TYPE_PRODUCT_ADDED,
+	models.EventProductRemoved:  gen.PVZEventType_PVZ_EVENT_TYPE_PRODUCT_REMOVED,
+}
+
+func (h *Handler) WatchPVZEvents(req *gen.WatchPVZEventsRequest, stream grpc.ServerStreamingServer[gen.PVZEvent]) error {
+	const op = "grpc.pvz.Handler.WatchPVZEvents"
+	logger := logctx.From(stream.Context(), h.logger).With("op", op)
+
+	filter := &models.PvzEventFilter{AfterID: req.GetLastEventId()}
+	for _, id := range req.GetPvzIds() {
+		pvzID, err := uuid.Parse(id)
+		if err != nil {
+			return status.Errorf(codes.InvalidArgument, "%s", pvz.ErrBadRequest.Error())
+		}
+		if !middleware.CanAccessPvz(stream.Context(), pvzID) {
+			logger.Warn("pvz denied", "pvz_id", pvzID)
+			return status.Errorf(codes.PermissionDenied, "%s", pvz.ErrNoAccess.Error())
+		}
+		filter.PvzIDs = append(filter.PvzIDs, pvzID)
+	}
+	if len(filter.PvzIDs) == 0 {
+		// restricted callers watch all of their pvz by default
+		filter.PvzIDs = middleware.AccessiblePvzIDs(stream.Context())
+	}
+	for _, city := range req.GetCities() {
+		filter.Cities = append(filter.Cities, models.City(city))
+	}
+
+	logger.Info("watcher connected", "pvz_ids", len(filter.PvzIDs), "cities", len(filter.Cities), "last_event_id", filter.AfterID)
+
+	err := h.usecase.WatchEvents(stream.Context(), filter, func(event *models.PvzEvent) error {
+		return stream.Send(convertEvent(event))
+	})
+	switch {
+	case errors.Is(err, context.Canceled):
+		logger.Info("watcher disconnected")
+		return nil
+	case errors.Is(err, pvz.ErrInaccessibleCity):
+		return status.Errorf(codes.InvalidArgument, "%s", err.Error())
+	case errors.Is(err, pvz.ErrEventStreamLagged):
+		return status.Errorf(codes.Unavailable, "%s", err.Error())
+	case err != nil:
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Errorf(codes.Internal, "%s", err.Error())
+	}
+	return nil
+}
+
+func convertEvent(event *models.PvzEvent) *gen.PVZEvent {
+	converted := &gen.PVZEvent{
+		Id:          event.ID,
+		Type:        eventTypes[event.Type],
+		PvzId:       event.PvzID.String(),
+		City:        string(event.City),
+		ReceptionId: event.ReceptionID.String(),
+		ProductType: string(event.ProductType),
+		CreatedAt:   timestamppb.New(event.CreatedAt),
+	}
+	if event.ProductID != nil {
+		converted.ProductId = event.ProductID.String()
+	}
+	return converted
+}
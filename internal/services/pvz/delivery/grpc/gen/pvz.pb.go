@@ -7,6 +7,7 @@
 package gen
 
 import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
@@ -68,11 +69,67 @@ func (ReceptionStatus) EnumDescriptor() ([]byte, []int) {
 	return file_pvz_proto_rawDescGZIP(), []int{0}
 }
 
+type PVZEventType int32
+
+const (
+	PVZEventType_PVZ_EVENT_TYPE_UNSPECIFIED      PVZEventType = 0
+	PVZEventType_PVZ_EVENT_TYPE_RECEPTION_OPENED PVZEventType = 1
+	PVZEventType_PVZ_EVENT_TYPE_RECEPTION_CLOSED PVZEventType = 2
+	PVZEventType_PVZ_EVENT_TYPE_PRODUCT_ADDED    PVZEventType = 3
+	PVZEventType_PVZ_EVENT_TYPE_PRODUCT_REMOVED  PVZEventType = 4
+)
+
+// Enum value maps for PVZEventType.
+var (
+	PVZEventType_name = map[int32]string{
+		0: "PVZ_EVENT_TYPE_UNSPECIFIED",
+		1: "PVZ_EVENT_TYPE_RECEPTION_OPENED",
+		2: "PVZ_EVENT_TYPE_RECEPTION_CLOSED",
+		3: "PVZ_EVENT_TYPE_PRODUCT_ADDED",
+		4: "PVZ_EVENT_TYPE_PRODUCT_REMOVED",
+	}
+	PVZEventType_value = map[string]int32{
+		"PVZ_EVENT_TYPE_UNSPECIFIED":      0,
+		"PVZ_EVENT_TYPE_RECEPTION_OPENED": 1,
+		"PVZ_EVENT_TYPE_RECEPTION_CLOSED": 2,
+		"PVZ_EVENT_TYPE_PRODUCT_ADDED":    3,
+		"PVZ_EVENT_TYPE_PRODUCT_REMOVED":  4,
+	}
+)
+
+func (x PVZEventType) Enum() *PVZEventType {
+	p := new(PVZEventType)
+	*p = x
+	return p
+}
+
+func (x PVZEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (PVZEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_pvz_proto_enumTypes[1].Descriptor()
+}
+
+func (PVZEventType) Type() protoreflect.EnumType {
+	return &file_pvz_proto_enumTypes[1]
+}
+
+func (x PVZEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use PVZEventType.Descriptor instead.
+func (PVZEventType) EnumDescriptor() ([]byte, []int) {
+	return file_pvz_proto_rawDescGZIP(), []int{1}
+}
+
 type PVZ struct {
 	state            protoimpl.MessageState `protogen:"open.v1"`
 	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
 	RegistrationDate *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=registration_date,json=registrationDate,proto3" json:"registration_date,omitempty"`
 	City             string                 `protobuf:"bytes,3,opt,name=city,proto3" json:"city,omitempty"`
+	Address          string                 `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`
 	unknownFields    protoimpl.UnknownFields
 	sizeCache        protoimpl.SizeCache
 }
@@ -128,6 +185,13 @@ func (x *PVZ) GetCity() string {
 	return ""
 }
 
+func (x *PVZ) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
 type GetPVZListRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -208,25 +272,457 @@ func (x *GetPVZListResponse) GetPvzs() []*PVZ {
 	return nil
 }
 
+type GetStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartDate     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start_date,json=startDate,proto3" json:"start_date,omitempty"`
+	EndDate       *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=end_date,json=endDate,proto3" json:"end_date,omitempty"`
+	Period        string                 `protobuf:"bytes,3,opt,name=period,proto3" json:"period,omitempty"`
+	GroupBy       []string               `protobuf:"bytes,4,rep,name=group_by,json=groupBy,proto3" json:"group_by,omitempty"`
+	City          string                 `protobuf:"bytes,5,opt,name=city,proto3" json:"city,omitempty"`
+	PvzId         string                 `protobuf:"bytes,6,opt,name=pvz_id,json=pvzId,proto3" json:"pvz_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_pvz_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pvz_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_pvz_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetStatsRequest) GetStartDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartDate
+	}
+	return nil
+}
+
+func (x *GetStatsRequest) GetEndDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndDate
+	}
+	return nil
+}
+
+func (x *GetStatsRequest) GetPeriod() string {
+	if x != nil {
+		return x.Period
+	}
+	return ""
+}
+
+func (x *GetStatsRequest) GetGroupBy() []string {
+	if x != nil {
+		return x.GroupBy
+	}
+	return nil
+}
+
+func (x *GetStatsRequest) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *GetStatsRequest) GetPvzId() string {
+	if x != nil {
+		return x.PvzId
+	}
+	return ""
+}
+
+type StatsRow struct {
+	state                       protoimpl.MessageState `protogen:"open.v1"`
+	Period                      *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=period,proto3" json:"period,omitempty"`
+	PvzId                       string                 `protobuf:"bytes,2,opt,name=pvz_id,json=pvzId,proto3" json:"pvz_id,omitempty"`
+	City                        string                 `protobuf:"bytes,3,opt,name=city,proto3" json:"city,omitempty"`
+	ProductType                 string                 `protobuf:"bytes,4,opt,name=product_type,json=productType,proto3" json:"product_type,omitempty"`
+	ReceptionsCount             int64                  `protobuf:"varint,5,opt,name=receptions_count,json=receptionsCount,proto3" json:"receptions_count,omitempty"`
+	ProductsCount               int64                  `protobuf:"varint,6,opt,name=products_count,json=productsCount,proto3" json:"products_count,omitempty"`
+	AvgReceptionDurationSeconds *float64               `protobuf:"fixed64,7,opt,name=avg_reception_duration_seconds,json=avgReceptionDurationSeconds,proto3,oneof" json:"avg_reception_duration_seconds,omitempty"`
+	ProductsPerReception        float64                `protobuf:"fixed64,8,opt,name=products_per_reception,json=productsPerReception,proto3" json:"products_per_reception,omitempty"`
+	unknownFields               protoimpl.UnknownFields
+	sizeCache                   protoimpl.SizeCache
+}
+
+func (x *StatsRow) Reset() {
+	*x = StatsRow{}
+	mi := &file_pvz_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatsRow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatsRow) ProtoMessage() {}
+
+func (x *StatsRow) ProtoReflect() protoreflect.Message {
+	mi := &file_pvz_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatsRow.ProtoReflect.Descriptor instead.
+func (*StatsRow) Descriptor() ([]byte, []int) {
+	return file_pvz_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StatsRow) GetPeriod() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Period
+	}
+	return nil
+}
+
+func (x *StatsRow) GetPvzId() string {
+	if x != nil {
+		return x.PvzId
+	}
+	return ""
+}
+
+func (x *StatsRow) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *StatsRow) GetProductType() string {
+	if x != nil {
+		return x.ProductType
+	}
+	return ""
+}
+
+func (x *StatsRow) GetReceptionsCount() int64 {
+	if x != nil {
+		return x.ReceptionsCount
+	}
+	return 0
+}
+
+func (x *StatsRow) GetProductsCount() int64 {
+	if x != nil {
+		return x.ProductsCount
+	}
+	return 0
+}
+
+func (x *StatsRow) GetAvgReceptionDurationSeconds() float64 {
+	if x != nil && x.AvgReceptionDurationSeconds != nil {
+		return *x.AvgReceptionDurationSeconds
+	}
+	return 0
+}
+
+func (x *StatsRow) GetProductsPerReception() float64 {
+	if x != nil {
+		return x.ProductsPerReception
+	}
+	return 0
+}
+
+type GetStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rows          []*StatsRow            `protobuf:"bytes,1,rep,name=rows,proto3" json:"rows,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_pvz_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pvz_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_pvz_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetStatsResponse) GetRows() []*StatsRow {
+	if x != nil {
+		return x.Rows
+	}
+	return nil
+}
+
+type WatchPVZEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PvzIds        []string               `protobuf:"bytes,1,rep,name=pvz_ids,json=pvzIds,proto3" json:"pvz_ids,omitempty"`
+	Cities        []string               `protobuf:"bytes,2,rep,name=cities,proto3" json:"cities,omitempty"`
+	LastEventId   int64                  `protobuf:"varint,3,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchPVZEventsRequest) Reset() {
+	*x = WatchPVZEventsRequest{}
+	mi := &file_pvz_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchPVZEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchPVZEventsRequest) ProtoMessage() {}
+
+func (x *WatchPVZEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pvz_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchPVZEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchPVZEventsRequest) Descriptor() ([]byte, []int) {
+	return file_pvz_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WatchPVZEventsRequest) GetPvzIds() []string {
+	if x != nil {
+		return x.PvzIds
+	}
+	return nil
+}
+
+func (x *WatchPVZEventsRequest) GetCities() []string {
+	if x != nil {
+		return x.Cities
+	}
+	return nil
+}
+
+func (x *WatchPVZEventsRequest) GetLastEventId() int64 {
+	if x != nil {
+		return x.LastEventId
+	}
+	return 0
+}
+
+type PVZEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          PVZEventType           `protobuf:"varint,2,opt,name=type,proto3,enum=pvz.v1.PVZEventType" json:"type,omitempty"`
+	PvzId         string                 `protobuf:"bytes,3,opt,name=pvz_id,json=pvzId,proto3" json:"pvz_id,omitempty"`
+	City          string                 `protobuf:"bytes,4,opt,name=city,proto3" json:"city,omitempty"`
+	ReceptionId   string                 `protobuf:"bytes,5,opt,name=reception_id,json=receptionId,proto3" json:"reception_id,omitempty"`
+	ProductId     string                 `protobuf:"bytes,6,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	ProductType   string                 `protobuf:"bytes,7,opt,name=product_type,json=productType,proto3" json:"product_type,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PVZEvent) Reset() {
+	*x = PVZEvent{}
+	mi := &file_pvz_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PVZEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PVZEvent) ProtoMessage() {}
+
+func (x *PVZEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pvz_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PVZEvent.ProtoReflect.Descriptor instead.
+func (*PVZEvent) Descriptor() ([]byte, []int) {
+	return file_pvz_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *PVZEvent) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PVZEvent) GetType() PVZEventType {
+	if x != nil {
+		return x.Type
+	}
+	return PVZEventType_PVZ_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *PVZEvent) GetPvzId() string {
+	if x != nil {
+		return x.PvzId
+	}
+	return ""
+}
+
+func (x *PVZEvent) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *PVZEvent) GetReceptionId() string {
+	if x != nil {
+		return x.ReceptionId
+	}
+	return ""
+}
+
+func (x *PVZEvent) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *PVZEvent) GetProductType() string {
+	if x != nil {
+		return x.ProductType
+	}
+	return ""
+}
+
+func (x *PVZEvent) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
 var File_pvz_proto protoreflect.FileDescriptor
 
 const file_pvz_proto_rawDesc = "" +
 	"\n" +
-	"\tpvz.proto\x12\x06pvz.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"r\n" +
+	"\tpvz.proto\x12\x06pvz.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8c\x01\n" +
 	"\x03PVZ\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12G\n" +
 	"\x11registration_date\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x10registrationDate\x12\x12\n" +
-	"\x04city\x18\x03 \x01(\tR\x04city\"\x13\n" +
+	"\x04city\x18\x03 \x01(\tR\x04city\x12\x18\n" +
+	"\aaddress\x18\x04 \x01(\tR\aaddress\"\x13\n" +
 	"\x11GetPVZListRequest\"5\n" +
 	"\x12GetPVZListResponse\x12\x1f\n" +
-	"\x04pvzs\x18\x01 \x03(\v2\v.pvz.v1.PVZR\x04pvzs*P\n" +
+	"\x04pvzs\x18\x01 \x03(\v2\v.pvz.v1.PVZR\x04pvzs\"\xe1\x01\n" +
+	"\x0fGetStatsRequest\x129\n" +
+	"\n" +
+	"start_date\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\tstartDate\x125\n" +
+	"\bend_date\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\aendDate\x12\x16\n" +
+	"\x06period\x18\x03 \x01(\tR\x06period\x12\x19\n" +
+	"\bgroup_by\x18\x04 \x03(\tR\agroupBy\x12\x12\n" +
+	"\x04city\x18\x05 \x01(\tR\x04city\x12\x15\n" +
+	"\x06pvz_id\x18\x06 \x01(\tR\x05pvzId\"\x81\x03\n" +
+	"\bStatsRow\x122\n" +
+	"\x06period\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x06period\x12\x15\n" +
+	"\x06pvz_id\x18\x02 \x01(\tR\x05pvzId\x12\x12\n" +
+	"\x04city\x18\x03 \x01(\tR\x04city\x12!\n" +
+	"\fproduct_type\x18\x04 \x01(\tR\vproductType\x12)\n" +
+	"\x10receptions_count\x18\x05 \x01(\x03R\x0freceptionsCount\x12%\n" +
+	"\x0eproducts_count\x18\x06 \x01(\x03R\rproductsCount\x12H\n" +
+	"\x1eavg_reception_duration_seconds\x18\a \x01(\x01H\x00R\x1bavgReceptionDurationSeconds\x88\x01\x01\x124\n" +
+	"\x16products_per_reception\x18\b \x01(\x01R\x14productsPerReceptionB!\n" +
+	"\x1f_avg_reception_duration_seconds\"8\n" +
+	"\x10GetStatsResponse\x12$\n" +
+	"\x04rows\x18\x01 \x03(\v2\x10.pvz.v1.StatsRowR\x04rows\"l\n" +
+	"\x15WatchPVZEventsRequest\x12\x17\n" +
+	"\apvz_ids\x18\x01 \x03(\tR\x06pvzIds\x12\x16\n" +
+	"\x06cities\x18\x02 \x03(\tR\x06cities\x12\"\n" +
+	"\rlast_event_id\x18\x03 \x01(\x03R\vlastEventId\"\x8f\x02\n" +
+	"\bPVZEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12(\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x14.pvz.v1.PVZEventTypeR\x04type\x12\x15\n" +
+	"\x06pvz_id\x18\x03 \x01(\tR\x05pvzId\x12\x12\n" +
+	"\x04city\x18\x04 \x01(\tR\x04city\x12!\n" +
+	"\freception_id\x18\x05 \x01(\tR\vreceptionId\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x06 \x01(\tR\tproductId\x12!\n" +
+	"\fproduct_type\x18\a \x01(\tR\vproductType\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt*P\n" +
 	"\x0fReceptionStatus\x12 \n" +
 	"\x1cRECEPTION_STATUS_IN_PROGRESS\x10\x00\x12\x1b\n" +
-	"\x17RECEPTION_STATUS_CLOSED\x10\x012Q\n" +
+	"\x17RECEPTION_STATUS_CLOSED\x10\x01*\xbe\x01\n" +
+	"\fPVZEventType\x12\x1e\n" +
+	"\x1aPVZ_EVENT_TYPE_UNSPECIFIED\x10\x00\x12#\n" +
+	"\x1fPVZ_EVENT_TYPE_RECEPTION_OPENED\x10\x01\x12#\n" +
+	"\x1fPVZ_EVENT_TYPE_RECEPTION_CLOSED\x10\x02\x12 \n" +
+	"\x1cPVZ_EVENT_TYPE_PRODUCT_ADDED\x10\x03\x12\"\n" +
+	"\x1ePVZ_EVENT_TYPE_PRODUCT_REMOVED\x10\x042\xb1\x02\n" +
 	"\n" +
-	"PVZService\x12C\n" +
+	"PVZService\x12`\n" +
 	"\n" +
-	"GetPVZList\x12\x19.pvz.v1.GetPVZListRequest\x1a\x1a.pvz.v1.GetPVZListResponseB0Z../internal/services/pvz/delivery/grpc/gen/;genb\x06proto3"
+	"GetPVZList\x12\x19.pvz.v1.GetPVZListRequest\x1a\x1a.pvz.v1.GetPVZListResponse\"\x1b\x82\xd3\xe4\x93\x02\x15\x12\x13/api/gateway/v1/pvz\x12\\\n" +
+	"\bGetStats\x12\x17.pvz.v1.GetStatsRequest\x1a\x18.pvz.v1.GetStatsResponse\"\x1d\x82\xd3\xe4\x93\x02\x17\x12\x15/api/gateway/v1/stats\x12c\n" +
+	"\x0eWatchPVZEvents\x12\x1d.pvz.v1.WatchPVZEventsRequest\x1a\x10.pvz.v1.PVZEvent\"\x1e\x82\xd3\xe4\x93\x02\x18\x12\x16/api/gateway/v1/events0\x01B0Z../internal/services/pvz/delivery/grpc/gen/;genb\x06proto3"
 
 var (
 	file_pvz_proto_rawDescOnce sync.Once
@@ -240,25 +736,41 @@ func file_pvz_proto_rawDescGZIP() []byte {
 	return file_pvz_proto_rawDescData
 }
 
-var file_pvz_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_pvz_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_pvz_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_pvz_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_pvz_proto_goTypes = []any{
 	(ReceptionStatus)(0),          // 0: pvz.v1.ReceptionStatus
-	(*PVZ)(nil),                   // 1: pvz.v1.PVZ
-	(*GetPVZListRequest)(nil),     // 2: pvz.v1.GetPVZListRequest
-	(*GetPVZListResponse)(nil),    // 3: pvz.v1.GetPVZListResponse
-	(*timestamppb.Timestamp)(nil), // 4: google.protobuf.Timestamp
+	(PVZEventType)(0),             // 1: pvz.v1.PVZEventType
+	(*PVZ)(nil),                   // 2: pvz.v1.PVZ
+	(*GetPVZListRequest)(nil),     // 3: pvz.v1.GetPVZListRequest
+	(*GetPVZListResponse)(nil),    // 4: pvz.v1.GetPVZListResponse
+	(*GetStatsRequest)(nil),       // 5: pvz.v1.GetStatsRequest
+	(*StatsRow)(nil),              // 6: pvz.v1.StatsRow
+	(*GetStatsResponse)(nil),      // 7: pvz.v1.GetStatsResponse
+	(*WatchPVZEventsRequest)(nil), // 8: pvz.v1.WatchPVZEventsRequest
+	(*PVZEvent)(nil),              // 9: pvz.v1.PVZEvent
+	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
 }
 var file_pvz_proto_depIdxs = []int32{
-	4, // 0: pvz.v1.PVZ.registration_date:type_name -> google.protobuf.Timestamp
-	1, // 1: pvz.v1.GetPVZListResponse.pvzs:type_name -> pvz.v1.PVZ
-	2, // 2: pvz.v1.PVZService.GetPVZList:input_type -> pvz.v1.GetPVZListRequest
-	3, // 3: pvz.v1.PVZService.GetPVZList:output_type -> pvz.v1.GetPVZListResponse
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	10, // 0: pvz.v1.PVZ.registration_date:type_name -> google.protobuf.Timestamp
+	2,  // 1: pvz.v1.GetPVZListResponse.pvzs:type_name -> pvz.v1.PVZ
+	10, // 2: pvz.v1.GetStatsRequest.start_date:type_name -> google.protobuf.Timestamp
+	10, // 3: pvz.v1.GetStatsRequest.end_date:type_name -> google.protobuf.Timestamp
+	10, // 4: pvz.v1.StatsRow.period:type_name -> google.protobuf.Timestamp
+	6,  // 5: pvz.v1.GetStatsResponse.rows:type_name -> pvz.v1.StatsRow
+	1,  // 6: pvz.v1.PVZEvent.type:type_name -> pvz.v1.PVZEventType
+	10, // 7: pvz.v1.PVZEvent.created_at:type_name -> google.protobuf.Timestamp
+	3,  // 8: pvz.v1.PVZService.GetPVZList:input_type -> pvz.v1.GetPVZListRequest
+	5,  // 9: pvz.v1.PVZService.GetStats:input_type -> pvz.v1.GetStatsRequest
+	8,  // 10: pvz.v1.PVZService.WatchPVZEvents:input_type -> pvz.v1.WatchPVZEventsRequest
+	4,  // 11: pvz.v1.PVZService.GetPVZList:output_type -> pvz.v1.GetPVZListResponse
+	7,  // 12: pvz.v1.PVZService.GetStats:output_type -> pvz.v1.GetStatsResponse
+	9,  // 13: pvz.v1.PVZService.WatchPVZEvents:output_type -> pvz.v1.PVZEvent
+	11, // [11:14] is the sub-list for method output_type
+	8,  // [8:11] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
 }
 
 func init() { file_pvz_proto_init() }
@@ -266,13 +778,14 @@ func file_pvz_proto_init() {
 	if File_pvz_proto != nil {
 		return
 	}
+	file_pvz_proto_msgTypes[4].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pvz_proto_rawDesc), len(file_pvz_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   3,
+			NumEnums:      2,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -2,7 +2,11 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"github.com/google/uuid"
 	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
 	"github.com/marrgancovka/pvzService/internal/services/pvz"
 	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
 	"go.uber.org/fx"
@@ -10,6 +14,7 @@ import (
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"log/slog"
+	"time"
 )
 
 type Params struct {
@@ -35,9 +40,9 @@ func NewHandler(params Params) *Handler {
 
 func (h *Handler) GetPVZList(ctx context.Context, _ *gen.GetPVZListRequest) (*gen.GetPVZListResponse, error) {
 	const op = "grpc.pvz.Handler.GetPVZList"
-	h.logger = h.logger.With("op", op)
+	logger := logctx.From(ctx, h.logger).With("op", op)
 
-	results, err := h.usecase.GetPvzList(ctx)
+	results, err := h.usecase.GetPvzList(ctx, middleware.AccessiblePvzIDs(ctx))
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "%s", err.Error())
 	}
@@ -47,14 +52,85 @@ func (h *Handler) GetPVZList(ctx context.Context, _ *gen.GetPVZListRequest) (*ge
 		converted[i] = convert(results[i])
 	}
 
-	h.logger.Info("success get pvz list", "result", converted)
+	logger.Info("success get pvz list", "result", converted)
 	return &gen.GetPVZListResponse{Pvzs: converted}, nil
 }
 
+func (h *Handler) GetStats(ctx context.Context, req *gen.GetStatsRequest) (*gen.GetStatsResponse, error) {
+	const op = "grpc.pvz.Handler.GetStats"
+	logger := logctx.From(ctx, h.logger).With("op", op)
+
+	filter := &models.StatsFilter{
+		StartDate: time.Now().AddDate(0, -1, 0),
+		EndDate:   time.Now(),
+		Period:    models.StatsPeriod(req.GetPeriod()),
+		City:      models.City(req.GetCity()),
+	}
+	if req.GetStartDate() != nil {
+		filter.StartDate = req.GetStartDate().AsTime()
+	}
+	if req.GetEndDate() != nil {
+		filter.EndDate = req.GetEndDate().AsTime()
+	}
+	if req.GetPvzId() != "" {
+		pvzID, err := uuid.Parse(req.GetPvzId())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "%s", pvz.ErrBadRequest.Error())
+		}
+		if !middleware.CanAccessPvz(ctx, pvzID) {
+			logger.Warn("pvz denied", "pvz_id", pvzID)
+			return nil, status.Errorf(codes.PermissionDenied, "%s", pvz.ErrNoAccess.Error())
+		}
+		filter.PvzID = pvzID
+	}
+	filter.PvzIDs = middleware.AccessiblePvzIDs(ctx)
+	for _, group := range req.GetGroupBy() {
+		filter.GroupBy = append(filter.GroupBy, models.StatsGroup(group))
+	}
+
+	results, err := h.usecase.GetStats(ctx, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, pvz.ErrInvalidStatsPeriod),
+			errors.Is(err, pvz.ErrInvalidStatsGroup),
+			errors.Is(err, pvz.ErrInvalidDateRange),
+			errors.Is(err, pvz.ErrInaccessibleCity):
+			return nil, status.Errorf(codes.InvalidArgument, "%s", err.Error())
+		default:
+			return nil, status.Errorf(codes.Internal, "%s", err.Error())
+		}
+	}
+
+	rows := make([]*gen.StatsRow, len(results))
+	for i := range results {
+		rows[i] = convertStatsRow(results[i])
+	}
+
+	logger.Info("success get stats", "rows", len(rows))
+	return &gen.GetStatsResponse{Rows: rows}, nil
+}
+
 func convert(pvz *models.Pvz) *gen.PVZ {
 	return &gen.PVZ{
 		Id:               pvz.ID.String(),
 		RegistrationDate: timestamppb.New(pvz.RegistrationDate),
 		City:             string(pvz.City),
+		Address:          pvz.Address,
+	}
+}
+
+func convertStatsRow(row *models.StatsRow) *gen.StatsRow {
+	converted := &gen.StatsRow{
+		Period:                      timestamppb.New(row.Period),
+		City:                        string(row.City),
+		ProductType:                 string(row.ProductType),
+		ReceptionsCount:             row.ReceptionsCount,
+		ProductsCount:               row.ProductsCount,
+		AvgReceptionDurationSeconds: row.AvgReceptionDurationSeconds,
+		ProductsPerReception:        row.ProductsPerReception,
+	}
+	if row.PvzID != nil {
+		converted.PvzId = row.PvzID.String()
 	}
+	return converted
 }
@@ -0,0 +1,15 @@
+package grpc
+
+import (
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+)
+
+// Permissions maps the RPCs to the permission a caller needs, the same one
+// as for the matching HTTP route. RPCs that are missing here are rejected,
+// so a new RPC has to be added before it is reachable.
+var Permissions = map[string]rbac.Permission{
+	gen.PVZService_GetPVZList_FullMethodName:     rbac.PvzRead,
+	gen.PVZService_GetStats_FullMethodName:       rbac.StatsRead,
+	gen.PVZService_WatchPVZEvents_FullMethodName: rbac.ReceptionRead,
+}
@@ -0,0 +1,95 @@
+package http
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_RestrictedPrincipal(t *testing.T) {
+	allowed := uuid.New()
+	principal := &models.Principal{APIKeyID: uuid.New(), Role: models.RoleEmployee, PvzIDs: []uuid.UUID{allowed}}
+
+	tests := []struct {
+		name         string
+		target       string
+		setupMocks   func(usecase *mocks.MockUsecase)
+		serve        func(h *Handler, w http.ResponseWriter, r *http.Request)
+		expectedCode int
+	}{
+		{
+			name:   "pvz list is filtered",
+			target: "/api/v1/pvz",
+			setupMocks: func(usecase *mocks.MockUsecase) {
+				usecase.EXPECT().GetPvz(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), []uuid.UUID{allowed}).Return(nil, nil)
+			},
+			serve:        (*Handler).GetPvzs,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:   "stats are filtered",
+			target: "/api/v1/pvz/stats",
+			setupMocks: func(usecase *mocks.MockUsecase) {
+				usecase.EXPECT().GetStats(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, filter *models.StatsFilter) ([]*models.StatsRow, error) {
+					assert.Equal(t, []uuid.UUID{allowed}, filter.PvzIDs)
+					return nil, nil
+				})
+			},
+			serve:        (*Handler).GetStats,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "stats of another pvz",
+			target:       "/api/v1/pvz/stats?pvzId=" + uuid.NewString(),
+			setupMocks:   func(*mocks.MockUsecase) {},
+			serve:        (*Handler).GetStats,
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			name:   "export is filtered",
+			target: "/api/v1/pvz/export",
+			setupMocks: func(usecase *mocks.MockUsecase) {
+				usecase.EXPECT().ExportReceptions(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, filter *models.ExportFilter, _ func(*models.ExportRow) error) error {
+					assert.Equal(t, []uuid.UUID{allowed}, filter.PvzIDs)
+					return nil
+				})
+			},
+			serve:        (*Handler).ExportReceptions,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "export of another pvz",
+			target:       "/api/v1/pvz/export?pvzId=" + uuid.NewString(),
+			setupMocks:   func(*mocks.MockUsecase) {},
+			serve:        (*Handler).ExportReceptions,
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usecase := mocks.NewMockUsecase(ctrl)
+			tt.setupMocks(usecase)
+
+			h := &Handler{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), usecase: usecase}
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req = req.WithContext(context.WithValue(req.Context(), middleware.PrincipalInContext, principal))
+			rec := httptest.NewRecorder()
+
+			tt.serve(h, rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+}
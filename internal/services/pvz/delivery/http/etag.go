@@ -0,0 +1,54 @@
+package http
+
+import (
+	"errors"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/pkg/responser"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var (
+	errIfMatchRequired = errors.New("If-Match header is required")
+	errInvalidIfMatch  = errors.New("invalid If-Match header")
+)
+
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// readIfMatch returns the precondition of the If-Match header, either "*" or a comma-separated list of tags.
+func readIfMatch(r *http.Request) (models.IfMatch, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return models.IfMatch{}, errIfMatchRequired
+	}
+	if header == "*" {
+		return models.IfMatch{Any: true}, nil
+	}
+
+	ifMatch := models.IfMatch{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		unquoted, err := strconv.Unquote(tag)
+		if err != nil {
+			unquoted = tag
+		}
+
+		version, err := strconv.ParseInt(unquoted, 10, 64)
+		if err != nil || version <= 0 {
+			return models.IfMatch{}, errInvalidIfMatch
+		}
+		ifMatch.Versions = append(ifMatch.Versions, version)
+	}
+	return ifMatch, nil
+}
+
+func sendIfMatchErr(w http.ResponseWriter, err error) {
+	if errors.Is(err, errIfMatchRequired) {
+		responser.SendErr(w, http.StatusPreconditionRequired, err.Error())
+		return
+	}
+	responser.SendErr(w, http.StatusBadRequest, err.Error())
+}
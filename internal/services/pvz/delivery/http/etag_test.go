@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadIfMatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		header          string
+		expectedIfMatch models.IfMatch
+		expectedErr     error
+	}{
+		{name: "quoted", header: `"3"`, expectedIfMatch: models.IfMatch{Versions: []int64{3}}},
+		{name: "weak", header: `W/"7"`, expectedIfMatch: models.IfMatch{Versions: []int64{7}}},
+		{name: "unquoted", header: `12`, expectedIfMatch: models.IfMatch{Versions: []int64{12}}},
+		{name: "list", header: `"3", W/"4",5`, expectedIfMatch: models.IfMatch{Versions: []int64{3, 4, 5}}},
+		{name: "any", header: `*`, expectedIfMatch: models.IfMatch{Any: true}},
+		{name: "missing", header: ``, expectedErr: errIfMatchRequired},
+		{name: "not a number", header: `"abc"`, expectedErr: errInvalidIfMatch},
+		{name: "zero", header: `"0"`, expectedErr: errInvalidIfMatch},
+		{name: "any in list", header: `"3", *`, expectedErr: errInvalidIfMatch},
+		{name: "empty list item", header: `"3",`, expectedErr: errInvalidIfMatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/pvz", nil)
+			if tt.header != "" {
+				req.Header.Set("If-Match", tt.header)
+			}
+
+			ifMatch, err := readIfMatch(req)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedIfMatch, ifMatch)
+			}
+		})
+	}
+}
+
+func TestSetETag(t *testing.T) {
+	rec := httptest.NewRecorder()
+	setETag(rec, 5)
+	assert.Equal(t, `"5"`, rec.Header().Get("ETag"))
+}
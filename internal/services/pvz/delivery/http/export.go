@@ -0,0 +1,118 @@
+package http
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/pkg/xlsx"
+	"net/http"
+	"time"
+)
+
+const exportFlushEvery = 1000
+
+// exportStatusTrailer is sent after the file, a failure after the first
+// page can no longer change the status code.
+const (
+	exportStatusTrailer  = "X-Export-Status"
+	exportStatusComplete = "complete"
+	exportStatusFailed   = "failed"
+)
+
+var exportHeader = []string{
+	"pvz_id",
+	"pvz_city",
+	"pvz_registration_date",
+	"reception_id",
+	"reception_date_time",
+	"reception_status",
+	"reception_closed_at",
+	"product_id",
+	"product_date_time",
+	"product_type",
+}
+
+type rowWriter interface {
+	WriteRow(cells []string) error
+	Flush() error
+	Close() error
+}
+
+type csvRowWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvRowWriter) WriteRow(cells []string) error {
+	return c.writer.Write(cells)
+}
+
+func (c *csvRowWriter) Flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+func (c *csvRowWriter) Close() error {
+	return c.Flush()
+}
+
+// startExport writes the response headers and the header row, after that the status code can no longer be changed.
+func startExport(w http.ResponseWriter, format models.ExportFormat, filter *models.ExportFilter) (rowWriter, error) {
+	filename := fmt.Sprintf("receptions_%s_%s.%s",
+		filter.StartDate.Format("20060102"),
+		filter.EndDate.Format("20060102"),
+		format,
+	)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.Header().Set("Trailer", exportStatusTrailer)
+
+	var writer rowWriter
+	switch format {
+	case models.FormatXLSX:
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		w.WriteHeader(http.StatusOK)
+
+		xlsxWriter, err := xlsx.NewWriter(w, "receptions")
+		if err != nil {
+			return nil, err
+		}
+		writer = xlsxWriter
+	default:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+
+		writer = &csvRowWriter{writer: csv.NewWriter(w)}
+	}
+
+	if err := writer.WriteRow(exportHeader); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+func exportRowCells(row *models.ExportRow) []string {
+	cells := []string{
+		row.PvzID.String(),
+		string(row.PvzCity),
+		row.PvzRegistrationDate.Format(time.RFC3339),
+		row.ReceptionID.String(),
+		row.ReceptionDateTime.Format(time.RFC3339),
+		string(row.ReceptionStatus),
+		"",
+		"",
+		"",
+		"",
+	}
+	if row.ReceptionClosedAt != nil {
+		cells[6] = row.ReceptionClosedAt.Format(time.RFC3339)
+	}
+	if row.ProductID != nil {
+		cells[7] = row.ProductID.String()
+	}
+	if row.ProductDateTime != nil {
+		cells[8] = row.ProductDateTime.Format(time.RFC3339)
+	}
+	if row.ProductType != nil {
+		cells[9] = string(*row.ProductType)
+	}
+	return cells
+}
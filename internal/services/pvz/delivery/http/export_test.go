@@ -0,0 +1,65 @@
+package http
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandler_ExportReceptions(t *testing.T) {
+	row := &models.ExportRow{PvzID: uuid.New(), ReceptionID: uuid.New()}
+	emit := func(rows int, err error) func(any, any, func(*models.ExportRow) error) error {
+		return func(_ any, _ any, fn func(*models.ExportRow) error) error {
+			for i := 0; i < rows; i++ {
+				if err := fn(row); err != nil {
+					return err
+				}
+			}
+			return err
+		}
+	}
+
+	tests := []struct {
+		name            string
+		rows            int
+		err             error
+		expectedCode    int
+		expectedTrailer string
+		expectedLines   int
+	}{
+		{name: "complete", rows: 3, expectedCode: http.StatusOK, expectedTrailer: exportStatusComplete, expectedLines: 4},
+		{name: "empty", expectedCode: http.StatusOK, expectedTrailer: exportStatusComplete, expectedLines: 1},
+		{name: "fails within the first page", rows: 3, err: errors.New("connection reset"), expectedCode: http.StatusInternalServerError},
+		{name: "fails after the first page", rows: exportFlushEvery + 1, err: errors.New("connection reset"), expectedCode: http.StatusOK, expectedTrailer: exportStatusFailed, expectedLines: exportFlushEvery + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			usecase := mocks.NewMockUsecase(ctrl)
+			usecase.EXPECT().ExportReceptions(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(emit(tt.rows, tt.err))
+
+			h := &Handler{logger: slog.New(slog.NewTextHandler(io.Discard, nil)), usecase: usecase}
+			rec := httptest.NewRecorder()
+
+			h.ExportReceptions(rec, httptest.NewRequest(http.MethodGet, "/api/v1/pvz/export", nil))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedTrailer != "" {
+				assert.Equal(t, exportStatusTrailer, rec.Header().Get("Trailer"))
+				assert.Equal(t, tt.expectedTrailer, rec.Result().Trailer.Get(exportStatusTrailer))
+				assert.Equal(t, tt.expectedLines, strings.Count(rec.Body.String(), "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type fakePVZClient struct {
+	gen.PVZServiceClient
+	resp *gen.GetPVZListResponse
+	err  error
+}
+
+func (f *fakePVZClient) GetPVZList(context.Context, *gen.GetPVZListRequest, ...grpc.CallOption) (*gen.GetPVZListResponse, error) {
+	return f.resp, f.err
+}
+
+func TestHandler_GetPvzList(t *testing.T) {
+	tests := []struct {
+		name         string
+		client       *fakePVZClient
+		expectedCode int
+	}{
+		{
+			name: "ok",
+			client: &fakePVZClient{resp: &gen.GetPVZListResponse{Pvzs: []*gen.PVZ{
+				{Id: "0f8fad5b-d9cb-469f-a165-70867728950e", City: "Москва", RegistrationDate: timestamppb.Now()},
+			}}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "service unavailable",
+			client:       &fakePVZClient{err: status.Error(codes.Unavailable, "connection refused")},
+			expectedCode: http.StatusServiceUnavailable,
+		},
+		{
+			name:         "deadline exceeded",
+			client:       &fakePVZClient{err: status.Error(codes.DeadlineExceeded, "deadline exceeded")},
+			expectedCode: http.StatusGatewayTimeout,
+		},
+		{
+			name:         "internal",
+			client:       &fakePVZClient{err: status.Error(codes.Internal, "boom")},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{
+				logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+				grpcClient: tt.client,
+			}
+			rec := httptest.NewRecorder()
+
+			h.GetPvzList(rec, httptest.NewRequest(http.MethodGet, "/api/v1/pvzGrpc", nil))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+}
@@ -5,17 +5,22 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
 	"github.com/marrgancovka/pvzService/internal/pkg/metrics"
 	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
 	"github.com/marrgancovka/pvzService/internal/services/pvz"
 	"github.com/marrgancovka/pvzService/internal/services/pvz/delivery/grpc/gen"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/events"
 	"github.com/marrgancovka/pvzService/pkg/reader"
 	"github.com/marrgancovka/pvzService/pkg/responser"
 	"go.uber.org/fx"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -26,6 +31,7 @@ type Params struct {
 	Usecase    pvz.Usecase
 	GRPCClient *grpc.ClientConn
 	Metrics    metrics.Metrics
+	Events     events.Config
 }
 
 type Handler struct {
@@ -33,6 +39,7 @@ type Handler struct {
 	usecase    pvz.Usecase
 	grpcClient gen.PVZServiceClient
 	metrics    metrics.Metrics
+	events     events.Config
 }
 
 func NewHandler(params Params) *Handler {
@@ -41,18 +48,13 @@ func NewHandler(params Params) *Handler {
 		logger:     params.Logger,
 		usecase:    params.Usecase,
 		metrics:    params.Metrics,
+		events:     params.Events,
 	}
 }
 
 func (h *Handler) CreatePvz(w http.ResponseWriter, r *http.Request) {
 	const op = "pvz.Handler.CreatePvz"
-	logger := h.logger.With("op", op)
-
-	if r.Context().Value(middleware.RoleInContext) != models.RoleModerator {
-		logger.Error("only for moderator")
-		responser.SendErr(w, http.StatusForbidden, pvz.ErrNoAccess.Error())
-		return
-	}
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
 
 	pvzData := &models.Pvz{}
 	if err := reader.ReadRequestData(r, pvzData); err != nil {
@@ -78,18 +80,13 @@ func (h *Handler) CreatePvz(w http.ResponseWriter, r *http.Request) {
 
 	logger.Info("success create pvz", "response", createdPvz)
 	h.metrics.CreatedPvzTotal(string(createdPvz.City))
+	setETag(w, createdPvz.Version)
 	responser.SendOk(w, http.StatusCreated, createdPvz)
 }
 
 func (h *Handler) GetPvzs(w http.ResponseWriter, r *http.Request) {
 	const op = "pvz.Handler.GetPvzList"
-	logger := h.logger.With("op", op)
-
-	if r.Context().Value(middleware.RoleInContext) != models.RoleModerator && r.Context().Value(middleware.RoleInContext) != models.RoleEmployee {
-		logger.Error("only for moderator or employee")
-		responser.SendErr(w, http.StatusForbidden, pvz.ErrNoAccess.Error())
-		return
-	}
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
 
 	var err error
 	queryParams := r.URL.Query()
@@ -140,7 +137,7 @@ func (h *Handler) GetPvzs(w http.ResponseWriter, r *http.Request) {
 		page = uint64(pageInt)
 	}
 
-	pvzList, err := h.usecase.GetPvz(r.Context(), startDate, endDate, limit, page)
+	pvzList, err := h.usecase.GetPvz(r.Context(), startDate, endDate, limit, page, middleware.AccessiblePvzIDs(r.Context()))
 	if err != nil {
 		responser.SendErr(w, http.StatusInternalServerError, err.Error())
 		return
@@ -150,16 +147,115 @@ func (h *Handler) GetPvzs(w http.ResponseWriter, r *http.Request) {
 	responser.SendOk(w, http.StatusOK, pvzList)
 }
 
-func (h *Handler) CloseLastReception(w http.ResponseWriter, r *http.Request) {
-	const op = "pvz.Handler.CloseLastReception"
-	logger := h.logger.With("op", op)
+func (h *Handler) GetPvz(w http.ResponseWriter, r *http.Request) {
+	const op = "pvz.Handler.GetPvz"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
 
-	if r.Context().Value(middleware.RoleInContext) != models.RoleEmployee {
-		logger.Error("only for employee")
-		responser.SendErr(w, http.StatusForbidden, pvz.ErrNoAccess.Error())
+	pvzId, err := reader.ReadVarsUUID(r, "pvzId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+		return
+	}
+
+	pvzData, err := h.usecase.GetPvzByID(r.Context(), pvzId)
+	if err != nil {
+		switch {
+		case errors.Is(err, pvz.ErrPvzNotExists):
+			responser.SendErr(w, http.StatusNotFound, pvz.ErrPvzNotExists.Error())
+			return
+		default:
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+			return
+		}
+	}
+
+	logger.Info("success get pvz", "response", pvzData)
+	setETag(w, pvzData.Version)
+	responser.SendOk(w, http.StatusOK, pvzData)
+}
+
+func (h *Handler) UpdatePvz(w http.ResponseWriter, r *http.Request) {
+	const op = "pvz.Handler.UpdatePvz"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	pvzId, err := reader.ReadVarsUUID(r, "pvzId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+		return
+	}
+
+	ifMatch, err := readIfMatch(r)
+	if err != nil {
+		logger.Error("error read If-Match: " + err.Error())
+		sendIfMatchErr(w, err)
+		return
+	}
+
+	pvzData := &models.Pvz{}
+	if err = reader.ReadRequestData(r, pvzData); err != nil {
+		logger.Error("error read request data: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+		return
+	}
+	pvzData.ID = pvzId
+
+	updatedPvz, err := h.usecase.UpdatePvz(r.Context(), pvzData, ifMatch)
+	if err != nil {
+		switch {
+		case errors.Is(err, pvz.ErrInaccessibleCity):
+			responser.SendErr(w, http.StatusBadRequest, pvz.ErrInaccessibleCity.Error())
+			return
+		case errors.Is(err, pvz.ErrPvzNotExists):
+			responser.SendErr(w, http.StatusNotFound, pvz.ErrPvzNotExists.Error())
+			return
+		case errors.Is(err, pvz.ErrVersionMismatch):
+			responser.SendErr(w, http.StatusPreconditionFailed, pvz.ErrVersionMismatch.Error())
+			return
+		default:
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+			return
+		}
+	}
+
+	logger.Info("success update pvz", "response", updatedPvz)
+	setETag(w, updatedPvz.Version)
+	responser.SendOk(w, http.StatusOK, updatedPvz)
+}
+
+func (h *Handler) GetLastReception(w http.ResponseWriter, r *http.Request) {
+	const op = "pvz.Handler.GetLastReception"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	pvzId, err := reader.ReadVarsUUID(r, "pvzId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
 		return
 	}
 
+	reception, err := h.usecase.GetLastReception(r.Context(), pvzId)
+	if err != nil {
+		switch {
+		case errors.Is(err, pvz.ErrNoReception):
+			responser.SendErr(w, http.StatusNotFound, pvz.ErrNoReception.Error())
+			return
+		default:
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+			return
+		}
+	}
+
+	logger.Info("success get last reception", "response", reception)
+	setETag(w, reception.Version)
+	responser.SendOk(w, http.StatusOK, reception)
+}
+
+func (h *Handler) CloseLastReception(w http.ResponseWriter, r *http.Request) {
+	const op = "pvz.Handler.CloseLastReception"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
 	pvzId, err := reader.ReadVarsUUID(r, "pvzId")
 	if err != nil {
 		logger.Error("error read var uuid: " + err.Error())
@@ -172,12 +268,22 @@ func (h *Handler) CloseLastReception(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	closedReception, err := h.usecase.CloseLastReceptions(r.Context(), pvzId)
+	ifMatch, err := readIfMatch(r)
+	if err != nil {
+		logger.Error("error read If-Match: " + err.Error())
+		sendIfMatchErr(w, err)
+		return
+	}
+
+	closedReception, err := h.usecase.CloseLastReceptions(r.Context(), pvzId, ifMatch)
 	if err != nil {
 		switch {
 		case errors.Is(err, pvz.ErrNoOpenReception):
 			responser.SendErr(w, http.StatusBadRequest, pvz.ErrNoOpenReception.Error())
 			return
+		case errors.Is(err, pvz.ErrVersionMismatch):
+			responser.SendErr(w, http.StatusPreconditionFailed, pvz.ErrVersionMismatch.Error())
+			return
 		default:
 			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
 			return
@@ -185,18 +291,13 @@ func (h *Handler) CloseLastReception(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logger.Info("success close last reception", "response", closedReception)
+	setETag(w, closedReception.Version)
 	responser.SendOk(w, http.StatusOK, closedReception)
 }
 
 func (h *Handler) DeleteLastProduct(w http.ResponseWriter, r *http.Request) {
 	const op = "pvz.Handler.DeleteLastProduct"
-	logger := h.logger.With("op", op)
-
-	if r.Context().Value(middleware.RoleInContext) != models.RoleEmployee {
-		logger.Error("only for employee")
-		responser.SendErr(w, http.StatusForbidden, pvz.ErrNoAccess.Error())
-		return
-	}
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
 
 	pvzId, err := reader.ReadVarsUUID(r, "pvzId")
 	if err != nil {
@@ -226,13 +327,7 @@ func (h *Handler) DeleteLastProduct(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) CreateReception(w http.ResponseWriter, r *http.Request) {
 	op := "pvz.Handler.CreateReception"
-	logger := h.logger.With("op", op)
-
-	if r.Context().Value(middleware.RoleInContext) != models.RoleEmployee {
-		logger.Error("only for employee")
-		responser.SendErr(w, http.StatusForbidden, pvz.ErrNoAccess.Error())
-		return
-	}
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
 
 	receptionData := &models.ReceptionRequest{}
 	if err := reader.ReadRequestData(r, receptionData); err != nil {
@@ -240,6 +335,11 @@ func (h *Handler) CreateReception(w http.ResponseWriter, r *http.Request) {
 		responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
 		return
 	}
+	if !middleware.CanAccessPvz(r.Context(), receptionData.PvzID) {
+		logger.Error("pvz is not accessible", "pvz_id", receptionData.PvzID)
+		responser.SendErr(w, http.StatusForbidden, pvz.ErrNoAccess.Error())
+		return
+	}
 	createdReception, err := h.usecase.CreateReception(r.Context(), receptionData)
 	if err != nil {
 		switch {
@@ -254,18 +354,13 @@ func (h *Handler) CreateReception(w http.ResponseWriter, r *http.Request) {
 
 	logger.Info("success create reception", "response", createdReception)
 	h.metrics.CreatedReceptionsTotal(fmt.Sprint(createdReception.PvzID))
+	setETag(w, createdReception.Version)
 	responser.SendOk(w, http.StatusCreated, createdReception)
 }
 
 func (h *Handler) AddProduct(w http.ResponseWriter, r *http.Request) {
 	op := "pvz.Handler.AddProduct"
-	logger := h.logger.With("op", op)
-
-	if r.Context().Value(middleware.RoleInContext) != models.RoleEmployee {
-		logger.Error("only for employee")
-		responser.SendErr(w, http.StatusForbidden, pvz.ErrNoAccess.Error())
-		return
-	}
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
 
 	productData := &models.ProductRequest{}
 	if err := reader.ReadRequestData(r, productData); err != nil {
@@ -279,6 +374,11 @@ func (h *Handler) AddProduct(w http.ResponseWriter, r *http.Request) {
 		responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
 		return
 	}
+	if !middleware.CanAccessPvz(r.Context(), productData.PvzID) {
+		logger.Error("pvz is not accessible", "pvz_id", productData.PvzID)
+		responser.SendErr(w, http.StatusForbidden, pvz.ErrNoAccess.Error())
+		return
+	}
 
 	addedProduct, err := h.usecase.AddProduct(r.Context(), productData)
 	if err != nil {
@@ -299,12 +399,13 @@ func (h *Handler) AddProduct(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) GetPvzList(w http.ResponseWriter, r *http.Request) {
 	const op = "pvz.Handler.GetPvzList"
-	logger := h.logger.With("op", op)
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
 
 	list, err := h.grpcClient.GetPVZList(r.Context(), &gen.GetPVZListRequest{})
 	if err != nil {
 		logger.Error("error get pvz list: " + err.Error())
-		responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+		sendGRPCErr(w, err)
+		return
 	}
 
 	result := make([]*models.Pvz, len(list.Pvzs))
@@ -316,6 +417,276 @@ func (h *Handler) GetPvzList(w http.ResponseWriter, r *http.Request) {
 	responser.SendOk(w, http.StatusOK, result)
 }
 
+func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
+	const op = "pvz.Handler.GetStats"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	var err error
+	queryParams := r.URL.Query()
+
+	filter := &models.StatsFilter{
+		StartDate: time.Now().AddDate(0, -1, 0),
+		EndDate:   time.Now(),
+		Period:    models.StatsPeriod(queryParams.Get("period")),
+		City:      models.City(queryParams.Get("city")),
+	}
+
+	if startDateStr := queryParams.Get("startDate"); startDateStr != "" {
+		filter.StartDate, err = parseDate(startDateStr)
+		if err != nil {
+			logger.Error("error parsing date: " + err.Error())
+			responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+			return
+		}
+	}
+
+	if endDateStr := queryParams.Get("endDate"); endDateStr != "" {
+		filter.EndDate, err = parseDate(endDateStr)
+		if err != nil {
+			logger.Error("error parsing date: " + err.Error())
+			responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+			return
+		}
+	}
+
+	if pvzIdStr := queryParams.Get("pvzId"); pvzIdStr != "" {
+		filter.PvzID, err = uuid.Parse(pvzIdStr)
+		if err != nil {
+			logger.Error("error parsing pvz id: " + err.Error())
+			responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+			return
+		}
+		if !middleware.CanAccessPvz(r.Context(), filter.PvzID) {
+			logger.Warn("pvz denied", "pvz_id", filter.PvzID)
+			responser.SendErr(w, http.StatusForbidden, pvz.ErrNoAccess.Error())
+			return
+		}
+	}
+	filter.PvzIDs = middleware.AccessiblePvzIDs(r.Context())
+
+	if groupByStr := queryParams.Get("groupBy"); groupByStr != "" {
+		for _, group := range strings.Split(groupByStr, ",") {
+			filter.GroupBy = append(filter.GroupBy, models.StatsGroup(strings.TrimSpace(group)))
+		}
+	}
+
+	stats, err := h.usecase.GetStats(r.Context(), filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, pvz.ErrInvalidStatsPeriod),
+			errors.Is(err, pvz.ErrInvalidStatsGroup),
+			errors.Is(err, pvz.ErrInvalidDateRange),
+			errors.Is(err, pvz.ErrInaccessibleCity):
+			responser.SendErr(w, http.StatusBadRequest, err.Error())
+			return
+		default:
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+			return
+		}
+	}
+
+	if stats == nil {
+		stats = []*models.StatsRow{}
+	}
+
+	logger.Info("success get stats", "rows", len(stats))
+	responser.SendOk(w, http.StatusOK, stats)
+}
+
+func (h *Handler) ExportReceptions(w http.ResponseWriter, r *http.Request) {
+	const op = "pvz.Handler.ExportReceptions"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	var err error
+	queryParams := r.URL.Query()
+
+	format := models.FormatCSV
+	if formatStr := queryParams.Get("format"); formatStr != "" {
+		format = models.ExportFormat(formatStr)
+	}
+	if !format.IsValid() {
+		logger.Error("incorrect export format: " + string(format))
+		responser.SendErr(w, http.StatusBadRequest, pvz.ErrInvalidExportFormat.Error())
+		return
+	}
+
+	filter := &models.ExportFilter{
+		StartDate:   time.Now().AddDate(0, -1, 0),
+		EndDate:     time.Now(),
+		City:        models.City(queryParams.Get("city")),
+		Status:      models.ReceptionType(queryParams.Get("status")),
+		ProductType: models.ProductType(queryParams.Get("type")),
+	}
+
+	if startDateStr := queryParams.Get("startDate"); startDateStr != "" {
+		filter.StartDate, err = parseDate(startDateStr)
+		if err != nil {
+			logger.Error("error parsing date: " + err.Error())
+			responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+			return
+		}
+	}
+
+	if endDateStr := queryParams.Get("endDate"); endDateStr != "" {
+		filter.EndDate, err = parseDate(endDateStr)
+		if err != nil {
+			logger.Error("error parsing date: " + err.Error())
+			responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+			return
+		}
+	}
+
+	if pvzIdStr := queryParams.Get("pvzId"); pvzIdStr != "" {
+		filter.PvzID, err = uuid.Parse(pvzIdStr)
+		if err != nil {
+			logger.Error("error parsing pvz id: " + err.Error())
+			responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+			return
+		}
+		if !middleware.CanAccessPvz(r.Context(), filter.PvzID) {
+			logger.Warn("pvz denied", "pvz_id", filter.PvzID)
+			responser.SendErr(w, http.StatusForbidden, pvz.ErrNoAccess.Error())
+			return
+		}
+	}
+	filter.PvzIDs = middleware.AccessiblePvzIDs(r.Context())
+
+	var writer rowWriter
+	var pending [][]string
+	flusher, _ := w.(http.Flusher)
+	written := 0
+
+	// the first rows are held back until a page is full, so that a failure
+	// while reading them is still answered with an error status
+	start := func() error {
+		started, err := startExport(w, format, filter)
+		if err != nil {
+			return err
+		}
+		writer = started
+		for _, cells := range pending {
+			if err := writer.WriteRow(cells); err != nil {
+				return err
+			}
+		}
+		pending = nil
+		return nil
+	}
+
+	err = h.usecase.ExportReceptions(r.Context(), filter, func(row *models.ExportRow) error {
+		written++
+		if writer == nil {
+			pending = append(pending, exportRowCells(row))
+			if len(pending) < exportFlushEvery {
+				return nil
+			}
+			if err := start(); err != nil {
+				return err
+			}
+		} else if err := writer.WriteRow(exportRowCells(row)); err != nil {
+			return err
+		}
+
+		if written%exportFlushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if writer != nil {
+			// the status is already sent, the trailer tells the client
+			// that the file is incomplete
+			logger.Error("export interrupted: "+err.Error(), "rows", written)
+			w.Header().Set(exportStatusTrailer, exportStatusFailed)
+			return
+		}
+
+		switch {
+		case errors.Is(err, pvz.ErrInvalidDateRange),
+			errors.Is(err, pvz.ErrInaccessibleCity),
+			errors.Is(err, pvz.ErrIncorrectStatus),
+			errors.Is(err, pvz.ErrIncorrectProductType):
+			responser.SendErr(w, http.StatusBadRequest, err.Error())
+			return
+		default:
+			logger.Error("export failed: " + err.Error())
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+			return
+		}
+	}
+
+	if writer == nil {
+		if err = start(); err != nil {
+			logger.Error("failed to start export: " + err.Error())
+			w.Header().Set(exportStatusTrailer, exportStatusFailed)
+			return
+		}
+	}
+	if err = writer.Close(); err != nil {
+		logger.Error("failed to finish export: " + err.Error())
+		w.Header().Set(exportStatusTrailer, exportStatusFailed)
+		return
+	}
+	w.Header().Set(exportStatusTrailer, exportStatusComplete)
+
+	logger.Info("success export receptions", "format", format, "rows", written)
+}
+
+func (h *Handler) ImportPvz(w http.ResponseWriter, r *http.Request) {
+	const op = "pvz.Handler.ImportPvz"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	dryRun := false
+	if dryRunStr := r.URL.Query().Get("dryRun"); dryRunStr != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			logger.Error("error parsing dry run flag: " + err.Error())
+			responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+			return
+		}
+	}
+
+	rows, err := readImportRows(w, r)
+	if err != nil {
+		logger.Error("error read import file: " + err.Error())
+		switch {
+		case errors.Is(err, pvz.ErrImportTooLarge):
+			responser.SendErr(w, http.StatusRequestEntityTooLarge, pvz.ErrImportTooLarge.Error())
+		default:
+			responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+		}
+		return
+	}
+
+	report, err := h.usecase.ImportPvz(r.Context(), rows, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, pvz.ErrEmptyImport):
+			responser.SendErr(w, http.StatusBadRequest, pvz.ErrEmptyImport.Error())
+			return
+		default:
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+			return
+		}
+	}
+
+	logger.Info("pvz import finished", "dryRun", report.DryRun, "total", report.Total, "imported", report.Imported, "errors", len(report.Errors))
+	switch {
+	case len(report.Errors) > 0:
+		responser.SendOk(w, http.StatusUnprocessableEntity, report)
+	case report.DryRun:
+		responser.SendOk(w, http.StatusOK, report)
+	default:
+		responser.SendOk(w, http.StatusCreated, report)
+	}
+}
+
 func parseDate(dateStr string) (time.Time, error) {
 	formats := []string{
 		time.RFC3339,
@@ -342,5 +713,19 @@ func convert(pvz *gen.PVZ) *models.Pvz {
 		ID:               pvzUUID,
 		RegistrationDate: pvz.RegistrationDate.AsTime(),
 		City:             models.City(pvz.City),
+		Address:          pvz.Address,
+	}
+}
+
+// sendGRPCErr maps the error of a call to the pvz gRPC service, a service
+// that is down or has its circuit breaker open is reported as 503.
+func sendGRPCErr(w http.ResponseWriter, err error) {
+	switch status.Code(err) {
+	case codes.Unavailable:
+		responser.SendErr(w, http.StatusServiceUnavailable, pvz.ErrServiceUnavailable.Error())
+	case codes.DeadlineExceeded:
+		responser.SendErr(w, http.StatusGatewayTimeout, pvz.ErrServiceTimeout.Error())
+	default:
+		responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
 	}
 }
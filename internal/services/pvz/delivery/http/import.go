@@ -0,0 +1,77 @@
+package http
+
+import (
+	"encoding/csv"
+	"errors"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/services/pvz"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	importMaxBytes = 10 << 20
+	importMaxRows  = 10000
+)
+
+// readImportRows reads a CSV file either from the "file" field of a multipart form or from the raw request body.
+// A header row starting with "id" is skipped.
+func readImportRows(w http.ResponseWriter, r *http.Request) ([]*models.PvzImportRow, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, importMaxBytes)
+	defer r.Body.Close()
+
+	var source io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		source = file
+	}
+
+	reader := csv.NewReader(source)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []*models.PvzImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			maxBytesErr := &http.MaxBytesError{}
+			if errors.As(err, &maxBytesErr) {
+				return nil, pvz.ErrImportTooLarge
+			}
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(rows) == 0 && line == 1 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(record[0], "\ufeff")), "id") {
+			continue
+		}
+
+		if len(rows) == importMaxRows {
+			return nil, pvz.ErrImportTooLarge
+		}
+
+		row := &models.PvzImportRow{
+			Line:       line,
+			FieldCount: len(record),
+		}
+		fields := []*string{&row.ID, &row.City, &row.RegistrationDate, &row.Address}
+		for i := range fields {
+			if i < len(record) {
+				*fields[i] = record[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
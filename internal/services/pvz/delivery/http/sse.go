@@ -0,0 +1,169 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/services/pvz"
+	"github.com/marrgancovka/pvzService/pkg/reader"
+	"github.com/marrgancovka/pvzService/pkg/responser"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const lastEventIDHeader = "Last-Event-ID"
+
+var errInvalidLastEventID = errors.New("invalid last event id")
+
+// sseWriter serializes events and heartbeats written to one stream.
+type sseWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	rc  *http.ResponseController
+	err error
+}
+
+func (s *sseWriter) write(format string, args ...any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+	if _, err := fmt.Fprintf(s.w, format, args...); err != nil {
+		s.err = err
+		return err
+	}
+	s.err = s.rc.Flush()
+	return s.err
+}
+
+func (s *sseWriter) event(event *models.PvzEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.write("id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
+
+func (s *sseWriter) heartbeat() error {
+	return s.write(": ping\n\n")
+}
+
+// readLastEventID returns the id the client resumes from, browsers send it in
+// the Last-Event-ID header on reconnect, the query parameter covers the first
+// connection.
+func readLastEventID(r *http.Request) (int64, error) {
+	value := r.Header.Get(lastEventIDHeader)
+	if value == "" {
+		value = r.URL.Query().Get("lastEventId")
+	}
+	if value == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errInvalidLastEventID
+	}
+	return id, nil
+}
+
+// StreamEvents streams reception and product changes of one pvz as
+// Server-Sent Events until the client disconnects.
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	const op = "pvz.Handler.StreamEvents"
+	logger := logctx.From(r.Context(), h.logger).With("op", op)
+
+	pvzId, err := reader.ReadVarsUUID(r, "pvzId")
+	if err != nil {
+		logger.Error("error read var uuid: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, pvz.ErrBadRequest.Error())
+		return
+	}
+
+	lastEventID, err := readLastEventID(r)
+	if err != nil {
+		logger.Error("error read last event id: " + err.Error())
+		responser.SendErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err = h.usecase.GetPvzByID(r.Context(), pvzId); err != nil {
+		switch {
+		case errors.Is(err, pvz.ErrPvzNotExists):
+			responser.SendErr(w, http.StatusNotFound, pvz.ErrPvzNotExists.Error())
+			return
+		default:
+			responser.SendErr(w, http.StatusInternalServerError, "internal mainServer error")
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &sseWriter{w: w, rc: http.NewResponseController(w)}
+	if err = stream.write("retry: %d\n\n", h.events.RetryDelay.Milliseconds()); err != nil {
+		logger.Error("streaming is not supported: " + err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.heartbeat(ctx, stream, cancel)
+	}()
+
+	logger.Info("watcher connected", "pvz_id", pvzId, "last_event_id", lastEventID)
+
+	filter := &models.PvzEventFilter{PvzIDs: []uuid.UUID{pvzId}, AfterID: lastEventID}
+	err = h.usecase.WatchEvents(ctx, filter, stream.event)
+	cancel()
+	wg.Wait()
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		logger.Info("watcher disconnected")
+	case errors.Is(err, pvz.ErrEventStreamLagged):
+		// closing the stream makes the client reconnect with its last event id
+		logger.Warn("watcher lagged behind, closing stream")
+	case err != nil:
+		logger.Error("error watch events: " + err.Error())
+	}
+}
+
+// heartbeat keeps an idle stream alive and cancels it once the client is gone.
+func (h *Handler) heartbeat(ctx context.Context, stream *sseWriter, cancel context.CancelFunc) {
+	if h.events.Heartbeat <= 0 {
+		return
+	}
+	ticker := time.NewTicker(h.events.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := stream.heartbeat(); err != nil {
+				cancel()
+				return
+			}
+		}
+	}
+}
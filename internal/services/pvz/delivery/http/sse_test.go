@@ -0,0 +1,229 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/services/pvz"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/events"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestReadLastEventID(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		query       string
+		expectedID  int64
+		expectedErr error
+	}{
+		{name: "none", expectedID: 0},
+		{name: "header", header: "42", expectedID: 42},
+		{name: "query", query: "7", expectedID: 7},
+		{name: "header wins", header: "42", query: "7", expectedID: 42},
+		{name: "not a number", header: "abc", expectedErr: errInvalidLastEventID},
+		{name: "negative", query: "-1", expectedErr: errInvalidLastEventID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/pvz/events?lastEventId="+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set(lastEventIDHeader, tt.header)
+			}
+
+			id, err := readLastEventID(req)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedID, id)
+			}
+		})
+	}
+}
+
+// pingRecorder reports heartbeats, with failPings set they fail as if the
+// client was gone.
+type pingRecorder struct {
+	*httptest.ResponseRecorder
+	pings     chan struct{}
+	failPings bool
+}
+
+func (r *pingRecorder) Write(b []byte) (int, error) {
+	if !strings.HasPrefix(string(b), ": ping") {
+		return r.ResponseRecorder.Write(b)
+	}
+	if r.failPings {
+		return 0, errors.New("broken pipe")
+	}
+	r.pings <- struct{}{}
+	return r.ResponseRecorder.Write(b)
+}
+
+func TestHandler_StreamEvents(t *testing.T) {
+	pvzID := uuid.New()
+	productID := uuid.New()
+
+	newRequest := func(lastEventID string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/pvz/"+pvzID.String()+"/events", nil)
+		if lastEventID != "" {
+			req.Header.Set(lastEventIDHeader, lastEventID)
+		}
+		return mux.SetURLVars(req, map[string]string{"pvzId": pvzID.String()})
+	}
+
+	t.Run("streams events", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usecase := mocks.NewMockUsecase(ctrl)
+		h := &Handler{
+			logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+			usecase: usecase,
+			events:  events.Config{Heartbeat: time.Hour, RetryDelay: 3 * time.Second},
+		}
+
+		usecase.EXPECT().GetPvzByID(gomock.Any(), pvzID).Return(&models.Pvz{ID: pvzID}, nil)
+		usecase.EXPECT().WatchEvents(gomock.Any(), &models.PvzEventFilter{PvzIDs: []uuid.UUID{pvzID}, AfterID: 5}, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ *models.PvzEventFilter, fn func(event *models.PvzEvent) error) error {
+				err := fn(&models.PvzEvent{ID: 6, Type: models.EventProductAdded, PvzID: pvzID, ProductID: &productID})
+				assert.NoError(t, err)
+				return context.Canceled
+			})
+
+		rec := httptest.NewRecorder()
+		h.StreamEvents(rec, newRequest("5"))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+		assert.Contains(t, rec.Body.String(), "retry: 3000\n\n")
+		assert.Contains(t, rec.Body.String(), "id: 6\nevent: product_added\ndata: {")
+		assert.Contains(t, rec.Body.String(), productID.String())
+	})
+
+	t.Run("invalid last event id", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		h := &Handler{
+			logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+			usecase: mocks.NewMockUsecase(ctrl),
+		}
+
+		rec := httptest.NewRecorder()
+		h.StreamEvents(rec, newRequest("abc"))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("pvz not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usecase := mocks.NewMockUsecase(ctrl)
+		h := &Handler{
+			logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+			usecase: usecase,
+		}
+
+		usecase.EXPECT().GetPvzByID(gomock.Any(), pvzID).Return(nil, pvz.ErrPvzNotExists)
+
+		rec := httptest.NewRecorder()
+		h.StreamEvents(rec, newRequest(""))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	t.Run("heartbeat", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usecase := mocks.NewMockUsecase(ctrl)
+		h := &Handler{
+			logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+			usecase: usecase,
+			events:  events.Config{Heartbeat: 10 * time.Millisecond},
+		}
+		rec := &pingRecorder{ResponseRecorder: httptest.NewRecorder(), pings: make(chan struct{}, 1)}
+
+		usecase.EXPECT().GetPvzByID(gomock.Any(), pvzID).Return(&models.Pvz{ID: pvzID}, nil)
+		usecase.EXPECT().WatchEvents(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ *models.PvzEventFilter, _ func(event *models.PvzEvent) error) error {
+				select {
+				case <-rec.pings:
+				case <-time.After(time.Second):
+					t.Error("no heartbeat sent")
+				}
+				return context.Canceled
+			})
+
+		h.StreamEvents(rec, newRequest(""))
+
+		assert.Contains(t, rec.Body.String(), ": ping\n\n")
+	})
+
+	t.Run("client disconnected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usecase := mocks.NewMockUsecase(ctrl)
+		h := &Handler{
+			logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+			usecase: usecase,
+			events:  events.Config{Heartbeat: time.Hour},
+		}
+		req := newRequest("")
+		ctx, cancel := context.WithCancel(req.Context())
+
+		usecase.EXPECT().GetPvzByID(gomock.Any(), pvzID).Return(&models.Pvz{ID: pvzID}, nil)
+		usecase.EXPECT().WatchEvents(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ *models.PvzEventFilter, _ func(event *models.PvzEvent) error) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			h.StreamEvents(httptest.NewRecorder(), req.WithContext(ctx))
+		}()
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("stream not stopped after the client disconnected")
+		}
+	})
+
+	t.Run("heartbeat write fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		usecase := mocks.NewMockUsecase(ctrl)
+		h := &Handler{
+			logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+			usecase: usecase,
+			events:  events.Config{Heartbeat: 10 * time.Millisecond},
+		}
+		rec := &pingRecorder{ResponseRecorder: httptest.NewRecorder(), failPings: true}
+
+		usecase.EXPECT().GetPvzByID(gomock.Any(), pvzID).Return(&models.Pvz{ID: pvzID}, nil)
+		usecase.EXPECT().WatchEvents(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ *models.PvzEventFilter, _ func(event *models.PvzEvent) error) error {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-time.After(time.Second):
+					t.Error("stream not cancelled after a failed heartbeat")
+					return nil
+				}
+			})
+
+		h.StreamEvents(rec, newRequest(""))
+
+		assert.NotContains(t, rec.Body.String(), ": ping")
+	})
+}
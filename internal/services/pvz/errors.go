@@ -12,4 +12,16 @@ var (
 	ErrNoProduct            = errors.New("no product found")
 	ErrNoAccess             = errors.New("no access")
 	ErrBadRequest           = errors.New("bad request")
+	ErrInvalidStatsPeriod   = errors.New("invalid stats period")
+	ErrInvalidStatsGroup    = errors.New("invalid stats group")
+	ErrInvalidDateRange     = errors.New("start date is after end date")
+	ErrInvalidExportFormat  = errors.New("invalid export format")
+	ErrIncorrectStatus      = errors.New("incorrect reception status")
+	ErrEmptyImport          = errors.New("no rows to import")
+	ErrImportTooLarge       = errors.New("too many rows to import")
+	ErrVersionMismatch      = errors.New("resource version does not match")
+	ErrNoReception          = errors.New("no reception found")
+	ErrEventStreamLagged    = errors.New("event stream fell behind, resume from the last received event")
+	ErrServiceUnavailable   = errors.New("pvz service is unavailable")
+	ErrServiceTimeout       = errors.New("pvz service did not respond in time")
 )
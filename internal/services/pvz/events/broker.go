@@ -0,0 +1,95 @@
+package events
+
+import (
+	"github.com/marrgancovka/pvzService/internal/models"
+	"sync"
+)
+
+type subscriber struct {
+	filter models.PvzEventFilter
+	ch     chan *models.PvzEvent
+}
+
+// broker fans events out to the subscribers of this process.
+type broker struct {
+	mu     sync.Mutex
+	subs   map[*subscriber]struct{}
+	buffer int
+	closed bool
+}
+
+func newBroker(buffer int) *broker {
+	return &broker{
+		subs:   make(map[*subscriber]struct{}),
+		buffer: buffer,
+	}
+}
+
+func (b *broker) subscribe(filter models.PvzEventFilter) (<-chan *models.PvzEvent, func()) {
+	sub := &subscriber{
+		filter: filter,
+		ch:     make(chan *models.PvzEvent, b.buffer),
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(sub.ch)
+		return sub.ch, func() {}
+	}
+	b.subs[sub] = struct{}{}
+
+	return sub.ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(sub)
+	}
+}
+
+// publish never blocks, a subscriber whose buffer is full is dropped and
+// its channel closed.
+func (b *broker) publish(event *models.PvzEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			b.remove(sub)
+		}
+	}
+}
+
+// reset drops every subscriber, new ones are accepted.
+func (b *broker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+func (b *broker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+	b.closed = true
+}
+
+// remove must be called with mu held.
+func (b *broker) remove(sub *subscriber) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,77 @@
+package events
+
+import (
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBroker_Filter(t *testing.T) {
+	b := newBroker(4)
+	pvzID := uuid.New()
+
+	byPvz, unsubscribePvz := b.subscribe(models.PvzEventFilter{PvzIDs: []uuid.UUID{pvzID}})
+	defer unsubscribePvz()
+	byCity, unsubscribeCity := b.subscribe(models.PvzEventFilter{Cities: []models.City{models.CityKazan}})
+	defer unsubscribeCity()
+
+	b.publish(&models.PvzEvent{ID: 1, PvzID: pvzID, City: models.CityMoscow})
+	b.publish(&models.PvzEvent{ID: 2, PvzID: uuid.New(), City: models.CityKazan})
+
+	assert.Equal(t, int64(1), (<-byPvz).ID)
+	assert.Equal(t, int64(2), (<-byCity).ID)
+	assert.Empty(t, byPvz)
+	assert.Empty(t, byCity)
+}
+
+func TestBroker_SlowSubscriberDropped(t *testing.T) {
+	b := newBroker(1)
+
+	slow, unsubscribe := b.subscribe(models.PvzEventFilter{})
+	defer unsubscribe()
+
+	b.publish(&models.PvzEvent{ID: 1})
+	b.publish(&models.PvzEvent{ID: 2})
+
+	event, ok := <-slow
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), event.ID)
+
+	_, ok = <-slow
+	assert.False(t, ok)
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := newBroker(1)
+
+	sub, unsubscribe := b.subscribe(models.PvzEventFilter{})
+	b.close()
+	unsubscribe()
+
+	_, ok := <-sub
+	assert.False(t, ok)
+
+	late, _ := b.subscribe(models.PvzEventFilter{})
+	_, ok = <-late
+	assert.False(t, ok)
+}
+
+func TestBroker_Reset(t *testing.T) {
+	b := newBroker(1)
+
+	sub, unsubscribe := b.subscribe(models.PvzEventFilter{})
+	defer unsubscribe()
+	b.reset()
+
+	_, ok := <-sub
+	assert.False(t, ok)
+
+	fresh, unsubscribeFresh := b.subscribe(models.PvzEventFilter{})
+	defer unsubscribeFresh()
+	b.publish(&models.PvzEvent{ID: 1})
+
+	event, ok := <-fresh
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), event.ID)
+}
@@ -0,0 +1,229 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/services/pvz"
+	"go.uber.org/fx"
+	"log/slog"
+	"time"
+)
+
+var eventColumns = []string{
+	"id", "type", "pvz_id", "city", "reception_id", "product_id", "product_type", "created_at",
+}
+
+type Params struct {
+	fx.In
+
+	Pool    *pgxpool.Pool
+	Logger  *slog.Logger
+	Builder squirrel.StatementBuilderType
+	Config  Config
+}
+
+// Bus stores pvz events in Postgres and delivers them to subscribers of
+// every replica, this one included, through LISTEN/NOTIFY.
+type Bus struct {
+	pool    *pgxpool.Pool
+	log     *slog.Logger
+	builder squirrel.StatementBuilderType
+	cfg     Config
+	broker  *broker
+}
+
+func NewBus(p Params) (*Bus, error) {
+	if p.Config.Channel == "" {
+		return nil, ErrEmptyChannel
+	}
+
+	return &Bus{
+		pool:    p.Pool,
+		log:     p.Logger,
+		builder: p.Builder,
+		cfg:     p.Config,
+		broker:  newBroker(p.Config.Buffer),
+	}, nil
+}
+
+// Publish stores the event in tx, filling in its id, city and time. The
+// notification is sent by Postgres when tx commits, so events of rolled
+// back changes are never seen and events of committed ones are never lost:
+// watchers that miss the notification resume from the stored events.
+//
+// Resuming after an id relies on ids being committed in order, so the id
+// is taken under a lock held until tx ends. Publish has to be the last
+// statement of tx to keep the serialized part short.
+func (b *Bus) Publish(ctx context.Context, tx pgx.Tx, event *models.PvzEvent) error {
+	const op = "pvz.events.Bus.Publish"
+	logger := b.log.With("op", op)
+
+	// The lock is global, not per pvz: watchers of a city or of every pvz
+	// resume from a single id, so ids must follow the commit order across
+	// all pvz. It serializes the insert, the notify and the commit of
+	// every event writing transaction, so their throughput is bounded by
+	// one commit latency at a time. Statements before Publish still run
+	// concurrently. TestEventsArriveInIDOrder covers the order.
+	if _, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", b.cfg.Channel); err != nil {
+		logger.Error("failed to lock events: " + err.Error())
+		return err
+	}
+
+	query, args, err := b.builder.
+		Insert("pvz_events").
+		Columns("type", "pvz_id", "city", "reception_id", "product_id", "product_type").
+		Select(b.builder.
+			Select().
+			Column("?::text", event.Type).
+			Column("id").
+			Column("city").
+			Column("?::uuid", event.ReceptionID).
+			Column("?::uuid", event.ProductID).
+			Column("NULLIF(?, '')", string(event.ProductType)).
+			From("pvz").
+			Where(squirrel.Eq{"id": event.PvzID})).
+		Suffix("RETURNING id, city, created_at").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&event.ID, &event.City, &event.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Error("pvz with this id not exists")
+			return pvz.ErrPvzNotExists
+		}
+		logger.Error("failed to save event: " + err.Error())
+		return err
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("failed to marshal event: " + err.Error())
+		return err
+	}
+	if _, err = tx.Exec(ctx, "SELECT pg_notify($1, $2)", b.cfg.Channel, string(payload)); err != nil {
+		logger.Error("failed to notify: " + err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// Subscribe returns the live events matching filter. The channel is closed
+// when the subscriber falls behind or the bus stops; the returned func
+// must be called once the subscriber is done.
+func (b *Bus) Subscribe(filter *models.PvzEventFilter) (<-chan *models.PvzEvent, func()) {
+	return b.broker.subscribe(*filter)
+}
+
+// Replay calls fn for the stored events after filter.AfterID in id order.
+func (b *Bus) Replay(ctx context.Context, filter *models.PvzEventFilter, fn func(event *models.PvzEvent) error) error {
+	const op = "pvz.events.Bus.Replay"
+	logger := b.log.With("op", op)
+
+	afterID := filter.AfterID
+	for {
+		where := squirrel.And{squirrel.Gt{"id": afterID}}
+		if len(filter.PvzIDs) > 0 {
+			where = append(where, squirrel.Eq{"pvz_id": filter.PvzIDs})
+		}
+		if len(filter.Cities) > 0 {
+			where = append(where, squirrel.Eq{"city": filter.Cities})
+		}
+
+		query, args, err := b.builder.
+			Select(eventColumns...).
+			From("pvz_events").
+			Where(where).
+			OrderBy("id").
+			Limit(b.cfg.ReplayBatch).
+			ToSql()
+		if err != nil {
+			logger.Error("build query error: " + err.Error())
+			return err
+		}
+
+		rows, err := b.pool.Query(ctx, query, args...)
+		if err != nil {
+			logger.Error("failed to execute query: " + err.Error())
+			return err
+		}
+
+		batch := make([]*models.PvzEvent, 0, b.cfg.ReplayBatch)
+		for rows.Next() {
+			event, err := scanEvent(rows)
+			if err != nil {
+				rows.Close()
+				logger.Error("failed to scan row: " + err.Error())
+				return err
+			}
+			batch = append(batch, event)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			logger.Error("failed to read rows: " + err.Error())
+			return err
+		}
+
+		for _, event := range batch {
+			if err = fn(event); err != nil {
+				return err
+			}
+			afterID = event.ID
+		}
+		if uint64(len(batch)) < b.cfg.ReplayBatch {
+			return nil
+		}
+	}
+}
+
+// DeleteBefore removes events older than t, they can't be resumed from.
+func (b *Bus) DeleteBefore(ctx context.Context, t time.Time) (int64, error) {
+	const op = "pvz.events.Bus.DeleteBefore"
+	logger := b.log.With("op", op)
+
+	query, args, err := b.builder.
+		Delete("pvz_events").
+		Where(squirrel.Lt{"created_at": t}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return 0, err
+	}
+
+	tag, err := b.pool.Exec(ctx, query, args...)
+	if err != nil {
+		logger.Error("failed to delete events: " + err.Error())
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func scanEvent(row pgx.Row) (*models.PvzEvent, error) {
+	event := &models.PvzEvent{}
+	var productType *string
+	if err := row.Scan(
+		&event.ID,
+		&event.Type,
+		&event.PvzID,
+		&event.City,
+		&event.ReceptionID,
+		&event.ProductID,
+		&productType,
+		&event.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if productType != nil {
+		event.ProductType = models.ProductType(*productType)
+	}
+	return event, nil
+}
@@ -0,0 +1,21 @@
+package events
+
+import "time"
+
+type Config struct {
+	// Channel is the Postgres NOTIFY channel shared by all replicas.
+	Channel string `yaml:"channel" env-default:"pvz_events"`
+	// Buffer is the number of events queued per subscriber. Subscribers
+	// that fall further behind are dropped and have to resume.
+	Buffer         int           `yaml:"buffer" env-default:"256"`
+	ReplayBatch    uint64        `yaml:"replayBatch" env-default:"500"`
+	ReconnectDelay time.Duration `yaml:"reconnectDelay" env-default:"1s"`
+	// Retention is how long events are kept for resumption.
+	Retention       time.Duration `yaml:"retention" env-default:"168h"`
+	CleanupInterval time.Duration `yaml:"cleanupInterval" env-default:"1h"`
+	// Heartbeat is how often an idle SSE stream gets a comment so that
+	// proxies don't close it.
+	Heartbeat time.Duration `yaml:"heartbeat" env-default:"15s"`
+	// RetryDelay is the reconnect delay suggested to SSE clients.
+	RetryDelay time.Duration `yaml:"retryDelay" env-default:"3s"`
+}
@@ -0,0 +1,5 @@
+package events
+
+import "errors"
+
+var ErrEmptyChannel = errors.New("events channel is not configured")
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jackc/pgx/v5"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"go.uber.org/fx"
+	"log/slog"
+	"time"
+)
+
+type RunParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Bus       *Bus
+	Config    Config
+	Logger    *slog.Logger
+}
+
+// Run starts listening for events of other replicas and the periodic
+// removal of events past retention.
+func Run(p RunParams) {
+	ctx, cancel := context.WithCancel(context.Background())
+	listenDone := make(chan struct{})
+	cleanupDone := make(chan struct{})
+
+	p.Lifecycle.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(listenDone)
+				p.Bus.listen(ctx)
+			}()
+			go func() {
+				defer close(cleanupDone)
+				p.Bus.cleanup(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(context.Context) error {
+			cancel()
+			<-listenDone
+			<-cleanupDone
+			p.Bus.broker.close()
+			return nil
+		},
+	})
+}
+
+// listen keeps a dedicated connection subscribed to the channel and
+// reconnects after failures until ctx is done.
+func (b *Bus) listen(ctx context.Context) {
+	const op = "pvz.events.Bus.listen"
+	logger := b.log.With("op", op)
+
+	for {
+		err := b.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Error("listen failed, reconnecting: " + err.Error())
+		// notifications sent until the next LISTEN are lost, the dropped
+		// subscribers resume from the stored events instead
+		b.broker.reset()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(b.cfg.ReconnectDelay):
+		}
+	}
+}
+
+func (b *Bus) listenOnce(ctx context.Context) error {
+	const op = "pvz.events.Bus.listenOnce"
+	logger := b.log.With("op", op)
+
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// The connection is in LISTEN state, it must not go back to the pool.
+	pgConn := conn.Hijack()
+	defer pgConn.Close(context.Background())
+
+	if _, err = pgConn.Exec(ctx, "LISTEN "+pgx.Identifier{b.cfg.Channel}.Sanitize()); err != nil {
+		return err
+	}
+	logger.Info("listening for pvz events", "channel", b.cfg.Channel)
+
+	for {
+		n, err := pgConn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		event := &models.PvzEvent{}
+		if err = json.Unmarshal([]byte(n.Payload), event); err != nil || event.ID == 0 {
+			logger.Warn("skipping malformed notification", "payload", n.Payload)
+			continue
+		}
+		b.broker.publish(event)
+	}
+}
+
+func (b *Bus) cleanup(ctx context.Context) {
+	const op = "pvz.events.Bus.cleanup"
+	logger := b.log.With("op", op)
+
+	if b.cfg.CleanupInterval <= 0 || b.cfg.Retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(b.cfg.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := b.DeleteBefore(ctx, time.Now().Add(-b.cfg.Retention))
+			if err != nil {
+				logger.Error("failed to delete old pvz events: " + err.Error())
+				continue
+			}
+			logger.Debug("deleted old pvz events", "count", deleted)
+		}
+	}
+}
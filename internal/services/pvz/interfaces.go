@@ -3,6 +3,7 @@ package pvz
 import (
 	"context"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/marrgancovka/pvzService/internal/models"
 	"time"
 )
@@ -10,19 +11,45 @@ import (
 type Usecase interface {
 	CreatePvz(ctx context.Context, pvzData *models.Pvz) (*models.Pvz, error)
 	CreateReception(ctx context.Context, receptionData *models.ReceptionRequest) (*models.Reception, error)
-	CloseLastReceptions(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error)
+	CloseLastReceptions(ctx context.Context, pvzId uuid.UUID, ifMatch models.IfMatch) (*models.Reception, error)
 	AddProduct(ctx context.Context, product *models.ProductRequest) (*models.Product, error)
 	DeleteLastProduct(ctx context.Context, pvzId uuid.UUID) error
-	GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64) ([]*models.PvzWithReceptions, error)
-	GetPvzList(ctx context.Context) ([]*models.Pvz, error)
+	GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64, pvzIDs []uuid.UUID) ([]*models.PvzWithReceptions, error)
+	GetPvzList(ctx context.Context, pvzIDs []uuid.UUID) ([]*models.Pvz, error)
+	GetStats(ctx context.Context, filter *models.StatsFilter) ([]*models.StatsRow, error)
+	ExportReceptions(ctx context.Context, filter *models.ExportFilter, fn func(row *models.ExportRow) error) error
+	ImportPvz(ctx context.Context, rows []*models.PvzImportRow, dryRun bool) (*models.PvzImportReport, error)
+	GetPvzByID(ctx context.Context, pvzId uuid.UUID) (*models.Pvz, error)
+	UpdatePvz(ctx context.Context, pvzData *models.Pvz, ifMatch models.IfMatch) (*models.Pvz, error)
+	GetLastReception(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error)
+	WatchEvents(ctx context.Context, filter *models.PvzEventFilter, fn func(event *models.PvzEvent) error) error
+}
+
+// EventPublisher stores pvz events in the transaction of the change they
+// describe, so that an event is published if and only if the change commits.
+// Publish must be the last statement before the commit.
+type EventPublisher interface {
+	Publish(ctx context.Context, tx pgx.Tx, event *models.PvzEvent) error
+}
+
+// EventBus delivers the stored pvz events to watchers of all replicas.
+type EventBus interface {
+	Subscribe(filter *models.PvzEventFilter) (<-chan *models.PvzEvent, func())
+	Replay(ctx context.Context, filter *models.PvzEventFilter, fn func(event *models.PvzEvent) error) error
 }
 
 type Repository interface {
 	CreatePvz(ctx context.Context, pvzData *models.Pvz) (*models.Pvz, error)
 	CreateReception(ctx context.Context, receptionData *models.Reception) (*models.Reception, error)
-	CloseLastReceptions(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error)
+	CloseLastReceptions(ctx context.Context, pvzId uuid.UUID, ifMatch models.IfMatch) (*models.Reception, error)
 	AddProduct(ctx context.Context, product *models.Product, pvzID uuid.UUID) (*models.Product, error)
-	DeleteLastProduct(ctx context.Context, pvzId uuid.UUID) error
-	GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64) ([]*models.PvzWithReceptions, error)
-	GetPvzList(ctx context.Context) ([]*models.Pvz, error)
+	DeleteLastProduct(ctx context.Context, pvzId uuid.UUID) (*models.Product, error)
+	GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64, pvzIDs []uuid.UUID) ([]*models.PvzWithReceptions, error)
+	GetPvzList(ctx context.Context, pvzIDs []uuid.UUID) ([]*models.Pvz, error)
+	GetStats(ctx context.Context, filter *models.StatsFilter) ([]*models.StatsRow, error)
+	ExportReceptions(ctx context.Context, filter *models.ExportFilter, fn func(row *models.ExportRow) error) error
+	ImportPvz(ctx context.Context, pvzList []*models.Pvz, commit bool) ([]error, error)
+	GetPvzByID(ctx context.Context, pvzId uuid.UUID) (*models.Pvz, error)
+	UpdatePvz(ctx context.Context, pvzData *models.Pvz, ifMatch models.IfMatch) (*models.Pvz, error)
+	GetLastReception(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error)
 }
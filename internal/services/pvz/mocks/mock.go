@@ -15,6 +15,7 @@ import (
 	time "time"
 
 	uuid "github.com/google/uuid"
+	pgx "github.com/jackc/pgx/v5"
 	models "github.com/marrgancovka/pvzService/internal/models"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -59,18 +60,18 @@ func (mr *MockUsecaseMockRecorder) AddProduct(ctx, product any) *gomock.Call {
 }
 
 // CloseLastReceptions mocks base method.
-func (m *MockUsecase) CloseLastReceptions(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error) {
+func (m *MockUsecase) CloseLastReceptions(ctx context.Context, pvzId uuid.UUID, ifMatch models.IfMatch) (*models.Reception, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CloseLastReceptions", ctx, pvzId)
+	ret := m.ctrl.Call(m, "CloseLastReceptions", ctx, pvzId, ifMatch)
 	ret0, _ := ret[0].(*models.Reception)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CloseLastReceptions indicates an expected call of CloseLastReceptions.
-func (mr *MockUsecaseMockRecorder) CloseLastReceptions(ctx, pvzId any) *gomock.Call {
+func (mr *MockUsecaseMockRecorder) CloseLastReceptions(ctx, pvzId, ifMatch any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseLastReceptions", reflect.TypeOf((*MockUsecase)(nil).CloseLastReceptions), ctx, pvzId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseLastReceptions", reflect.TypeOf((*MockUsecase)(nil).CloseLastReceptions), ctx, pvzId, ifMatch)
 }
 
 // CreatePvz mocks base method.
@@ -117,34 +118,228 @@ func (mr *MockUsecaseMockRecorder) DeleteLastProduct(ctx, pvzId any) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLastProduct", reflect.TypeOf((*MockUsecase)(nil).DeleteLastProduct), ctx, pvzId)
 }
 
+// ExportReceptions mocks base method.
+func (m *MockUsecase) ExportReceptions(ctx context.Context, filter *models.ExportFilter, fn func(*models.ExportRow) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportReceptions", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportReceptions indicates an expected call of ExportReceptions.
+func (mr *MockUsecaseMockRecorder) ExportReceptions(ctx, filter, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportReceptions", reflect.TypeOf((*MockUsecase)(nil).ExportReceptions), ctx, filter, fn)
+}
+
+// GetLastReception mocks base method.
+func (m *MockUsecase) GetLastReception(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastReception", ctx, pvzId)
+	ret0, _ := ret[0].(*models.Reception)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastReception indicates an expected call of GetLastReception.
+func (mr *MockUsecaseMockRecorder) GetLastReception(ctx, pvzId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastReception", reflect.TypeOf((*MockUsecase)(nil).GetLastReception), ctx, pvzId)
+}
+
 // GetPvz mocks base method.
-func (m *MockUsecase) GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64) ([]*models.PvzWithReceptions, error) {
+func (m *MockUsecase) GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64, pvzIDs []uuid.UUID) ([]*models.PvzWithReceptions, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPvz", ctx, startDate, endDate, limit, page)
+	ret := m.ctrl.Call(m, "GetPvz", ctx, startDate, endDate, limit, page, pvzIDs)
 	ret0, _ := ret[0].([]*models.PvzWithReceptions)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPvz indicates an expected call of GetPvz.
-func (mr *MockUsecaseMockRecorder) GetPvz(ctx, startDate, endDate, limit, page any) *gomock.Call {
+func (mr *MockUsecaseMockRecorder) GetPvz(ctx, startDate, endDate, limit, page, pvzIDs any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPvz", reflect.TypeOf((*MockUsecase)(nil).GetPvz), ctx, startDate, endDate, limit, page)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPvz", reflect.TypeOf((*MockUsecase)(nil).GetPvz), ctx, startDate, endDate, limit, page, pvzIDs)
+}
+
+// GetPvzByID mocks base method.
+func (m *MockUsecase) GetPvzByID(ctx context.Context, pvzId uuid.UUID) (*models.Pvz, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPvzByID", ctx, pvzId)
+	ret0, _ := ret[0].(*models.Pvz)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPvzByID indicates an expected call of GetPvzByID.
+func (mr *MockUsecaseMockRecorder) GetPvzByID(ctx, pvzId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPvzByID", reflect.TypeOf((*MockUsecase)(nil).GetPvzByID), ctx, pvzId)
 }
 
 // GetPvzList mocks base method.
-func (m *MockUsecase) GetPvzList(ctx context.Context) ([]*models.Pvz, error) {
+func (m *MockUsecase) GetPvzList(ctx context.Context, pvzIDs []uuid.UUID) ([]*models.Pvz, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPvzList", ctx)
+	ret := m.ctrl.Call(m, "GetPvzList", ctx, pvzIDs)
 	ret0, _ := ret[0].([]*models.Pvz)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPvzList indicates an expected call of GetPvzList.
-func (mr *MockUsecaseMockRecorder) GetPvzList(ctx any) *gomock.Call {
+func (mr *MockUsecaseMockRecorder) GetPvzList(ctx, pvzIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPvzList", reflect.TypeOf((*MockUsecase)(nil).GetPvzList), ctx, pvzIDs)
+}
+
+// GetStats mocks base method.
+func (m *MockUsecase) GetStats(ctx context.Context, filter *models.StatsFilter) ([]*models.StatsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", ctx, filter)
+	ret0, _ := ret[0].([]*models.StatsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockUsecaseMockRecorder) GetStats(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockUsecase)(nil).GetStats), ctx, filter)
+}
+
+// ImportPvz mocks base method.
+func (m *MockUsecase) ImportPvz(ctx context.Context, rows []*models.PvzImportRow, dryRun bool) (*models.PvzImportReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportPvz", ctx, rows, dryRun)
+	ret0, _ := ret[0].(*models.PvzImportReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportPvz indicates an expected call of ImportPvz.
+func (mr *MockUsecaseMockRecorder) ImportPvz(ctx, rows, dryRun any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportPvz", reflect.TypeOf((*MockUsecase)(nil).ImportPvz), ctx, rows, dryRun)
+}
+
+// UpdatePvz mocks base method.
+func (m *MockUsecase) UpdatePvz(ctx context.Context, pvzData *models.Pvz, ifMatch models.IfMatch) (*models.Pvz, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePvz", ctx, pvzData, ifMatch)
+	ret0, _ := ret[0].(*models.Pvz)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePvz indicates an expected call of UpdatePvz.
+func (mr *MockUsecaseMockRecorder) UpdatePvz(ctx, pvzData, ifMatch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePvz", reflect.TypeOf((*MockUsecase)(nil).UpdatePvz), ctx, pvzData, ifMatch)
+}
+
+// WatchEvents mocks base method.
+func (m *MockUsecase) WatchEvents(ctx context.Context, filter *models.PvzEventFilter, fn func(*models.PvzEvent) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchEvents", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WatchEvents indicates an expected call of WatchEvents.
+func (mr *MockUsecaseMockRecorder) WatchEvents(ctx, filter, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchEvents", reflect.TypeOf((*MockUsecase)(nil).WatchEvents), ctx, filter, fn)
+}
+
+// MockEventPublisher is a mock of EventPublisher interface.
+type MockEventPublisher struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventPublisherMockRecorder
+	isgomock struct{}
+}
+
+// MockEventPublisherMockRecorder is the mock recorder for MockEventPublisher.
+type MockEventPublisherMockRecorder struct {
+	mock *MockEventPublisher
+}
+
+// NewMockEventPublisher creates a new mock instance.
+func NewMockEventPublisher(ctrl *gomock.Controller) *MockEventPublisher {
+	mock := &MockEventPublisher{ctrl: ctrl}
+	mock.recorder = &MockEventPublisherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventPublisher) EXPECT() *MockEventPublisherMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockEventPublisher) Publish(ctx context.Context, tx pgx.Tx, event *models.PvzEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, tx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockEventPublisherMockRecorder) Publish(ctx, tx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockEventPublisher)(nil).Publish), ctx, tx, event)
+}
+
+// MockEventBus is a mock of EventBus interface.
+type MockEventBus struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventBusMockRecorder
+	isgomock struct{}
+}
+
+// MockEventBusMockRecorder is the mock recorder for MockEventBus.
+type MockEventBusMockRecorder struct {
+	mock *MockEventBus
+}
+
+// NewMockEventBus creates a new mock instance.
+func NewMockEventBus(ctrl *gomock.Controller) *MockEventBus {
+	mock := &MockEventBus{ctrl: ctrl}
+	mock.recorder = &MockEventBusMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventBus) EXPECT() *MockEventBusMockRecorder {
+	return m.recorder
+}
+
+// Replay mocks base method.
+func (m *MockEventBus) Replay(ctx context.Context, filter *models.PvzEventFilter, fn func(*models.PvzEvent) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replay", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Replay indicates an expected call of Replay.
+func (mr *MockEventBusMockRecorder) Replay(ctx, filter, fn any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPvzList", reflect.TypeOf((*MockUsecase)(nil).GetPvzList), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replay", reflect.TypeOf((*MockEventBus)(nil).Replay), ctx, filter, fn)
+}
+
+// Subscribe mocks base method.
+func (m *MockEventBus) Subscribe(filter *models.PvzEventFilter) (<-chan *models.PvzEvent, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", filter)
+	ret0, _ := ret[0].(<-chan *models.PvzEvent)
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockEventBusMockRecorder) Subscribe(filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockEventBus)(nil).Subscribe), filter)
 }
 
 // MockRepository is a mock of Repository interface.
@@ -187,18 +382,18 @@ func (mr *MockRepositoryMockRecorder) AddProduct(ctx, product, pvzID any) *gomoc
 }
 
 // CloseLastReceptions mocks base method.
-func (m *MockRepository) CloseLastReceptions(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error) {
+func (m *MockRepository) CloseLastReceptions(ctx context.Context, pvzId uuid.UUID, ifMatch models.IfMatch) (*models.Reception, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CloseLastReceptions", ctx, pvzId)
+	ret := m.ctrl.Call(m, "CloseLastReceptions", ctx, pvzId, ifMatch)
 	ret0, _ := ret[0].(*models.Reception)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CloseLastReceptions indicates an expected call of CloseLastReceptions.
-func (mr *MockRepositoryMockRecorder) CloseLastReceptions(ctx, pvzId any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CloseLastReceptions(ctx, pvzId, ifMatch any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseLastReceptions", reflect.TypeOf((*MockRepository)(nil).CloseLastReceptions), ctx, pvzId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseLastReceptions", reflect.TypeOf((*MockRepository)(nil).CloseLastReceptions), ctx, pvzId, ifMatch)
 }
 
 // CreatePvz mocks base method.
@@ -232,11 +427,12 @@ func (mr *MockRepositoryMockRecorder) CreateReception(ctx, receptionData any) *g
 }
 
 // DeleteLastProduct mocks base method.
-func (m *MockRepository) DeleteLastProduct(ctx context.Context, pvzId uuid.UUID) error {
+func (m *MockRepository) DeleteLastProduct(ctx context.Context, pvzId uuid.UUID) (*models.Product, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteLastProduct", ctx, pvzId)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(*models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DeleteLastProduct indicates an expected call of DeleteLastProduct.
@@ -245,32 +441,121 @@ func (mr *MockRepositoryMockRecorder) DeleteLastProduct(ctx, pvzId any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLastProduct", reflect.TypeOf((*MockRepository)(nil).DeleteLastProduct), ctx, pvzId)
 }
 
+// ExportReceptions mocks base method.
+func (m *MockRepository) ExportReceptions(ctx context.Context, filter *models.ExportFilter, fn func(*models.ExportRow) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportReceptions", ctx, filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ExportReceptions indicates an expected call of ExportReceptions.
+func (mr *MockRepositoryMockRecorder) ExportReceptions(ctx, filter, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportReceptions", reflect.TypeOf((*MockRepository)(nil).ExportReceptions), ctx, filter, fn)
+}
+
+// GetLastReception mocks base method.
+func (m *MockRepository) GetLastReception(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastReception", ctx, pvzId)
+	ret0, _ := ret[0].(*models.Reception)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastReception indicates an expected call of GetLastReception.
+func (mr *MockRepositoryMockRecorder) GetLastReception(ctx, pvzId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastReception", reflect.TypeOf((*MockRepository)(nil).GetLastReception), ctx, pvzId)
+}
+
 // GetPvz mocks base method.
-func (m *MockRepository) GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64) ([]*models.PvzWithReceptions, error) {
+func (m *MockRepository) GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64, pvzIDs []uuid.UUID) ([]*models.PvzWithReceptions, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPvz", ctx, startDate, endDate, limit, page)
+	ret := m.ctrl.Call(m, "GetPvz", ctx, startDate, endDate, limit, page, pvzIDs)
 	ret0, _ := ret[0].([]*models.PvzWithReceptions)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPvz indicates an expected call of GetPvz.
-func (mr *MockRepositoryMockRecorder) GetPvz(ctx, startDate, endDate, limit, page any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetPvz(ctx, startDate, endDate, limit, page, pvzIDs any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPvz", reflect.TypeOf((*MockRepository)(nil).GetPvz), ctx, startDate, endDate, limit, page)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPvz", reflect.TypeOf((*MockRepository)(nil).GetPvz), ctx, startDate, endDate, limit, page, pvzIDs)
+}
+
+// GetPvzByID mocks base method.
+func (m *MockRepository) GetPvzByID(ctx context.Context, pvzId uuid.UUID) (*models.Pvz, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPvzByID", ctx, pvzId)
+	ret0, _ := ret[0].(*models.Pvz)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPvzByID indicates an expected call of GetPvzByID.
+func (mr *MockRepositoryMockRecorder) GetPvzByID(ctx, pvzId any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPvzByID", reflect.TypeOf((*MockRepository)(nil).GetPvzByID), ctx, pvzId)
 }
 
 // GetPvzList mocks base method.
-func (m *MockRepository) GetPvzList(ctx context.Context) ([]*models.Pvz, error) {
+func (m *MockRepository) GetPvzList(ctx context.Context, pvzIDs []uuid.UUID) ([]*models.Pvz, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPvzList", ctx)
+	ret := m.ctrl.Call(m, "GetPvzList", ctx, pvzIDs)
 	ret0, _ := ret[0].([]*models.Pvz)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPvzList indicates an expected call of GetPvzList.
-func (mr *MockRepositoryMockRecorder) GetPvzList(ctx any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetPvzList(ctx, pvzIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPvzList", reflect.TypeOf((*MockRepository)(nil).GetPvzList), ctx, pvzIDs)
+}
+
+// GetStats mocks base method.
+func (m *MockRepository) GetStats(ctx context.Context, filter *models.StatsFilter) ([]*models.StatsRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStats", ctx, filter)
+	ret0, _ := ret[0].([]*models.StatsRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStats indicates an expected call of GetStats.
+func (mr *MockRepositoryMockRecorder) GetStats(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStats", reflect.TypeOf((*MockRepository)(nil).GetStats), ctx, filter)
+}
+
+// ImportPvz mocks base method.
+func (m *MockRepository) ImportPvz(ctx context.Context, pvzList []*models.Pvz, commit bool) ([]error, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportPvz", ctx, pvzList, commit)
+	ret0, _ := ret[0].([]error)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportPvz indicates an expected call of ImportPvz.
+func (mr *MockRepositoryMockRecorder) ImportPvz(ctx, pvzList, commit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportPvz", reflect.TypeOf((*MockRepository)(nil).ImportPvz), ctx, pvzList, commit)
+}
+
+// UpdatePvz mocks base method.
+func (m *MockRepository) UpdatePvz(ctx context.Context, pvzData *models.Pvz, ifMatch models.IfMatch) (*models.Pvz, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePvz", ctx, pvzData, ifMatch)
+	ret0, _ := ret[0].(*models.Pvz)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePvz indicates an expected call of UpdatePvz.
+func (mr *MockRepositoryMockRecorder) UpdatePvz(ctx, pvzData, ifMatch any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPvzList", reflect.TypeOf((*MockRepository)(nil).GetPvzList), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePvz", reflect.TypeOf((*MockRepository)(nil).UpdatePvz), ctx, pvzData, ifMatch)
 }
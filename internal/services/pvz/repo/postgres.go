@@ -4,13 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
 	"github.com/marrgancovka/pvzService/internal/services/pvz"
+	"go.opentelemetry.io/otel/attribute"
 	"go.uber.org/fx"
 	"log/slog"
 	"time"
@@ -21,18 +25,25 @@ const (
 	PgErrViolatesForeignKeyConstraint = "23503"
 )
 
+// querier is implemented by both the pool and a transaction.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type Params struct {
 	fx.In
 
 	Pool    *pgxpool.Pool
 	Logger  *slog.Logger
 	Builder squirrel.StatementBuilderType
+	Events  pvz.EventPublisher
 }
 
 type Repository struct {
 	pool    *pgxpool.Pool
 	log     *slog.Logger
 	builder squirrel.StatementBuilderType
+	events  pvz.EventPublisher
 }
 
 func NewRepository(params Params) *Repository {
@@ -40,18 +51,19 @@ func NewRepository(params Params) *Repository {
 		pool:    params.Pool,
 		log:     params.Logger,
 		builder: params.Builder,
+		events:  params.Events,
 	}
 }
 
 func (repo *Repository) CreatePvz(ctx context.Context, pvzData *models.Pvz) (*models.Pvz, error) {
 	const op = "pvz.Repository.CreatePvz"
-	logger := repo.log.With("op", op)
+	logger := logctx.From(ctx, repo.log).With("op", op)
 
 	query, args, err := repo.builder.
 		Insert("pvz").
-		Columns("id", "registration_date", "city").
-		Values(pvzData.ID, pvzData.RegistrationDate, pvzData.City).
-		Suffix("RETURNING id, registration_date, city").
+		Columns("id", "registration_date", "city", "address").
+		Values(pvzData.ID, pvzData.RegistrationDate, pvzData.City, pvzData.Address).
+		Suffix("RETURNING id, registration_date, city, address, version").
 		ToSql()
 	if err != nil {
 		logger.Error("build query error: " + err.Error())
@@ -63,6 +75,8 @@ func (repo *Repository) CreatePvz(ctx context.Context, pvzData *models.Pvz) (*mo
 		&createdPvz.ID,
 		&createdPvz.RegistrationDate,
 		&createdPvz.City,
+		&createdPvz.Address,
+		&createdPvz.Version,
 	); err != nil {
 		pgErr := &pgconn.PgError{}
 		if errors.As(err, &pgErr) && pgErr.Code == PgErrCodeAlreadyExists {
@@ -78,7 +92,7 @@ func (repo *Repository) CreatePvz(ctx context.Context, pvzData *models.Pvz) (*mo
 
 func (repo *Repository) CreateReception(ctx context.Context, receptionData *models.Reception) (*models.Reception, error) {
 	const op = "pvz.Repository.CreateReception"
-	logger := repo.log.With("op", op)
+	logger := logctx.From(ctx, repo.log).With("op", op)
 
 	tx, err := repo.pool.Begin(ctx)
 	if err != nil {
@@ -89,7 +103,7 @@ func (repo *Repository) CreateReception(ctx context.Context, receptionData *mode
 
 	_, err = repo.getLastInProgressReceptionID(ctx, tx, receptionData.PvzID)
 	if err == nil {
-		logger.Error("failed to get last in-progress reception id: " + err.Error())
+		logger.Error("pvz already has an in-progress reception")
 		return nil, pvz.ErrNoClosedReception
 	}
 
@@ -97,7 +111,7 @@ func (repo *Repository) CreateReception(ctx context.Context, receptionData *mode
 		Insert("receptions").
 		Columns("id", "date_time", "pvz_id", "status").
 		Values(receptionData.ID, receptionData.DateTime, receptionData.PvzID, receptionData.Status).
-		Suffix("RETURNING id, date_time, pvz_id, status").
+		Suffix("RETURNING id, date_time, pvz_id, status, version").
 		ToSql()
 	if err != nil {
 		logger.Error("build query error: " + err.Error())
@@ -110,6 +124,7 @@ func (repo *Repository) CreateReception(ctx context.Context, receptionData *mode
 		&createdReception.DateTime,
 		&createdReception.PvzID,
 		&createdReception.Status,
+		&createdReception.Version,
 	); err != nil {
 		pgErr := &pgconn.PgError{}
 		if errors.As(err, &pgErr) && pgErr.Code == PgErrViolatesForeignKeyConstraint {
@@ -120,6 +135,14 @@ func (repo *Repository) CreateReception(ctx context.Context, receptionData *mode
 		return nil, err
 	}
 
+	if err = repo.events.Publish(ctx, tx, &models.PvzEvent{
+		Type:        models.EventReceptionOpened,
+		PvzID:       createdReception.PvzID,
+		ReceptionID: createdReception.ID,
+	}); err != nil {
+		return nil, err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		logger.Error("failed to commit transaction: " + err.Error())
 		return nil, err
@@ -128,18 +151,60 @@ func (repo *Repository) CreateReception(ctx context.Context, receptionData *mode
 	return createdReception, nil
 }
 
-func (repo *Repository) CloseLastReceptions(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error) {
+func (repo *Repository) CloseLastReceptions(ctx context.Context, pvzId uuid.UUID, ifMatch models.IfMatch) (*models.Reception, error) {
 	const op = "pvz.Repository.CloseLastReceptions"
-	logger := repo.log.With("op", op)
+	logger := logctx.From(ctx, repo.log).With("op", op)
 
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		logger.Error("failed to begin transaction: " + err.Error())
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// the open reception is locked before the precondition is checked, so
+	// "*" and every listed version are compared with the version being closed
 	query, args, err := repo.builder.
+		Select("id", "version").
+		From("receptions").
+		Where(squirrel.Eq{"pvz_id": pvzId, "status": models.StatusInProgress}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	var receptionID uuid.UUID
+	var version int64
+	if err = tx.QueryRow(ctx, query, args...).Scan(&receptionID, &version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			// the precondition goes first: a reception closed or changed
+			// since the client read it is a stale version, not a bad request
+			if !ifMatch.Any {
+				if last, lookupErr := repo.GetLastReception(ctx, pvzId); lookupErr == nil && !ifMatch.Matches(last.Version) {
+					logger.Warn("reception version mismatch", "pvz_id", pvzId, "version", last.Version)
+					return nil, pvz.ErrVersionMismatch
+				}
+			}
+			logger.Error("in this pvz open reception not exists")
+			return nil, pvz.ErrNoOpenReception
+		}
+		logger.Error("failed to get open reception: " + err.Error())
+		return nil, err
+	}
+	if !ifMatch.Matches(version) {
+		logger.Warn("reception version mismatch", "pvz_id", pvzId, "version", version)
+		return nil, pvz.ErrVersionMismatch
+	}
+
+	query, args, err = repo.builder.
 		Update("receptions").
 		Set("status", models.StatusClose).
-		Where(squirrel.And{
-			squirrel.Eq{"pvz_id": pvzId},
-			squirrel.Eq{"status": models.StatusInProgress},
-		}).
-		Suffix("RETURNING id, date_time, pvz_id, status").
+		Set("closed_at", squirrel.Expr("now()")).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": receptionID}).
+		Suffix("RETURNING id, date_time, pvz_id, status, closed_at, version").
 		ToSql()
 	if err != nil {
 		logger.Error("build query error: " + err.Error())
@@ -147,26 +212,37 @@ func (repo *Repository) CloseLastReceptions(ctx context.Context, pvzId uuid.UUID
 	}
 
 	closedReception := &models.Reception{}
-	if err = repo.pool.QueryRow(ctx, query, args...).Scan(
+	if err = tx.QueryRow(ctx, query, args...).Scan(
 		&closedReception.ID,
 		&closedReception.DateTime,
 		&closedReception.PvzID,
 		&closedReception.Status,
+		&closedReception.ClosedAt,
+		&closedReception.Version,
 	); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			logger.Error("in this pvz open reception not exists")
-			return nil, pvz.ErrNoOpenReception
-		}
 		logger.Error("failed to update status: " + err.Error())
 		return nil, err
 	}
 
+	if err = repo.events.Publish(ctx, tx, &models.PvzEvent{
+		Type:        models.EventReceptionClosed,
+		PvzID:       closedReception.PvzID,
+		ReceptionID: closedReception.ID,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("failed to commit transaction: " + err.Error())
+		return nil, err
+	}
+
 	return closedReception, nil
 }
 
 func (repo *Repository) AddProduct(ctx context.Context, product *models.Product, pvzID uuid.UUID) (*models.Product, error) {
 	const op = "pvz.Repository.AddProduct"
-	logger := repo.log.With("op", op)
+	logger := logctx.From(ctx, repo.log).With("op", op)
 
 	tx, err := repo.pool.Begin(ctx)
 	if err != nil {
@@ -201,6 +277,20 @@ func (repo *Repository) AddProduct(ctx context.Context, product *models.Product,
 		return nil, err
 	}
 
+	if err = repo.bumpReceptionVersion(ctx, tx, inProgressReceptionID); err != nil {
+		return nil, err
+	}
+
+	if err = repo.events.Publish(ctx, tx, &models.PvzEvent{
+		Type:        models.EventProductAdded,
+		PvzID:       pvzID,
+		ReceptionID: product.ReceptionID,
+		ProductID:   &product.ID,
+		ProductType: product.Type,
+	}); err != nil {
+		return nil, err
+	}
+
 	if err = tx.Commit(ctx); err != nil {
 		logger.Error("failed to commit transaction: " + err.Error())
 		return nil, err
@@ -209,20 +299,20 @@ func (repo *Repository) AddProduct(ctx context.Context, product *models.Product,
 	return product, nil
 }
 
-func (repo *Repository) DeleteLastProduct(ctx context.Context, pvzID uuid.UUID) error {
+func (repo *Repository) DeleteLastProduct(ctx context.Context, pvzID uuid.UUID) (*models.Product, error) {
 	const op = "pvz.Repository.DeleteLastProduct"
-	logger := repo.log.With("op", op)
+	logger := logctx.From(ctx, repo.log).With("op", op)
 
 	tx, err := repo.pool.Begin(ctx)
 	if err != nil {
 		logger.Error("failed to begin transaction: " + err.Error())
-		return err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
 	inProgressReceptionID, err := repo.getLastInProgressReceptionID(ctx, tx, pvzID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	query, args, err := repo.builder.
@@ -234,44 +324,88 @@ func (repo *Repository) DeleteLastProduct(ctx context.Context, pvzID uuid.UUID)
 		ToSql()
 	if err != nil {
 		logger.Error("build query error: " + err.Error())
-		return err
+		return nil, err
 	}
 
 	productId := uuid.Nil
 	if err = tx.QueryRow(ctx, query, args...).Scan(&productId); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			logger.Error("no products in this reception")
-			return pvz.ErrNoProduct
+			return nil, pvz.ErrNoProduct
 		}
 		logger.Error("failed to found product: " + err.Error())
-		return err
+		return nil, err
 	}
 
 	query, args, err = repo.builder.
 		Delete("products").
 		Where(squirrel.Eq{"id": productId}).
+		Suffix("RETURNING id, date_time, type, reception_id").
 		ToSql()
 	if err != nil {
 		logger.Error("build query error: " + err.Error())
-		return err
+		return nil, err
 	}
 
-	if _, err = tx.Exec(ctx, query, args...); err != nil {
+	deletedProduct := &models.Product{}
+	if err = tx.QueryRow(ctx, query, args...).Scan(
+		&deletedProduct.ID,
+		&deletedProduct.DateTime,
+		&deletedProduct.Type,
+		&deletedProduct.ReceptionID,
+	); err != nil {
 		logger.Error("failed to delete product: " + err.Error())
-		return err
+		return nil, err
+	}
+
+	if err = repo.bumpReceptionVersion(ctx, tx, inProgressReceptionID); err != nil {
+		return nil, err
+	}
+
+	if err = repo.events.Publish(ctx, tx, &models.PvzEvent{
+		Type:        models.EventProductRemoved,
+		PvzID:       pvzID,
+		ReceptionID: deletedProduct.ReceptionID,
+		ProductID:   &deletedProduct.ID,
+		ProductType: deletedProduct.Type,
+	}); err != nil {
+		return nil, err
 	}
 
 	if err = tx.Commit(ctx); err != nil {
 		logger.Error("failed to commit transaction: " + err.Error())
+		return nil, err
+	}
+
+	return deletedProduct, nil
+}
+
+// bumpReceptionVersion marks the reception as changed when its products
+// change, so that an If-Match taken before fails.
+func (repo *Repository) bumpReceptionVersion(ctx context.Context, tx pgx.Tx, receptionID uuid.UUID) error {
+	const op = "pvz.Repository.bumpReceptionVersion"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Update("receptions").
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": receptionID}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
 		return err
 	}
 
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		logger.Error("failed to bump reception version: " + err.Error())
+		return err
+	}
 	return nil
 }
 
-func (repo *Repository) getLastInProgressReceptionID(ctx context.Context, tx pgx.Tx, pvzID uuid.UUID) (uuid.UUID, error) {
+func (repo *Repository) getLastInProgressReceptionID(ctx context.Context, tx querier, pvzID uuid.UUID) (uuid.UUID, error) {
 	const op = "pvz.Repository.getLastInProgressReceptionID"
-	logger := repo.log.With("op", op)
+	logger := logctx.From(ctx, repo.log).With("op", op)
 
 	query, args, err := repo.builder.
 		Select("id").
@@ -299,12 +433,15 @@ func (repo *Repository) getLastInProgressReceptionID(ctx context.Context, tx pgx
 	return receptionID, nil
 }
 
-func (repo *Repository) GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64) ([]*models.PvzWithReceptions, error) {
+// GetPvz returns a page of pvz with their receptions, restricted to pvzIDs
+// unless it is empty.
+func (repo *Repository) GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64, pvzIDs []uuid.UUID) ([]*models.PvzWithReceptions, error) {
 	offset := (page - 1) * limit
 	query := `SELECT
     p.id,
     p.registration_date,
     p.city,
+    p.address,
     COALESCE(
             (
                 SELECT json_agg(
@@ -313,7 +450,8 @@ func (repo *Repository) GetPvz(ctx context.Context, startDate, endDate time.Time
                                        'id', r.id,
                                        'dateTime', r.date_time,
                                        'pvzId', r.pvz_id,
-                                       'status', r.status
+                                       'status', r.status,
+                                       'closedAt', r.closed_at
                                                     ),
                                        'products', COALESCE((
                                            SELECT json_agg(
@@ -336,57 +474,77 @@ func (repo *Repository) GetPvz(ctx context.Context, startDate, endDate time.Time
             '[]'::json
     ) AS receptions_json
 FROM pvz p
+WHERE cardinality($5::uuid[]) = 0 OR p.id = ANY($5)
 ORDER BY registration_date DESC
 LIMIT $3 OFFSET $4;`
 
-	rows, err := repo.pool.Query(ctx, query, startDate, endDate, limit, offset)
+	if pvzIDs == nil {
+		pvzIDs = []uuid.UUID{}
+	}
+	rows, err := repo.pool.Query(ctx, query, startDate, endDate, limit, offset, pvzIDs)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	var result []*models.PvzWithReceptions
+	var receptionsJSON [][]byte
 	for rows.Next() {
 		pvzWithReceptions := &models.PvzWithReceptions{}
-		var receptionsJSON []byte
+		var receptions []byte
 
 		err = rows.Scan(
 			&pvzWithReceptions.ID,
 			&pvzWithReceptions.RegistrationDate,
 			&pvzWithReceptions.City,
-			&receptionsJSON,
+			&pvzWithReceptions.Address,
+			&receptions,
 		)
 		if err != nil {
 			return nil, err
 		}
 
+		result = append(result, pvzWithReceptions)
+		receptionsJSON = append(receptionsJSON, receptions)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	// the query span ends with the rows, decoding gets its own span so that
+	// both show up separately in a trace
+	rows.Close()
+
+	_, span := tracing.Start(ctx, "pvz.Repository.GetPvz.decode", attribute.Int("pvz.count", len(result)))
+	defer span.End()
+
+	for i, pvzWithReceptions := range result {
 		var receptionWithProducts []*models.ReceptionWithProducts
-		if err = json.Unmarshal(receptionsJSON, &receptionWithProducts); err != nil {
+		if err = json.Unmarshal(receptionsJSON[i], &receptionWithProducts); err != nil {
 			return nil, err
 		}
-
 		pvzWithReceptions.Receptions = receptionWithProducts
-
-		result = append(result, pvzWithReceptions)
 	}
 	return result, nil
 }
 
-func (repo *Repository) GetPvzList(ctx context.Context) ([]*models.Pvz, error) {
+func (repo *Repository) GetPvzList(ctx context.Context, pvzIDs []uuid.UUID) ([]*models.Pvz, error) {
 	const op = "pvz.Repository.GetPvzList"
-	logger := repo.log.With("op", op)
+	logger := logctx.From(ctx, repo.log).With("op", op)
 
-	query, _, err := repo.builder.
-		Select("id", "registration_date", "city").
+	builder := repo.builder.
+		Select("id", "registration_date", "city", "address").
 		From("pvz").
-		OrderBy("registration_date").
-		ToSql()
+		OrderBy("registration_date")
+	if len(pvzIDs) > 0 {
+		builder = builder.Where(squirrel.Eq{"id": pvzIDs})
+	}
+	query, args, err := builder.ToSql()
 	if err != nil {
 		logger.Error("failed to build query: " + err.Error())
 		return nil, err
 	}
 
-	rows, err := repo.pool.Query(ctx, query)
+	rows, err := repo.pool.Query(ctx, query, args...)
 	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		logger.Error("failed to execute query: " + err.Error())
 		return nil, err
@@ -396,7 +554,7 @@ func (repo *Repository) GetPvzList(ctx context.Context) ([]*models.Pvz, error) {
 	var results []*models.Pvz
 	for rows.Next() {
 		row := &models.Pvz{}
-		if err = rows.Scan(&row.ID, &row.RegistrationDate, &row.City); err != nil {
+		if err = rows.Scan(&row.ID, &row.RegistrationDate, &row.City, &row.Address); err != nil {
 			logger.Error("failed to scan row: " + err.Error())
 			return nil, err
 		}
@@ -405,3 +563,428 @@ func (repo *Repository) GetPvzList(ctx context.Context) ([]*models.Pvz, error) {
 
 	return results, nil
 }
+
+var statsPeriodColumns = map[models.StatsPeriod]string{
+	models.PeriodDay:   "date_trunc('day', s.date_time) AS period",
+	models.PeriodWeek:  "date_trunc('week', s.date_time) AS period",
+	models.PeriodMonth: "date_trunc('month', s.date_time) AS period",
+}
+
+func (repo *Repository) GetStats(ctx context.Context, filter *models.StatsFilter) ([]*models.StatsRow, error) {
+	const op = "pvz.Repository.GetStats"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	groupByPvz, groupByCity, groupByType := false, false, false
+	for _, group := range filter.GroupBy {
+		switch group {
+		case models.GroupByPvz:
+			groupByPvz = true
+		case models.GroupByCity:
+			groupByCity = true
+		case models.GroupByProductType:
+			groupByType = true
+		}
+	}
+
+	// receptions are aggregated first so that durations are not weighted by the number of products
+	receptions := squirrel.Select(
+		"r.id", "r.pvz_id", "p.city", "r.date_time", "r.closed_at", "COUNT(pr.id) AS products_count",
+	).
+		From("receptions r").
+		Join("pvz p ON p.id = r.pvz_id").
+		LeftJoin("products pr ON pr.reception_id = r.id").
+		Where(squirrel.Expr("r.date_time BETWEEN ? AND ?", filter.StartDate, filter.EndDate)).
+		GroupBy("r.id", "p.id")
+	if filter.City != "" {
+		receptions = receptions.Where(squirrel.Eq{"p.city": filter.City})
+	}
+	if filter.PvzID != uuid.Nil {
+		receptions = receptions.Where(squirrel.Eq{"r.pvz_id": filter.PvzID})
+	}
+	if len(filter.PvzIDs) > 0 {
+		receptions = receptions.Where(squirrel.Eq{"r.pvz_id": filter.PvzIDs})
+	}
+	if groupByType {
+		receptions = receptions.Column("pr.type AS product_type").GroupBy("pr.type")
+	}
+
+	columns := []string{statsPeriodColumns[filter.Period]}
+	groupBy := []string{"1"}
+	if groupByPvz {
+		columns = append(columns, "s.pvz_id")
+		groupBy = append(groupBy, "s.pvz_id")
+	}
+	if groupByCity {
+		columns = append(columns, "s.city")
+		groupBy = append(groupBy, "s.city")
+	}
+	if groupByType {
+		columns = append(columns, "COALESCE(s.product_type, '')")
+		groupBy = append(groupBy, "s.product_type")
+	}
+	columns = append(columns,
+		"COUNT(DISTINCT s.id)",
+		"SUM(s.products_count)::bigint",
+		"EXTRACT(EPOCH FROM AVG(s.closed_at - s.date_time))::float8",
+		"SUM(s.products_count)::float8 / COUNT(DISTINCT s.id)",
+	)
+
+	query, args, err := repo.builder.
+		Select(columns...).
+		FromSelect(receptions, "s").
+		GroupBy(groupBy...).
+		OrderBy(groupBy...).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	rows, err := repo.pool.Query(ctx, query, args...)
+	if err != nil {
+		logger.Error("failed to execute query: " + err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*models.StatsRow
+	for rows.Next() {
+		row := &models.StatsRow{}
+		dest := []any{&row.Period}
+		if groupByPvz {
+			row.PvzID = &uuid.UUID{}
+			dest = append(dest, row.PvzID)
+		}
+		if groupByCity {
+			dest = append(dest, &row.City)
+		}
+		if groupByType {
+			dest = append(dest, &row.ProductType)
+		}
+		dest = append(dest,
+			&row.ReceptionsCount,
+			&row.ProductsCount,
+			&row.AvgReceptionDurationSeconds,
+			&row.ProductsPerReception,
+		)
+
+		if err = rows.Scan(dest...); err != nil {
+			logger.Error("failed to scan row: " + err.Error())
+			return nil, err
+		}
+		results = append(results, row)
+	}
+	if err = rows.Err(); err != nil {
+		logger.Error("failed to read rows: " + err.Error())
+		return nil, err
+	}
+
+	return results, nil
+}
+
+const exportFetchSize = 1000
+
+func (repo *Repository) ExportReceptions(ctx context.Context, filter *models.ExportFilter, fn func(row *models.ExportRow) error) error {
+	const op = "pvz.Repository.ExportReceptions"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query := repo.builder.
+		Select(
+			"p.id", "p.city", "p.registration_date",
+			"r.id", "r.date_time", "r.status", "r.closed_at",
+			"pr.id", "pr.date_time", "pr.type",
+		).
+		Prefix("DECLARE export_cursor NO SCROLL CURSOR FOR").
+		From("receptions r").
+		Join("pvz p ON p.id = r.pvz_id").
+		LeftJoin("products pr ON pr.reception_id = r.id").
+		Where(squirrel.Expr("r.date_time BETWEEN ? AND ?", filter.StartDate, filter.EndDate)).
+		OrderBy("p.id", "r.date_time", "pr.date_time")
+	if filter.City != "" {
+		query = query.Where(squirrel.Eq{"p.city": filter.City})
+	}
+	if filter.PvzID != uuid.Nil {
+		query = query.Where(squirrel.Eq{"r.pvz_id": filter.PvzID})
+	}
+	if len(filter.PvzIDs) > 0 {
+		query = query.Where(squirrel.Eq{"r.pvz_id": filter.PvzIDs})
+	}
+	if filter.Status != "" {
+		query = query.Where(squirrel.Eq{"r.status": filter.Status})
+	}
+	if filter.ProductType != "" {
+		query = query.Where(squirrel.Eq{"pr.type": filter.ProductType})
+	}
+
+	declare, args, err := query.ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return err
+	}
+
+	// the cursor lives only inside the transaction, rows are fetched in batches to keep memory flat
+	tx, err := repo.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		logger.Error("failed to begin transaction: " + err.Error())
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, declare, args...); err != nil {
+		logger.Error("failed to declare cursor: " + err.Error())
+		return err
+	}
+
+	for {
+		rows, err := tx.Query(ctx, fmt.Sprintf("FETCH %d FROM export_cursor", exportFetchSize))
+		if err != nil {
+			logger.Error("failed to fetch rows: " + err.Error())
+			return err
+		}
+
+		fetched := 0
+		for rows.Next() {
+			fetched++
+			row := &models.ExportRow{}
+			if err = rows.Scan(
+				&row.PvzID,
+				&row.PvzCity,
+				&row.PvzRegistrationDate,
+				&row.ReceptionID,
+				&row.ReceptionDateTime,
+				&row.ReceptionStatus,
+				&row.ReceptionClosedAt,
+				&row.ProductID,
+				&row.ProductDateTime,
+				&row.ProductType,
+			); err != nil {
+				rows.Close()
+				logger.Error("failed to scan row: " + err.Error())
+				return err
+			}
+
+			if err = fn(row); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			logger.Error("failed to read rows: " + err.Error())
+			return err
+		}
+
+		if fetched < exportFetchSize {
+			break
+		}
+	}
+
+	if _, err = tx.Exec(ctx, "CLOSE export_cursor"); err != nil {
+		logger.Error("failed to close cursor: " + err.Error())
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (repo *Repository) ImportPvz(ctx context.Context, pvzList []*models.Pvz, commit bool) ([]error, error) {
+	const op = "pvz.Repository.ImportPvz"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		logger.Error("failed to begin transaction: " + err.Error())
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rowErrs := make([]error, len(pvzList))
+	failed := false
+	for i, pvzData := range pvzList {
+		query, args, err := repo.builder.
+			Insert("pvz").
+			Columns("id", "registration_date", "city", "address").
+			Values(pvzData.ID, pvzData.RegistrationDate, pvzData.City, pvzData.Address).
+			ToSql()
+		if err != nil {
+			logger.Error("build query error: " + err.Error())
+			return nil, err
+		}
+
+		// every row gets its own savepoint, so a conflict is reported for the row without aborting the transaction
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			logger.Error("failed to create savepoint: " + err.Error())
+			return nil, err
+		}
+
+		if _, err = savepoint.Exec(ctx, query, args...); err != nil {
+			if rollbackErr := savepoint.Rollback(ctx); rollbackErr != nil {
+				logger.Error("failed to rollback savepoint: " + rollbackErr.Error())
+				return nil, rollbackErr
+			}
+
+			pgErr := &pgconn.PgError{}
+			if errors.As(err, &pgErr) && pgErr.Code == PgErrCodeAlreadyExists {
+				rowErrs[i] = pvz.ErrAlreadyExists
+				failed = true
+				continue
+			}
+			logger.Error("failed to import pvz: " + err.Error())
+			return nil, err
+		}
+
+		if err = savepoint.Commit(ctx); err != nil {
+			logger.Error("failed to release savepoint: " + err.Error())
+			return nil, err
+		}
+	}
+
+	if !commit || failed {
+		return rowErrs, nil
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("failed to commit transaction: " + err.Error())
+		return nil, err
+	}
+
+	return rowErrs, nil
+}
+
+func (repo *Repository) GetPvzByID(ctx context.Context, pvzId uuid.UUID) (*models.Pvz, error) {
+	const op = "pvz.Repository.GetPvzByID"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Select("id", "registration_date", "city", "address", "version").
+		From("pvz").
+		Where(squirrel.Eq{"id": pvzId}).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	pvzData := &models.Pvz{}
+	if err = repo.pool.QueryRow(ctx, query, args...).Scan(
+		&pvzData.ID,
+		&pvzData.RegistrationDate,
+		&pvzData.City,
+		&pvzData.Address,
+		&pvzData.Version,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Warn("pvz not found", "pvz_id", pvzId)
+			return nil, pvz.ErrPvzNotExists
+		}
+		logger.Error("failed to get pvz: " + err.Error())
+		return nil, err
+	}
+
+	return pvzData, nil
+}
+
+func (repo *Repository) UpdatePvz(ctx context.Context, pvzData *models.Pvz, ifMatch models.IfMatch) (*models.Pvz, error) {
+	const op = "pvz.Repository.UpdatePvz"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	tx, err := repo.pool.Begin(ctx)
+	if err != nil {
+		logger.Error("failed to begin transaction: " + err.Error())
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	query, args, err := repo.builder.
+		Select("version").
+		From("pvz").
+		Where(squirrel.Eq{"id": pvzData.ID}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	var version int64
+	if err = tx.QueryRow(ctx, query, args...).Scan(&version); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, pvz.ErrPvzNotExists
+		}
+		logger.Error("failed to get pvz version: " + err.Error())
+		return nil, err
+	}
+	if !ifMatch.Matches(version) {
+		logger.Warn("pvz version mismatch", "pvz_id", pvzData.ID, "version", version)
+		return nil, pvz.ErrVersionMismatch
+	}
+
+	query, args, err = repo.builder.
+		Update("pvz").
+		Set("city", pvzData.City).
+		Set("address", pvzData.Address).
+		Set("version", squirrel.Expr("version + 1")).
+		Where(squirrel.Eq{"id": pvzData.ID}).
+		Suffix("RETURNING id, registration_date, city, address, version").
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	updatedPvz := &models.Pvz{}
+	if err = tx.QueryRow(ctx, query, args...).Scan(
+		&updatedPvz.ID,
+		&updatedPvz.RegistrationDate,
+		&updatedPvz.City,
+		&updatedPvz.Address,
+		&updatedPvz.Version,
+	); err != nil {
+		logger.Error("failed to update pvz: " + err.Error())
+		return nil, err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		logger.Error("failed to commit transaction: " + err.Error())
+		return nil, err
+	}
+
+	return updatedPvz, nil
+}
+
+func (repo *Repository) GetLastReception(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error) {
+	const op = "pvz.Repository.GetLastReception"
+	logger := logctx.From(ctx, repo.log).With("op", op)
+
+	query, args, err := repo.builder.
+		Select("id", "date_time", "pvz_id", "status", "closed_at", "version").
+		From("receptions").
+		Where(squirrel.Eq{"pvz_id": pvzId}).
+		OrderBy("date_time DESC").
+		Limit(1).
+		ToSql()
+	if err != nil {
+		logger.Error("build query error: " + err.Error())
+		return nil, err
+	}
+
+	reception := &models.Reception{}
+	if err = repo.pool.QueryRow(ctx, query, args...).Scan(
+		&reception.ID,
+		&reception.DateTime,
+		&reception.PvzID,
+		&reception.Status,
+		&reception.ClosedAt,
+		&reception.Version,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			logger.Warn("no reception found", "pvz_id", pvzId)
+			return nil, pvz.ErrNoReception
+		}
+		logger.Error("failed to get last reception: " + err.Error())
+		return nil, err
+	}
+
+	return reception, nil
+}
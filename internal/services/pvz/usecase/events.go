@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"context"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
+	"github.com/marrgancovka/pvzService/internal/services/pvz"
+)
+
+// WatchEvents calls fn for every event matching filter until ctx is done or
+// fn fails. With filter.AfterID set the stored events after it are replayed
+// first, event ids are committed in order so none is skipped on resume.
+// It returns pvz.ErrEventStreamLagged if the watcher can't keep up.
+func (uc *Usecase) WatchEvents(ctx context.Context, filter *models.PvzEventFilter, fn func(event *models.PvzEvent) error) (err error) {
+	const op = "pvz.Usecase.WatchEvents"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	for _, city := range filter.Cities {
+		if !city.IsValid() {
+			logger.Error("incorrect city: " + string(city))
+			return pvz.ErrInaccessibleCity
+		}
+	}
+
+	// Subscribe before replaying so that nothing published in between is
+	// missed, events delivered by both are sent once.
+	live, unsubscribe := uc.events.Subscribe(filter)
+	defer unsubscribe()
+
+	replayed := make(map[int64]struct{})
+	if filter.AfterID > 0 {
+		err := uc.events.Replay(ctx, filter, func(event *models.PvzEvent) error {
+			replayed[event.ID] = struct{}{}
+			return fn(event)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-live:
+			if !ok {
+				logger.Warn("watcher dropped")
+				return pvz.ErrEventStreamLagged
+			}
+			if event.ID <= filter.AfterID {
+				continue
+			}
+			if _, ok = replayed[event.ID]; ok {
+				delete(replayed, event.ID)
+				continue
+			}
+			if err := fn(event); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/services/pvz"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestUsecase_WatchEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEvents := mocks.NewMockEventBus(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	uc := Usecase{
+		log:    log,
+		events: mockEvents,
+	}
+
+	pvzID := uuid.New()
+
+	t.Run("replay then live without duplicates", func(t *testing.T) {
+		filter := &models.PvzEventFilter{PvzIDs: []uuid.UUID{pvzID}, AfterID: 10}
+
+		live := make(chan *models.PvzEvent, 4)
+		live <- &models.PvzEvent{ID: 9}
+		live <- &models.PvzEvent{ID: 12}
+		live <- &models.PvzEvent{ID: 13}
+		close(live)
+
+		unsubscribed := false
+		mockEvents.EXPECT().Subscribe(filter).Return((<-chan *models.PvzEvent)(live), func() { unsubscribed = true })
+		mockEvents.EXPECT().
+			Replay(gomock.Any(), filter, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ *models.PvzEventFilter, fn func(*models.PvzEvent) error) error {
+				if err := fn(&models.PvzEvent{ID: 11}); err != nil {
+					return err
+				}
+				return fn(&models.PvzEvent{ID: 12})
+			})
+
+		var got []int64
+		err := uc.WatchEvents(context.Background(), filter, func(event *models.PvzEvent) error {
+			got = append(got, event.ID)
+			return nil
+		})
+
+		assert.ErrorIs(t, err, pvz.ErrEventStreamLagged)
+		assert.Equal(t, []int64{11, 12, 13}, got)
+		assert.True(t, unsubscribed)
+	})
+
+	t.Run("stops with context", func(t *testing.T) {
+		filter := &models.PvzEventFilter{}
+		ctx, cancel := context.WithCancel(context.Background())
+
+		live := make(chan *models.PvzEvent, 1)
+		live <- &models.PvzEvent{ID: 1}
+		mockEvents.EXPECT().Subscribe(filter).Return((<-chan *models.PvzEvent)(live), func() {})
+
+		err := uc.WatchEvents(ctx, filter, func(event *models.PvzEvent) error {
+			cancel()
+			return nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("invalid city", func(t *testing.T) {
+		err := uc.WatchEvents(context.Background(), &models.PvzEventFilter{Cities: []models.City{"Тверь"}}, nil)
+		assert.ErrorIs(t, err, pvz.ErrInaccessibleCity)
+	})
+}
@@ -2,11 +2,16 @@ package usecase
 
 import (
 	"context"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/marrgancovka/pvzService/internal/models"
+	"github.com/marrgancovka/pvzService/internal/pkg/logctx"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
 	"github.com/marrgancovka/pvzService/internal/services/pvz"
 	"go.uber.org/fx"
 	"log/slog"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -15,27 +20,45 @@ type Params struct {
 
 	Logger *slog.Logger
 	Repo   pvz.Repository
+	Events pvz.EventBus
 }
 
 type Usecase struct {
-	log  *slog.Logger
-	repo pvz.Repository
+	log    *slog.Logger
+	repo   pvz.Repository
+	events pvz.EventBus
 }
 
 func NewUsecase(p Params) *Usecase {
 	return &Usecase{
-		log:  p.Logger,
-		repo: p.Repo,
+		log:    p.Logger,
+		repo:   p.Repo,
+		events: p.Events,
 	}
 }
 
-func (uc *Usecase) CreatePvz(ctx context.Context, pvzData *models.Pvz) (*models.Pvz, error) {
+func (uc *Usecase) CreatePvz(ctx context.Context, pvzData *models.Pvz) (_ *models.Pvz, err error) {
 	const op = "pvz.Usecase.CreatePvz"
-	logger := uc.log.With("op", op)
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
 
+	if err := preparePvz(logger, pvzData); err != nil {
+		return nil, err
+	}
+
+	createdPvz, err := uc.repo.CreatePvz(ctx, pvzData)
+	if err != nil {
+		return nil, err
+	}
+	return createdPvz, nil
+}
+
+// preparePvz validates pvz data and fills in the defaults for an omitted id and registration date.
+func preparePvz(logger *slog.Logger, pvzData *models.Pvz) error {
 	if !pvzData.City.IsValid() {
 		logger.Error("incorrect city: " + string(pvzData.City))
-		return nil, pvz.ErrInaccessibleCity
+		return pvz.ErrInaccessibleCity
 	}
 
 	if pvzData.ID == uuid.Nil {
@@ -49,15 +72,13 @@ func (uc *Usecase) CreatePvz(ctx context.Context, pvzData *models.Pvz) (*models.
 		pvzData.RegistrationDate = time.Now()
 	}
 
-	createdPvz, err := uc.repo.CreatePvz(ctx, pvzData)
-	if err != nil {
-		return nil, err
-	}
-	return createdPvz, nil
+	return nil
 }
 
-func (uc *Usecase) CreateReception(ctx context.Context, receptionData *models.ReceptionRequest) (*models.Reception, error) {
+func (uc *Usecase) CreateReception(ctx context.Context, receptionData *models.ReceptionRequest) (_ *models.Reception, err error) {
 	const op = "pvz.Usecase.CreateReception"
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
 
 	reception := &models.Reception{
 		ID:       uuid.New(),
@@ -66,27 +87,22 @@ func (uc *Usecase) CreateReception(ctx context.Context, receptionData *models.Re
 		Status:   models.StatusInProgress,
 	}
 
-	createdReception, err := uc.repo.CreateReception(ctx, reception)
-	if err != nil {
-		return nil, err
-	}
-
-	return createdReception, nil
+	return uc.repo.CreateReception(ctx, reception)
 }
 
-func (uc *Usecase) CloseLastReceptions(ctx context.Context, pvzId uuid.UUID) (*models.Reception, error) {
+func (uc *Usecase) CloseLastReceptions(ctx context.Context, pvzId uuid.UUID, ifMatch models.IfMatch) (_ *models.Reception, err error) {
 	const op = "pvz.Usecase.CloseLastReceptions"
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
 
-	closedReception, err := uc.repo.CloseLastReceptions(ctx, pvzId)
-	if err != nil {
-		return nil, err
-	}
-	return closedReception, nil
+	return uc.repo.CloseLastReceptions(ctx, pvzId, ifMatch)
 }
 
-func (uc *Usecase) AddProduct(ctx context.Context, product *models.ProductRequest) (*models.Product, error) {
+func (uc *Usecase) AddProduct(ctx context.Context, product *models.ProductRequest) (_ *models.Product, err error) {
 	const op = "pvz.Usecase.AddProduct"
-	logger := uc.log.With("op", op)
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
 
 	if !product.Type.IsValid() {
 		logger.Error("incorrect type for product: " + string(product.Type))
@@ -100,32 +116,230 @@ func (uc *Usecase) AddProduct(ctx context.Context, product *models.ProductReques
 		ReceptionID: product.PvzID,
 	}
 
-	addedProduct, err := uc.repo.AddProduct(ctx, productData, product.PvzID)
+	return uc.repo.AddProduct(ctx, productData, product.PvzID)
+}
+
+func (uc *Usecase) DeleteLastProduct(ctx context.Context, pvzId uuid.UUID) (err error) {
+	const op = "pvz.Usecase.DeleteLastProduct"
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	_, err = uc.repo.DeleteLastProduct(ctx, pvzId)
+	return err
+}
+
+func (uc *Usecase) GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64, pvzIDs []uuid.UUID) (_ []*models.PvzWithReceptions, err error) {
+	const op = "pvz.Usecase.GetPvz"
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	return uc.repo.GetPvz(ctx, startDate, endDate, limit, page, pvzIDs)
+}
+
+func (uc *Usecase) GetPvzList(ctx context.Context, pvzIDs []uuid.UUID) (_ []*models.Pvz, err error) {
+	const op = "pvz.Usecase.GetPvzList"
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	return uc.repo.GetPvzList(ctx, pvzIDs)
+}
+
+func (uc *Usecase) GetStats(ctx context.Context, filter *models.StatsFilter) (_ []*models.StatsRow, err error) {
+	const op = "pvz.Usecase.GetStats"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if filter.Period == "" {
+		filter.Period = models.PeriodDay
+	}
+	if !filter.Period.IsValid() {
+		logger.Error("incorrect stats period: " + string(filter.Period))
+		return nil, pvz.ErrInvalidStatsPeriod
+	}
+
+	for _, group := range filter.GroupBy {
+		if !group.IsValid() {
+			logger.Error("incorrect stats group: " + string(group))
+			return nil, pvz.ErrInvalidStatsGroup
+		}
+	}
+
+	if filter.City != "" && !filter.City.IsValid() {
+		logger.Error("incorrect city: " + string(filter.City))
+		return nil, pvz.ErrInaccessibleCity
+	}
+
+	if filter.StartDate.After(filter.EndDate) {
+		logger.Error("start date is after end date")
+		return nil, pvz.ErrInvalidDateRange
+	}
+
+	return uc.repo.GetStats(ctx, filter)
+}
+
+func (uc *Usecase) ExportReceptions(ctx context.Context, filter *models.ExportFilter, fn func(row *models.ExportRow) error) (err error) {
+	const op = "pvz.Usecase.ExportReceptions"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if filter.City != "" && !filter.City.IsValid() {
+		logger.Error("incorrect city: " + string(filter.City))
+		return pvz.ErrInaccessibleCity
+	}
+
+	if filter.Status != "" && !filter.Status.IsValid() {
+		logger.Error("incorrect reception status: " + string(filter.Status))
+		return pvz.ErrIncorrectStatus
+	}
+
+	if filter.ProductType != "" && !filter.ProductType.IsValid() {
+		logger.Error("incorrect type for product: " + string(filter.ProductType))
+		return pvz.ErrIncorrectProductType
+	}
+
+	if filter.StartDate.After(filter.EndDate) {
+		logger.Error("start date is after end date")
+		return pvz.ErrInvalidDateRange
+	}
+
+	return uc.repo.ExportReceptions(ctx, filter, fn)
+}
+
+func (uc *Usecase) ImportPvz(ctx context.Context, rows []*models.PvzImportRow, dryRun bool) (_ *models.PvzImportReport, err error) {
+	const op = "pvz.Usecase.ImportPvz"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	report := &models.PvzImportReport{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Errors: []*models.PvzImportLineError{},
+	}
+	if len(rows) == 0 {
+		logger.Error("nothing to import")
+		return nil, pvz.ErrEmptyImport
+	}
+
+	pvzList := make([]*models.Pvz, 0, len(rows))
+	lines := make([]int, 0, len(rows))
+	seen := make(map[uuid.UUID]int, len(rows))
+	for _, row := range rows {
+		pvzData, err := parseImportRow(row)
+		if err == nil {
+			err = preparePvz(logger, pvzData)
+		}
+		if err == nil {
+			if line, ok := seen[pvzData.ID]; ok {
+				err = fmt.Errorf("%w: duplicates line %d", pvz.ErrAlreadyExists, line)
+			}
+		}
+		if err != nil {
+			report.Errors = append(report.Errors, &models.PvzImportLineError{Line: row.Line, Error: err.Error()})
+			continue
+		}
+
+		seen[pvzData.ID] = row.Line
+		pvzList = append(pvzList, pvzData)
+		lines = append(lines, row.Line)
+	}
+
+	// the repository is called even when some rows are invalid so that database conflicts are reported too,
+	// the transaction is committed only for a real import without errors
+	commit := !dryRun && len(report.Errors) == 0
+	rowErrs, err := uc.repo.ImportPvz(ctx, pvzList, commit)
 	if err != nil {
 		return nil, err
 	}
 
-	return addedProduct, nil
+	for i, rowErr := range rowErrs {
+		if rowErr != nil {
+			report.Errors = append(report.Errors, &models.PvzImportLineError{Line: lines[i], Error: rowErr.Error()})
+		}
+	}
+	sort.Slice(report.Errors, func(i, j int) bool {
+		return report.Errors[i].Line < report.Errors[j].Line
+	})
+
+	if len(report.Errors) == 0 && !dryRun {
+		report.Imported = len(pvzList)
+	}
+
+	return report, nil
 }
 
-func (uc *Usecase) DeleteLastProduct(ctx context.Context, pvzId uuid.UUID) error {
-	const op = "pvz.Usecase.DeleteLastProduct"
+func parseImportRow(row *models.PvzImportRow) (*models.Pvz, error) {
+	if row.FieldCount != models.PvzImportColumns {
+		return nil, fmt.Errorf("%w: expected %d columns, got %d", pvz.ErrBadRequest, models.PvzImportColumns, row.FieldCount)
+	}
 
-	err := uc.repo.DeleteLastProduct(ctx, pvzId)
-	if err != nil {
-		return err
+	pvzData := &models.Pvz{
+		City:    models.City(strings.TrimSpace(row.City)),
+		Address: strings.TrimSpace(row.Address),
 	}
-	return nil
+
+	if id := strings.TrimSpace(row.ID); id != "" {
+		parsedID, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid id", pvz.ErrBadRequest)
+		}
+		pvzData.ID = parsedID
+	}
+
+	if date := strings.TrimSpace(row.RegistrationDate); date != "" {
+		parsedDate, err := parseImportDate(date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid registration date", pvz.ErrBadRequest)
+		}
+		pvzData.RegistrationDate = parsedDate
+	}
+
+	return pvzData, nil
 }
 
-func (uc *Usecase) GetPvz(ctx context.Context, startDate, endDate time.Time, limit, page uint64) ([]*models.PvzWithReceptions, error) {
-	const op = "pvz.Usecase.GetPvz"
+func parseImportDate(dateStr string) (time.Time, error) {
+	formats := []string{
+		time.RFC3339,
+		"2006-01-02",
+	}
+
+	for _, format := range formats {
+		if t, err := time.Parse(format, dateStr); err == nil {
+			return t, nil
+		}
+	}
 
-	return uc.repo.GetPvz(ctx, startDate, endDate, limit, page)
+	return time.Time{}, fmt.Errorf("unrecognized date format")
 }
 
-func (uc *Usecase) GetPvzList(ctx context.Context) ([]*models.Pvz, error) {
-	const op = "pvz.Usecase.GetPvzList"
+func (uc *Usecase) GetPvzByID(ctx context.Context, pvzId uuid.UUID) (_ *models.Pvz, err error) {
+	const op = "pvz.Usecase.GetPvzByID"
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	return uc.repo.GetPvzByID(ctx, pvzId)
+}
+
+func (uc *Usecase) UpdatePvz(ctx context.Context, pvzData *models.Pvz, ifMatch models.IfMatch) (_ *models.Pvz, err error) {
+	const op = "pvz.Usecase.UpdatePvz"
+	logger := logctx.From(ctx, uc.log).With("op", op)
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
+
+	if !pvzData.City.IsValid() {
+		logger.Error("incorrect city: " + string(pvzData.City))
+		return nil, pvz.ErrInaccessibleCity
+	}
+
+	return uc.repo.UpdatePvz(ctx, pvzData, ifMatch)
+}
+
+func (uc *Usecase) GetLastReception(ctx context.Context, pvzId uuid.UUID) (_ *models.Reception, err error) {
+	const op = "pvz.Usecase.GetLastReception"
+	ctx, span := tracing.Start(ctx, op)
+	defer tracing.End(span, &err)
 
-	return uc.repo.GetPvzList(ctx)
+	return uc.repo.GetLastReception(ctx, pvzId)
 }
@@ -122,13 +122,15 @@ func TestUsecase_CreateReception(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockRepository(ctrl)
+	mockEvents := mocks.NewMockEventBus(ctrl)
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	}))
 
 	uc := Usecase{
-		log:  log,
-		repo: mockRepo,
+		log:    log,
+		repo:   mockRepo,
+		events: mockEvents,
 	}
 
 	testUUID := uuid.New()
@@ -207,13 +209,17 @@ func TestUsecase_CloseLastReceptions(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockRepository(ctrl)
+	mockEvents := mocks.NewMockEventBus(ctrl)
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	}))
 
+	ifMatch := models.IfMatch{Versions: []int64{1}}
+
 	uc := Usecase{
-		log:  log,
-		repo: mockRepo,
+		log:    log,
+		repo:   mockRepo,
+		events: mockEvents,
 	}
 
 	validPvzID := uuid.New()
@@ -236,7 +242,7 @@ func TestUsecase_CloseLastReceptions(t *testing.T) {
 			pvzId: validPvzID,
 			mockSetup: func() {
 				mockRepo.EXPECT().
-					CloseLastReceptions(gomock.Any(), validPvzID).
+					CloseLastReceptions(gomock.Any(), validPvzID, ifMatch).
 					Return(testReception, nil)
 			},
 			expected:    testReception,
@@ -247,7 +253,7 @@ func TestUsecase_CloseLastReceptions(t *testing.T) {
 			pvzId: validPvzID,
 			mockSetup: func() {
 				mockRepo.EXPECT().
-					CloseLastReceptions(gomock.Any(), validPvzID).
+					CloseLastReceptions(gomock.Any(), validPvzID, ifMatch).
 					Return(nil, pvz.ErrPvzNotExists)
 			},
 			expected:    nil,
@@ -258,7 +264,7 @@ func TestUsecase_CloseLastReceptions(t *testing.T) {
 			pvzId: validPvzID,
 			mockSetup: func() {
 				mockRepo.EXPECT().
-					CloseLastReceptions(gomock.Any(), validPvzID).
+					CloseLastReceptions(gomock.Any(), validPvzID, ifMatch).
 					Return(nil, pvz.ErrNoOpenReception)
 			},
 			expected:    nil,
@@ -272,7 +278,7 @@ func TestUsecase_CloseLastReceptions(t *testing.T) {
 				tt.mockSetup()
 			}
 
-			result, err := uc.CloseLastReceptions(context.Background(), tt.pvzId)
+			result, err := uc.CloseLastReceptions(context.Background(), tt.pvzId, ifMatch)
 
 			assert.Equal(t, tt.expected, result)
 			if tt.expectedErr != nil {
@@ -289,13 +295,15 @@ func TestUsecase_AddProduct(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockRepository(ctrl)
+	mockEvents := mocks.NewMockEventBus(ctrl)
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	}))
 
 	uc := Usecase{
-		log:  log,
-		repo: mockRepo,
+		log:    log,
+		repo:   mockRepo,
+		events: mockEvents,
 	}
 
 	validPvzID := uuid.New()
@@ -400,16 +408,20 @@ func TestUsecase_DeleteLastProduct(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mocks.NewMockRepository(ctrl)
+	mockEvents := mocks.NewMockEventBus(ctrl)
 	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level: slog.LevelDebug,
 	}))
 
 	uc := Usecase{
-		log:  log,
-		repo: mockRepo,
+		log:    log,
+		repo:   mockRepo,
+		events: mockEvents,
 	}
 
 	validPvzID := uuid.New()
+	productID := uuid.New()
+	receptionID := uuid.New()
 
 	tests := []struct {
 		name        string
@@ -423,7 +435,7 @@ func TestUsecase_DeleteLastProduct(t *testing.T) {
 			mockSetup: func() {
 				mockRepo.EXPECT().
 					DeleteLastProduct(gomock.Any(), validPvzID).
-					Return(nil)
+					Return(&models.Product{ID: productID, Type: models.TypeShoes, ReceptionID: receptionID}, nil)
 			},
 			expectedErr: nil,
 		},
@@ -433,7 +445,7 @@ func TestUsecase_DeleteLastProduct(t *testing.T) {
 			mockSetup: func() {
 				mockRepo.EXPECT().
 					DeleteLastProduct(gomock.Any(), validPvzID).
-					Return(pvz.ErrNoProduct)
+					Return(nil, pvz.ErrNoProduct)
 			},
 			expectedErr: pvz.ErrNoProduct,
 		},
@@ -455,3 +467,305 @@ func TestUsecase_DeleteLastProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestUsecase_GetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	uc := Usecase{
+		log:  log,
+		repo: mockRepo,
+	}
+
+	startDate := time.Now().AddDate(0, 0, -7)
+	endDate := time.Now()
+
+	tests := []struct {
+		name           string
+		input          *models.StatsFilter
+		mockSetup      func()
+		expectedPeriod models.StatsPeriod
+		expectedErr    error
+	}{
+		{
+			name: "default period is day",
+			input: &models.StatsFilter{
+				StartDate: startDate,
+				EndDate:   endDate,
+				GroupBy:   []models.StatsGroup{models.GroupByCity},
+			},
+			mockSetup: func() {
+				mockRepo.EXPECT().
+					GetStats(gomock.Any(), gomock.Any()).
+					Return([]*models.StatsRow{{Period: startDate, City: models.CityKazan, ReceptionsCount: 1}}, nil)
+			},
+			expectedPeriod: models.PeriodDay,
+			expectedErr:    nil,
+		},
+		{
+			name: "invalid period",
+			input: &models.StatsFilter{
+				StartDate: startDate,
+				EndDate:   endDate,
+				Period:    "year",
+			},
+			mockSetup:   func() {},
+			expectedErr: pvz.ErrInvalidStatsPeriod,
+		},
+		{
+			name: "invalid group",
+			input: &models.StatsFilter{
+				StartDate: startDate,
+				EndDate:   endDate,
+				Period:    models.PeriodWeek,
+				GroupBy:   []models.StatsGroup{"status"},
+			},
+			mockSetup:   func() {},
+			expectedErr: pvz.ErrInvalidStatsGroup,
+		},
+		{
+			name: "start date after end date",
+			input: &models.StatsFilter{
+				StartDate: endDate,
+				EndDate:   startDate,
+				Period:    models.PeriodMonth,
+			},
+			mockSetup:   func() {},
+			expectedErr: pvz.ErrInvalidDateRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.mockSetup != nil {
+				tt.mockSetup()
+			}
+
+			result, err := uc.GetStats(context.Background(), tt.input)
+
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedPeriod, tt.input.Period)
+				assert.Len(t, result, 1)
+			}
+		})
+	}
+}
+
+func TestUsecase_ExportReceptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	uc := Usecase{
+		log:  log,
+		repo: mockRepo,
+	}
+
+	startDate := time.Now().AddDate(0, -1, 0)
+	endDate := time.Now()
+
+	tests := []struct {
+		name         string
+		input        *models.ExportFilter
+		mockSetup    func()
+		expectedRows int
+		expectedErr  error
+	}{
+		{
+			name: "rows are passed to callback",
+			input: &models.ExportFilter{
+				StartDate: startDate,
+				EndDate:   endDate,
+				City:      models.CityMoscow,
+				Status:    models.StatusClose,
+			},
+			mockSetup: func() {
+				mockRepo.EXPECT().
+					ExportReceptions(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ *models.ExportFilter, fn func(row *models.ExportRow) error) error {
+						for i := 0; i < 3; i++ {
+							if err := fn(&models.ExportRow{PvzID: uuid.New()}); err != nil {
+								return err
+							}
+						}
+						return nil
+					})
+			},
+			expectedRows: 3,
+			expectedErr:  nil,
+		},
+		{
+			name: "invalid status",
+			input: &models.ExportFilter{
+				StartDate: startDate,
+				EndDate:   endDate,
+				Status:    "open",
+			},
+			mockSetup:   func() {},
+			expectedErr: pvz.ErrIncorrectStatus,
+		},
+		{
+			name: "invalid product type",
+			input: &models.ExportFilter{
+				StartDate:   startDate,
+				EndDate:     endDate,
+				ProductType: "мебель",
+			},
+			mockSetup:   func() {},
+			expectedErr: pvz.ErrIncorrectProductType,
+		},
+		{
+			name: "start date after end date",
+			input: &models.ExportFilter{
+				StartDate: endDate,
+				EndDate:   startDate,
+			},
+			mockSetup:   func() {},
+			expectedErr: pvz.ErrInvalidDateRange,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.mockSetup != nil {
+				tt.mockSetup()
+			}
+
+			rows := 0
+			err := uc.ExportReceptions(context.Background(), tt.input, func(row *models.ExportRow) error {
+				rows++
+				return nil
+			})
+
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedRows, rows)
+			}
+		})
+	}
+}
+
+func TestUsecase_ImportPvz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	uc := Usecase{
+		log:  log,
+		repo: mockRepo,
+	}
+
+	existingID := uuid.New()
+	validRow := func(line int, id string) *models.PvzImportRow {
+		return &models.PvzImportRow{
+			Line:             line,
+			FieldCount:       models.PvzImportColumns,
+			ID:               id,
+			City:             string(models.CityKazan),
+			RegistrationDate: "2025-04-01",
+			Address:          "ул. Баумана, 1",
+		}
+	}
+
+	tests := []struct {
+		name           string
+		rows           []*models.PvzImportRow
+		dryRun         bool
+		mockSetup      func()
+		expectedImport int
+		expectedLines  []int
+		expectedErr    error
+	}{
+		{
+			name:   "successful import",
+			rows:   []*models.PvzImportRow{validRow(2, uuid.NewString()), validRow(3, "")},
+			dryRun: false,
+			mockSetup: func() {
+				mockRepo.EXPECT().
+					ImportPvz(gomock.Any(), gomock.Len(2), true).
+					Return([]error{nil, nil}, nil)
+			},
+			expectedImport: 2,
+			expectedLines:  []int{},
+		},
+		{
+			name:   "dry run is never committed",
+			rows:   []*models.PvzImportRow{validRow(2, "")},
+			dryRun: true,
+			mockSetup: func() {
+				mockRepo.EXPECT().
+					ImportPvz(gomock.Any(), gomock.Len(1), false).
+					Return([]error{nil}, nil)
+			},
+			expectedImport: 0,
+			expectedLines:  []int{},
+		},
+		{
+			name: "invalid rows are reported by line",
+			rows: []*models.PvzImportRow{
+				validRow(2, existingID.String()),
+				{Line: 3, FieldCount: models.PvzImportColumns, City: "Владивосток"},
+				{Line: 4, FieldCount: 2, City: string(models.CityMoscow)},
+				validRow(5, "not-a-uuid"),
+				validRow(6, existingID.String()),
+			},
+			dryRun: false,
+			mockSetup: func() {
+				mockRepo.EXPECT().
+					ImportPvz(gomock.Any(), gomock.Len(1), false).
+					Return([]error{pvz.ErrAlreadyExists}, nil)
+			},
+			expectedImport: 0,
+			expectedLines:  []int{2, 3, 4, 5, 6},
+		},
+		{
+			name:        "empty file",
+			rows:        nil,
+			mockSetup:   func() {},
+			expectedErr: pvz.ErrEmptyImport,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.mockSetup != nil {
+				tt.mockSetup()
+			}
+
+			report, err := uc.ImportPvz(context.Background(), tt.rows, tt.dryRun)
+
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.dryRun, report.DryRun)
+				assert.Equal(t, len(tt.rows), report.Total)
+				assert.Equal(t, tt.expectedImport, report.Imported)
+
+				lines := make([]int, 0, len(report.Errors))
+				for _, lineErr := range report.Errors {
+					lines = append(lines, lineErr.Line)
+				}
+				assert.Equal(t, tt.expectedLines, lines)
+			}
+		})
+	}
+}
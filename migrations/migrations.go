@@ -25,24 +25,24 @@ var migrationFiles embed.FS
 func RunMigrations(p Params) error {
 	sourceDriver, err := iofs.New(migrationFiles, "schema")
 	if err != nil {
-		p.Logger.Error("failed to load migration files: ", err.Error())
+		p.Logger.Error("failed to load migration files: " + err.Error())
 		return fmt.Errorf("failed to initialize migrations source driver: %w", err)
 	}
 
 	dbDriver, err := postgres.WithInstance(p.DB, &postgres.Config{})
 	if err != nil {
-		p.Logger.Error("failed to initialize postgres driver: ", err.Error())
+		p.Logger.Error("failed to initialize postgres driver: " + err.Error())
 		return fmt.Errorf("failed to initialize postgres driver: %w", err)
 	}
 
 	m, err := migrate.NewWithInstance("iofs", sourceDriver, "postgres", dbDriver)
 	if err != nil {
-		p.Logger.Error("failed to initialize migrate instance: ", err.Error())
+		p.Logger.Error("failed to initialize migrate instance: " + err.Error())
 		return fmt.Errorf("failed to initialize migrate instance: %w", err)
 	}
 
 	if err = m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		p.Logger.Error("failed to run migrations: ", err.Error())
+		p.Logger.Error("failed to run migrations: " + err.Error())
 		return fmt.Errorf("migration up failed: %w", err)
 	}
 
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS products_reception_id_idx;
+DROP INDEX IF EXISTS receptions_date_time_idx;
+ALTER TABLE receptions DROP COLUMN IF EXISTS closed_at;
@@ -0,0 +1,3 @@
+ALTER TABLE receptions ADD COLUMN IF NOT EXISTS closed_at TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS receptions_date_time_idx ON receptions (date_time);
+CREATE INDEX IF NOT EXISTS products_reception_id_idx ON products (reception_id);
@@ -0,0 +1 @@
+ALTER TABLE pvz ADD COLUMN IF NOT EXISTS address TEXT NOT NULL DEFAULT '';
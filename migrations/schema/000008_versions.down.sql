@@ -0,0 +1,2 @@
+ALTER TABLE receptions DROP COLUMN IF EXISTS version;
+ALTER TABLE pvz DROP COLUMN IF EXISTS version;
@@ -0,0 +1,2 @@
+ALTER TABLE pvz ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE receptions ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
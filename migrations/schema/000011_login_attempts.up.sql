@@ -0,0 +1,13 @@
+-- email counters are kept per client ip, so a failing client cannot lock
+-- the account for everybody else; account counters (per email from any
+-- client) and ip counters leave client_ip empty
+CREATE TABLE IF NOT EXISTS login_attempts (
+    kind TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    client_ip TEXT NOT NULL DEFAULT '',
+    failures INT NOT NULL DEFAULT 0,
+    window_start TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_failure_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (kind, subject, client_ip)
+);
@@ -0,0 +1,2 @@
+-- Original spelling of emails is not kept, nothing to restore.
+SELECT 1;
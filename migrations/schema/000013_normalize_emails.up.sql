@@ -0,0 +1,27 @@
+-- Emails are now stored trimmed and lowercased. Accounts whose emails only
+-- differ in case or surrounding spaces can't be merged automatically, so the
+-- migration stops and lists them: rename or delete the extra accounts, then
+-- run the migrations again.
+DO $$
+DECLARE
+    collisions TEXT;
+BEGIN
+    SELECT string_agg(normalized || ' (' || accounts || ' accounts)', ', ' ORDER BY normalized)
+    INTO collisions
+    FROM (
+        SELECT lower(btrim(email)) AS normalized, count(*) AS accounts
+        FROM users
+        GROUP BY lower(btrim(email))
+        HAVING count(*) > 1
+    ) duplicates;
+
+    IF collisions IS NOT NULL THEN
+        RAISE EXCEPTION 'emails collide after normalization: %', collisions
+            USING HINT = 'rename or delete the duplicate accounts and run the migrations again';
+    END IF;
+END
+$$;
+
+UPDATE users
+SET email = lower(btrim(email))
+WHERE email <> lower(btrim(email));
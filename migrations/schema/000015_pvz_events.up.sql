@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS pvz_events (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    pvz_id UUID NOT NULL,
+    city TEXT NOT NULL,
+    reception_id UUID NOT NULL,
+    product_id UUID,
+    product_type TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS pvz_events_pvz_id_idx ON pvz_events (pvz_id, id);
+CREATE INDEX IF NOT EXISTS pvz_events_created_at_idx ON pvz_events (created_at);
@@ -19,6 +19,21 @@ func SendOk(w http.ResponseWriter, code int, v interface{}) {
 	_, _ = w.Write(resp)
 }
 
+type FieldErrorsResponse struct {
+	Msg    string      `json:"msg"`
+	Errors interface{} `json:"errors"`
+}
+
+func SendFieldErrs(w http.ResponseWriter, code int, msg string, errs interface{}) {
+	resp, err := json.Marshal(FieldErrorsResponse{Msg: msg, Errors: errs})
+	if err != nil {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_, _ = w.Write(resp)
+}
+
 func SendErr(w http.ResponseWriter, code int, msg string) {
 	resp, err := json.Marshal(MessageResponse{msg})
 	if err != nil {
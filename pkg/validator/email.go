@@ -0,0 +1,44 @@
+package validator
+
+import (
+	"net/mail"
+	"strings"
+)
+
+const (
+	maxEmailLength      = 254
+	maxEmailLocalLength = 64
+)
+
+// NormalizeEmail trims and lowercases the address, so lookups don't depend
+// on how the user typed it.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// ValidateEmail checks that email is a bare RFC 5322 address, display names
+// and comments are rejected. It returns an empty string for valid addresses.
+func ValidateEmail(email string) string {
+	if email == "" {
+		return "is required"
+	}
+	if len(email) > maxEmailLength {
+		return "is too long"
+	}
+
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" || addr.Address != email {
+		return "is not a valid email address"
+	}
+
+	at := strings.LastIndexByte(email, '@')
+	local, domain := email[:at], email[at+1:]
+	if len(local) > maxEmailLocalLength {
+		return "is not a valid email address"
+	}
+	if !strings.Contains(domain, ".") || strings.HasPrefix(domain, ".") || strings.HasSuffix(domain, ".") {
+		return "is not a valid email address"
+	}
+
+	return ""
+}
@@ -0,0 +1,33 @@
+package validator
+
+import "strings"
+
+// FieldError describes why a single request field was rejected.
+type FieldError struct {
+	Field string `json:"field"`
+	Msg   string `json:"msg"`
+}
+
+// Errors collects field errors of one request, it is returned as an error
+// so it can travel through the usecase layer.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, fe := range e {
+		msgs = append(msgs, fe.Field+": "+fe.Msg)
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *Errors) Add(field, msg string) {
+	*e = append(*e, FieldError{Field: field, Msg: msg})
+}
+
+// Err returns nil when nothing was collected.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
@@ -0,0 +1,95 @@
+package validator
+
+import (
+	"bufio"
+	_ "embed"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsList string
+
+var commonPasswords = parseCommonPasswords(commonPasswordsList)
+
+type PasswordPolicy struct {
+	MinLength     int  `yaml:"minLength" env-default:"8"`
+	MaxLength     int  `yaml:"maxLength" env-default:"128"`
+	RequireUpper  bool `yaml:"requireUpper" env-default:"true"`
+	RequireLower  bool `yaml:"requireLower" env-default:"true"`
+	RequireDigit  bool `yaml:"requireDigit" env-default:"true"`
+	RequireSymbol bool `yaml:"requireSymbol" env-default:"false"`
+	// DenyCommon rejects passwords from the embedded list of common ones.
+	DenyCommon bool `yaml:"denyCommon" env-default:"true"`
+}
+
+// Validate returns the reasons password violates the policy, nil if it
+// complies. Lengths are counted in characters, not bytes.
+func (p PasswordPolicy) Validate(password string) []string {
+	if password == "" {
+		return []string{"is required"}
+	}
+
+	var msgs []string
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		msgs = append(msgs, "must be at least "+strconv.Itoa(p.MinLength)+" characters long")
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		msgs = append(msgs, "must be at most "+strconv.Itoa(p.MaxLength)+" characters long")
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		msgs = append(msgs, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		msgs = append(msgs, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		msgs = append(msgs, "must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		msgs = append(msgs, "must contain a symbol")
+	}
+
+	if p.DenyCommon && IsCommonPassword(password) {
+		msgs = append(msgs, "is too common")
+	}
+
+	return msgs
+}
+
+// IsCommonPassword reports whether password is in the embedded denylist,
+// the comparison ignores case.
+func IsCommonPassword(password string) bool {
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
+
+func parseCommonPasswords(list string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
@@ -0,0 +1,80 @@
+package validator
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		email   string
+		wantMsg string
+	}{
+		{email: "user@example.com"},
+		{email: "first.last+tag@sub.example.co"},
+		{email: "", wantMsg: "is required"},
+		{email: "x", wantMsg: "is not a valid email address"},
+		{email: "user@", wantMsg: "is not a valid email address"},
+		{email: "@example.com", wantMsg: "is not a valid email address"},
+		{email: "user@localhost", wantMsg: "is not a valid email address"},
+		{email: "User <user@example.com>", wantMsg: "is not a valid email address"},
+		{email: "user@example.com.", wantMsg: "is not a valid email address"},
+		{email: "two@@example.com", wantMsg: "is not a valid email address"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			assert.Equal(t, tt.wantMsg, ValidateEmail(tt.email))
+		})
+	}
+}
+
+func TestNormalizeEmail(t *testing.T) {
+	assert.Equal(t, "user@example.com", NormalizeEmail("  User@Example.COM\n"))
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := PasswordPolicy{
+		MinLength:     8,
+		MaxLength:     16,
+		RequireUpper:  true,
+		RequireLower:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		DenyCommon:    true,
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		wantMsgs []string
+	}{
+		{name: "valid", password: "Str0ng!Pass"},
+		{name: "unicode", password: "Пароль-2024"},
+		{name: "empty", password: "", wantMsgs: []string{"is required"}},
+		{
+			name:     "short and simple",
+			password: "abc",
+			wantMsgs: []string{
+				"must be at least 8 characters long",
+				"must contain an uppercase letter",
+				"must contain a digit",
+				"must contain a symbol",
+			},
+		},
+		{name: "too long", password: "Str0ng!PassStr0ng!Pass", wantMsgs: []string{"must be at most 16 characters long"}},
+		{name: "common", password: "P@ssw0rd1", wantMsgs: []string{"is too common"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantMsgs, policy.Validate(tt.password))
+		})
+	}
+}
+
+func TestIsCommonPassword(t *testing.T) {
+	assert.True(t, IsCommonPassword("password"))
+	assert.True(t, IsCommonPassword("QWERTY123"))
+	assert.False(t, IsCommonPassword("correct horse battery staple"))
+}
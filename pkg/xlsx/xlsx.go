@@ -0,0 +1,145 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+)
+
+var ErrClosed = errors.New("xlsx writer is closed")
+
+const (
+	contentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	rootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	workbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	workbookHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`
+	workbookFooter = `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	sheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetFooter = `</sheetData></worksheet>`
+)
+
+// Writer streams a single-sheet workbook row by row, so memory usage does not depend on the number of rows.
+type Writer struct {
+	zip    *zip.Writer
+	sheet  io.Writer
+	row    int
+	closed bool
+}
+
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	escapedName := &bytes.Buffer{}
+	if err := xml.EscapeText(escapedName, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", workbookHeader + escapedName.String() + workbookFooter},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+	}
+	for _, part := range parts {
+		pw, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = io.WriteString(pw, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.WriteString(sheet, sheetHeader); err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		zip:   zw,
+		sheet: sheet,
+	}, nil
+}
+
+// WriteRow appends a row of inline string cells to the sheet.
+func (w *Writer) WriteRow(cells []string) error {
+	if w.closed {
+		return ErrClosed
+	}
+
+	w.row++
+	if _, err := io.WriteString(w.sheet, `<row r="`+strconv.Itoa(w.row)+`">`); err != nil {
+		return err
+	}
+	for i, cell := range cells {
+		if _, err := io.WriteString(w.sheet, `<c r="`+columnName(i)+strconv.Itoa(w.row)+`" t="inlineStr"><is><t>`); err != nil {
+			return err
+		}
+		if err := xml.EscapeText(w.sheet, []byte(cell)); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w.sheet, `</t></is></c>`); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w.sheet, `</row>`)
+	return err
+}
+
+// Flush flushes the compressed data written so far to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.closed {
+		return ErrClosed
+	}
+	return w.zip.Flush()
+}
+
+// Close finishes the sheet and writes the zip central directory. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrClosed
+	}
+	w.closed = true
+
+	if _, err := io.WriteString(w.sheet, sheetFooter); err != nil {
+		return err
+	}
+	return w.zip.Close()
+}
+
+// columnName converts a zero-based column index into a spreadsheet column name: 0 -> A, 26 -> AA.
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
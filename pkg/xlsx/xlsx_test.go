@@ -0,0 +1,51 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	w, err := NewWriter(buf, "Приемки & товары")
+	require.NoError(t, err)
+	require.NoError(t, w.WriteRow([]string{"id", "city"}))
+	require.NoError(t, w.WriteRow([]string{"1", "<Казань>"}))
+	require.NoError(t, w.Close())
+	assert.ErrorIs(t, w.WriteRow([]string{"2"}), ErrClosed)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+		files[f.Name] = string(content)
+	}
+
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files["xl/workbook.xml"], `name="Приемки &amp; товары"`)
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.True(t, strings.HasSuffix(sheet, "</sheetData></worksheet>"))
+	assert.Contains(t, sheet, `<c r="B2" t="inlineStr"><is><t>&lt;Казань&gt;</t></is></c>`)
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "AZ", columnName(51))
+	assert.Equal(t, "BA", columnName(52))
+}
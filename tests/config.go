@@ -3,9 +3,14 @@ package tests
 import (
 	_ "github.com/joho/godotenv/autoload"
 	"github.com/marrgancovka/pvzService/internal/config"
+	"github.com/marrgancovka/pvzService/internal/config/profile"
 	"github.com/marrgancovka/pvzService/internal/pkg/db"
+	"github.com/marrgancovka/pvzService/internal/pkg/idempotency"
 	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
+	"github.com/marrgancovka/pvzService/internal/pkg/notifier"
 	"github.com/marrgancovka/pvzService/internal/pkg/servers/mainServer"
+	"github.com/marrgancovka/pvzService/internal/services/auth"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/events"
 	"time"
 )
 
@@ -39,5 +44,32 @@ func Config() config.Out {
 			ExpirationTime: time.Hour,
 			KeyJWT:         []byte("dknsnslk"),
 		},
+		Auth: auth.Config{
+			AllowRegistration: true,
+			DummyLogin:        true,
+			Lockout: auth.LockoutConfig{
+				MaxFailures:        5,
+				MaxAccountFailures: 20,
+				MaxIPFailures:      1000,
+				Window:             time.Minute,
+				Duration:           time.Minute,
+			},
+		},
+		Idempotency: idempotency.Config{
+			TTL:         time.Hour,
+			MaxBodySize: 10 << 20,
+		},
+		Notifier: notifier.Config{
+			Driver: "log",
+		},
+		Events: events.Config{
+			Channel:        "pvz_events",
+			Buffer:         256,
+			ReplayBatch:    500,
+			ReconnectDelay: time.Second,
+			Heartbeat:      15 * time.Second,
+			RetryDelay:     3 * time.Second,
+		},
+		Environment: profile.Test,
 	}
 }
@@ -7,17 +7,24 @@ import (
 	"github.com/google/uuid"
 	"github.com/marrgancovka/pvzService/internal/models"
 	"github.com/marrgancovka/pvzService/internal/pkg/db"
+	"github.com/marrgancovka/pvzService/internal/pkg/gateway"
 	"github.com/marrgancovka/pvzService/internal/pkg/grpcconn"
+	"github.com/marrgancovka/pvzService/internal/pkg/idempotency"
 	"github.com/marrgancovka/pvzService/internal/pkg/jwter"
+	"github.com/marrgancovka/pvzService/internal/pkg/logger"
 	"github.com/marrgancovka/pvzService/internal/pkg/metrics"
 	"github.com/marrgancovka/pvzService/internal/pkg/middleware"
+	"github.com/marrgancovka/pvzService/internal/pkg/notifier"
+	"github.com/marrgancovka/pvzService/internal/pkg/rbac"
 	"github.com/marrgancovka/pvzService/internal/pkg/servers/mainServer"
+	"github.com/marrgancovka/pvzService/internal/pkg/tracing"
 	"github.com/marrgancovka/pvzService/internal/services/auth"
 	authHandler "github.com/marrgancovka/pvzService/internal/services/auth/delivery/http"
 	authRepository "github.com/marrgancovka/pvzService/internal/services/auth/repo"
 	authUsecase "github.com/marrgancovka/pvzService/internal/services/auth/usecase"
 	"github.com/marrgancovka/pvzService/internal/services/pvz"
 	pvzHandler "github.com/marrgancovka/pvzService/internal/services/pvz/delivery/http"
+	"github.com/marrgancovka/pvzService/internal/services/pvz/events"
 	pvzRepository "github.com/marrgancovka/pvzService/internal/services/pvz/repo"
 	pvzUsecase "github.com/marrgancovka/pvzService/internal/services/pvz/usecase"
 	"github.com/marrgancovka/pvzService/migrations"
@@ -30,6 +37,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -43,17 +51,21 @@ type PVZIntegrationSuite struct {
 	app       *fxtest.App
 	serverURL string
 	client    *http.Client
+	bus       *events.Bus
 }
 
 func (s *PVZIntegrationSuite) SetupSuite() {
 	s.app = fxtest.New(
 		s.T(),
 		fx.Provide(
-			func() *slog.Logger {
+			func() (*slog.Logger, *slog.LevelVar) {
+				level := new(slog.LevelVar)
+				level.Set(slog.LevelDebug)
 				return slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-					Level: slog.LevelDebug,
-				}))
+					Level: level,
+				})), level
 			},
+			logger.NewLevelHandler,
 			Config,
 			jwter.New,
 			builder.SetupBuilder,
@@ -61,25 +73,38 @@ func (s *PVZIntegrationSuite) SetupSuite() {
 			db.NewPostgresConnect,
 
 			grpcconn.Provide,
+			gateway.New,
 
 			fx.Annotate(metrics.New, fx.As(new(metrics.Metrics))),
+			tracing.New,
 			middleware.NewAuthMiddleware,
 			middleware.NewMetricsMiddleware,
+			middleware.NewRequestIDMiddleware,
+			middleware.NewTracingMiddleware,
+			middleware.NewIdempotencyMiddleware,
+			middleware.NewRBACMiddleware,
+			rbac.NewPolicy,
+			notifier.New,
+
+			fx.Annotate(idempotency.NewPostgresStore, fx.As(new(idempotency.Store))),
 
 			fx.Annotate(jwter.New, fx.As(new(auth.JWTer))),
 			authHandler.NewHandler,
-			fx.Annotate(authUsecase.NewUsecase, fx.As(new(auth.Usecase))),
+			fx.Annotate(authUsecase.NewUsecase, fx.As(new(auth.Usecase)), fx.As(new(auth.APIKeyAuthenticator)), fx.As(new(auth.SessionChecker))),
 			fx.Annotate(authRepository.NewRepository, fx.As(new(auth.Repository))),
 
 			pvzHandler.NewHandler,
 			fx.Annotate(pvzUsecase.NewUsecase, fx.As(new(pvz.Usecase))),
 			fx.Annotate(pvzRepository.NewRepository, fx.As(new(pvz.Repository))),
+			fx.Annotate(events.NewBus, fx.As(fx.Self()), fx.As(new(pvz.EventBus))),
 
 			mainServer.NewRouter,
 		),
+		fx.Populate(&s.bus),
 		fx.Invoke(
 			migrations.RunMigrations,
 			mainServer.RunServer,
+			events.Run,
 		),
 	)
 
@@ -107,12 +132,19 @@ func (s *PVZIntegrationSuite) authenticate(role *models.DummyLogin) string {
 }
 
 func (s *PVZIntegrationSuite) makeRequest(method string, path string, body []byte, token string) (*http.Response, error) {
+	return s.makeRequestWithHeaders(method, path, body, token, nil)
+}
+
+func (s *PVZIntegrationSuite) makeRequestWithHeaders(method string, path string, body []byte, token string, headers map[string]string) (*http.Response, error) {
 	req, err := http.NewRequestWithContext(s.T().Context(), method, s.serverURL+"/api/v1"+path, bytes.NewBuffer(body))
 	require.NoError(s.T(), err)
 	req.Header.Set("Content-Type", "application/json")
 	if token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
 	return s.client.Do(req)
 }
 
@@ -148,6 +180,8 @@ func (s *PVZIntegrationSuite) TestFullPVZWorkflow() {
 	createdReception := &models.Reception{}
 	err = json.NewDecoder(resp.Body).Decode(&createdReception)
 	require.NoError(t, err)
+	receptionETag := resp.Header.Get("ETag")
+	require.NotEmpty(t, receptionETag)
 
 	for i := 1; i <= 50; i++ {
 		var typeProduct models.ProductType
@@ -174,7 +208,106 @@ func (s *PVZIntegrationSuite) TestFullPVZWorkflow() {
 
 	resp, err = s.makeRequest(http.MethodPost, fmt.Sprintf("/pvz/%s/close_last_reception", pvzID), []byte{}, employeeToken)
 	require.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionRequired, resp.StatusCode)
+
+	resp, err = s.makeRequestWithHeaders(http.MethodPost, fmt.Sprintf("/pvz/%s/close_last_reception", pvzID), []byte{}, employeeToken,
+		map[string]string{"If-Match": `"0"`})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// every added product changed the reception
+	resp, err = s.makeRequestWithHeaders(http.MethodPost, fmt.Sprintf("/pvz/%s/close_last_reception", pvzID), []byte{}, employeeToken,
+		map[string]string{"If-Match": receptionETag})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	resp, err = s.makeRequest(http.MethodGet, fmt.Sprintf("/pvz/%s/last_reception", pvzID), nil, employeeToken)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	currentETag := resp.Header.Get("ETag")
+	assert.NotEqual(t, receptionETag, currentETag)
+
+	// a tag list matches when any of its versions is current
+	resp, err = s.makeRequestWithHeaders(http.MethodPost, fmt.Sprintf("/pvz/%s/close_last_reception", pvzID), []byte{}, employeeToken,
+		map[string]string{"If-Match": receptionETag + ", " + currentETag})
+	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEqual(t, currentETag, resp.Header.Get("ETag"))
+
+	// closing it again with the version read before the close is stale
+	resp, err = s.makeRequestWithHeaders(http.MethodPost, fmt.Sprintf("/pvz/%s/close_last_reception", pvzID), []byte{}, employeeToken,
+		map[string]string{"If-Match": currentETag})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	// "*" only requires an open reception to exist
+	resp, err = s.makeRequestWithHeaders(http.MethodPost, fmt.Sprintf("/pvz/%s/close_last_reception", pvzID), []byte{}, employeeToken,
+		map[string]string{"If-Match": "*"})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+// TestEventsArriveInIDOrder writes to several pvz at once. Event ids are
+// taken under a lock held until commit, so they are committed and
+// delivered in id order and a watcher resuming after the last id it saw
+// misses none.
+func (s *PVZIntegrationSuite) TestEventsArriveInIDOrder() {
+	t := s.T()
+	const pvzCount, productsPerPvz = 4, 25
+
+	moderatorToken := s.authenticate(&models.DummyLogin{Role: models.RoleModerator})
+	employeeToken := s.authenticate(&models.DummyLogin{Role: models.RoleEmployee})
+
+	pvzIDs := make([]uuid.UUID, 0, pvzCount)
+	for range pvzCount {
+		pvzBody, err := json.Marshal(models.Pvz{ID: uuid.New(), RegistrationDate: time.Now(), City: models.CityMoscow})
+		require.NoError(t, err)
+		resp, err := s.makeRequest(http.MethodPost, "/pvz", pvzBody, moderatorToken)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		createdPvz := &models.Pvz{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(createdPvz))
+		resp.Body.Close()
+		pvzIDs = append(pvzIDs, createdPvz.ID)
+	}
+
+	live, unsubscribe := s.bus.Subscribe(&models.PvzEventFilter{PvzIDs: pvzIDs})
+	defer unsubscribe()
+
+	var wg sync.WaitGroup
+	for _, pvzID := range pvzIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			receptionBody, _ := json.Marshal(&models.ReceptionRequest{PvzID: pvzID})
+			resp, err := s.makeRequest(http.MethodPost, "/receptions", receptionBody, employeeToken)
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.StatusCreated, resp.StatusCode)
+				resp.Body.Close()
+			}
+			productBody, _ := json.Marshal(&models.ProductRequest{Type: models.TypeShoes, PvzID: pvzID})
+			for range productsPerPvz {
+				resp, err := s.makeRequest(http.MethodPost, "/products", productBody, employeeToken)
+				if assert.NoError(t, err) {
+					assert.Equal(t, http.StatusCreated, resp.StatusCode)
+					resp.Body.Close()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var lastID int64
+	for range pvzCount * (productsPerPvz + 1) {
+		select {
+		case event := <-live:
+			require.NotNil(t, event)
+			assert.Greater(t, event.ID, lastID)
+			lastID = event.ID
+		case <-time.After(5 * time.Second):
+			t.Fatal("missing events")
+		}
+	}
 }
 
 func TestPVZWorkflow(t *testing.T) {